@@ -0,0 +1,46 @@
+package hydrate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// analyticsPepperInfo separates analytics peppers from other keys derived with HKDF.
+const analyticsPepperInfo = "hydrate analytics pepper "
+
+// TokenizeForAnalytics returns a keyed, non-reversible identifier for the token, stable for a given token and pepper.
+// The identifier is an HMAC-SHA256 of the token and cannot be used to reconstruct or verify it; keep the pepper secret.
+func TokenizeForAnalytics(token string, pepper []byte) string {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(token))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AnalyticsPepper derives the pepper for the rotation period containing the given time from a root secret.
+// Identifiers produced with peppers from different periods cannot be linked without the root secret.
+func AnalyticsPepper(root []byte, period time.Duration, at time.Time) ([]byte, error) {
+	if len(root) == 0 {
+		return nil, ErrInvalidSecretKey
+	}
+
+	if period <= 0 {
+		return nil, ErrInvalidTokenConfig
+	}
+
+	epoch := at.UnixNano() / int64(period)
+	pepper := make([]byte, derivedKeySize)
+
+	reader := hkdf.New(sha256.New, root, nil, []byte(analyticsPepperInfo+strconv.FormatInt(epoch, 10)))
+	if _, err := io.ReadFull(reader, pepper); err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	return pepper, nil
+}
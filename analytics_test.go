@@ -0,0 +1,65 @@
+package hydrate
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenizeForAnalytics(t *testing.T) {
+	token, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pepper := []byte("analytics pepper")
+	id := TokenizeForAnalytics(string(token), pepper)
+
+	if id != TokenizeForAnalytics(string(token), pepper) {
+		t.Errorf("Expected analytics ID to be stable for the same token and pepper")
+	}
+
+	if id == TokenizeForAnalytics(string(token), []byte("other pepper")) {
+		t.Errorf("Expected analytics ID to change with the pepper")
+	}
+
+	for _, segment := range strings.Split(string(token), ".") {
+		if strings.Contains(id, segment) {
+			t.Errorf("Expected analytics ID not to contain token segment %s", segment)
+		}
+	}
+
+	if _, err := config.ParseTokenString(id); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestAnalyticsPepperRotation(t *testing.T) {
+	root := []byte("analytics root secret")
+	now := time.Unix(1700000000, 0)
+
+	current, err := AnalyticsPepper(root, time.Hour, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sameHour, _ := AnalyticsPepper(root, time.Hour, now.Add(time.Minute))
+	if !bytes.Equal(current, sameHour) {
+		t.Errorf("Expected pepper to be stable within the rotation period")
+	}
+
+	nextHour, _ := AnalyticsPepper(root, time.Hour, now.Add(time.Hour))
+	if bytes.Equal(current, nextHour) {
+		t.Errorf("Expected pepper to change after the rotation period")
+	}
+
+	if _, err := AnalyticsPepper(nil, time.Hour, now); err != ErrInvalidSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidSecretKey, err)
+	}
+
+	if _, err := AnalyticsPepper(root, 0, now); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
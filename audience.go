@@ -0,0 +1,85 @@
+package hydrate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// audienceExpiryClaim is the claim holding the per-audience expiration times.
+const audienceExpiryClaim = "aud_exp"
+
+// WithAudiences optionally sets the audiences of the token, written to the aud claim as a JSON array.
+// It replaces the single Audience of WithStandardClaims.
+func WithAudiences(audiences []string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(audiences) == 0 {
+			return fmt.Errorf("%w: audiences must be non-empty", ErrInvalidTokenConfig)
+		}
+
+		for _, audience := range audiences {
+			if audience == "" {
+				return fmt.Errorf("%w: audiences must be non-empty", ErrInvalidTokenConfig)
+			}
+		}
+
+		t.audiences = append([]string(nil), audiences...)
+		return nil
+	}
+}
+
+// WithAudienceExpiry optionally shortens the lifetime of the token for a specific audience.
+// The expiration is written to the "aud_exp" claim and recomputed every time the token is regenerated.
+func WithAudienceExpiry(audience string, lifetime time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if audience == "" || lifetime <= 0 {
+			return ErrInvalidAudienceExpiry
+		}
+
+		if t.audienceExpiry == nil {
+			t.audienceExpiry = make(map[string]time.Duration)
+		}
+
+		t.audienceExpiry[audience] = lifetime
+		return nil
+	}
+}
+
+// ValidateAudienceExpiry checks the token has not expired for the provided audience.
+// The earlier of the exp claim and the audience-specific expiration is enforced.
+// Tokens without an expiration for the audience only have their exp claim checked. Time is read from the configured clock.
+func (t *TokenConfig) ValidateAudienceExpiry(claims jwt.MapClaims, audience string) error {
+	now := t.now().Unix()
+
+	if exp, ok := numericClaim(claims["exp"]); ok && exp < now {
+		return ErrTokenExpired
+	}
+
+	audienceExpiry, ok := claims[audienceExpiryClaim].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if exp, ok := numericClaim(audienceExpiry[audience]); ok && exp < now {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+// updateAudienceExpiry sets the per-audience expiration times relative to now.
+// If no audience expiry is configured, the claims are left untouched.
+func (t *TokenConfig) updateAudienceExpiry(claims jwt.MapClaims) {
+	if len(t.audienceExpiry) == 0 {
+		return
+	}
+
+	now := t.now()
+	audienceExpiry := make(map[string]interface{}, len(t.audienceExpiry))
+	for audience, lifetime := range t.audienceExpiry {
+		audienceExpiry[audience] = now.Add(lifetime).Unix()
+	}
+
+	claims[audienceExpiryClaim] = audienceExpiry
+}
@@ -0,0 +1,246 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupAudienceToken(t *testing.T, options ...func(*TokenConfig) error) *TokenConfig {
+	config, err := NewToken(append(options,
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithCustomClaims(map[string]interface{}{
+			"aud": []string{"app-a", "app-b"},
+		}),
+		WithAudienceExpiry("app-b", 10*time.Minute),
+	)...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestValidAudienceExpiry(t *testing.T) {
+	config := setupAudienceToken(t)
+
+	_, err := config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	audienceExpiry, ok := claims[audienceExpiryClaim].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected %s claim, got %v", audienceExpiryClaim, claims[audienceExpiryClaim])
+	}
+
+	exp, _ := numericClaim(audienceExpiry["app-b"])
+	if expected := time.Now().Add(10 * time.Minute).Unix(); exp < expected-1 || exp > expected {
+		t.Errorf("Expected app-b expiry near %d, got %d", expected, exp)
+	}
+
+	for _, audience := range []string{"app-a", "app-b"} {
+		if err := config.ValidateAudienceExpiry(claims, audience); err != nil {
+			t.Errorf("Unexpected error for %s: %v", audience, err)
+		}
+	}
+}
+
+func TestExpiredAudienceExpiry(t *testing.T) {
+	config := setupAudienceToken(t)
+	claims := jwt.MapClaims{
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+		audienceExpiryClaim: map[string]interface{}{
+			"app-b": float64(time.Now().Add(-time.Minute).Unix()),
+		},
+	}
+
+	if err := config.ValidateAudienceExpiry(claims, "app-a"); err != nil {
+		t.Errorf("Unexpected error for app-a: %v", err)
+	}
+
+	if err := config.ValidateAudienceExpiry(claims, "app-b"); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestAudienceExpiryLaterThanExp(t *testing.T) {
+	config := setupAudienceToken(t)
+	claims := jwt.MapClaims{
+		"exp": float64(time.Now().Add(-time.Minute).Unix()),
+		audienceExpiryClaim: map[string]interface{}{
+			"app-b": float64(time.Now().Add(time.Hour).Unix()),
+		},
+	}
+
+	if err := config.ValidateAudienceExpiry(claims, "app-b"); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestAudienceExpiryUsesClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config := setupAudienceToken(t, WithClock(clock))
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	clock.Advance(15 * time.Minute)
+
+	if err := config.ValidateAudienceExpiry(claims, "app-a"); err != nil {
+		t.Errorf("Unexpected error for app-a: %v", err)
+	}
+
+	if err := config.ValidateAudienceExpiry(claims, "app-b"); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestMissingAudienceExpiry(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	if err := config.ValidateAudienceExpiry(claims, "test"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestAudienceExpirySurvivesRefresh(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config := setupAudienceToken(t, WithClock(clock))
+
+	_, err := config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(1 * time.Second)
+
+	_, err = config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error regenerating token: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	audienceExpiry, ok := claims[audienceExpiryClaim].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected %s claim, got %v", audienceExpiryClaim, claims[audienceExpiryClaim])
+	}
+
+	exp, _ := numericClaim(audienceExpiry["app-b"])
+	if expected := clock.Now().Add(10 * time.Minute).Unix(); exp != expected {
+		t.Errorf("Expected recomputed app-b expiry near %d, got %d", expected, exp)
+	}
+}
+
+func TestInvalidAudienceExpiry(t *testing.T) {
+	_, err := NewToken(
+		SecretKey(secretKey),
+		WithAudienceExpiry("", time.Minute),
+	)
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestAudiencesRoundTrip(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Audience:  "ignored",
+		}),
+		WithAudiences([]string{"billing", "search"}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := config.GenerateToken(); err != nil {
+			t.Fatalf("Unexpected error generating token: %v", err)
+		}
+
+		claims, err := config.ExtractClaims()
+		if err != nil {
+			t.Fatalf("Unexpected error extracting claims: %v", err)
+		}
+
+		if audiences := stringSlice(claims["aud"]); len(audiences) != 2 || audiences[0] != "billing" || audiences[1] != "search" {
+			t.Errorf("Expected aud to be [billing search], got %v", claims["aud"])
+		}
+	}
+}
+
+func TestAudienceMatchModes(t *testing.T) {
+	cases := []struct {
+		name     string
+		aud      interface{}
+		match    AudienceMatch
+		expected error
+	}{
+		{"any with partial match", []string{"billing", "web"}, MatchAny, nil},
+		{"any without match", []string{"web"}, MatchAny, ErrAudienceMismatch},
+		{"all with every audience", []string{"search", "web", "billing"}, MatchAll, nil},
+		{"all with partial match", []string{"billing", "web"}, MatchAll, ErrAudienceMismatch},
+		{"all with string audience", "billing", MatchAll, ErrAudienceMismatch},
+		{"all without audience", nil, MatchAll, ErrAudienceMismatch},
+	}
+
+	for _, c := range cases {
+		claims := jwt.MapClaims{}
+		if c.aud != nil {
+			claims["aud"] = c.aud
+		}
+
+		config := newExpectingVerifier(t, signTestClaims(t, claims),
+			WithExpectedAudiences([]string{"billing", "search"}, c.match))
+
+		if err := config.Validate(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestInvalidAudiences(t *testing.T) {
+	options := []func(*TokenConfig) error{
+		WithAudiences(nil),
+		WithAudiences([]string{"billing", ""}),
+		WithExpectedAudiences(nil, MatchAny),
+		WithExpectedAudiences([]string{"billing"}, AudienceMatch(7)),
+	}
+
+	for _, option := range options {
+		if _, err := NewToken(SecretKey(secretKey), option); !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package hydrate
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// defaultAccessCookie is the name of the access token cookie unless CookieOptions sets one.
+	defaultAccessCookie = "access_token"
+	// defaultRefreshCookie is the name of the refresh token cookie unless CookieOptions sets one.
+	defaultRefreshCookie = "refresh_token"
+)
+
+// CookieOptions describes the cookies holding a token pair. Cookies are always HttpOnly.
+type CookieOptions struct {
+	AccessName  string        // Name of the access token cookie, "access_token" if empty
+	RefreshName string        // Name of the refresh token cookie, "refresh_token" if empty
+	Path        string        // Path attribute of both cookies
+	Domain      string        // Domain attribute of both cookies
+	Secure      bool          // Whether the cookies are only sent over HTTPS
+	SameSite    http.SameSite // SameSite attribute of both cookies, Lax if zero
+}
+
+// SetAuthCookies writes the access and refresh tokens of the pair as HttpOnly cookies.
+// Each cookie expires with its token, per the exp claim; tokens without exp get session cookies.
+func (t *TokenConfig) SetAuthCookies(w http.ResponseWriter, pair *TokenPair, opts CookieOptions) error {
+	if pair == nil {
+		return ErrTokenNotGenerated
+	}
+
+	accessName, refreshName := opts.names()
+
+	access, err := t.authCookie(accessName, string(pair.AccessToken), opts)
+	if err != nil {
+		return err
+	}
+
+	refresh, err := t.authCookie(refreshName, string(pair.RefreshToken), opts)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, access)
+	http.SetCookie(w, refresh)
+	return nil
+}
+
+// ClearAuthCookies expires the access and refresh token cookies, e.g. on logout.
+func ClearAuthCookies(w http.ResponseWriter, opts CookieOptions) {
+	accessName, refreshName := opts.names()
+	for _, name := range []string{accessName, refreshName} {
+		cookie := opts.cookie(name, "")
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(0, 0)
+
+		http.SetCookie(w, cookie)
+	}
+}
+
+// RefreshFromCookie rotates the token pair using the refresh token cookie of the request, then rewrites both cookies.
+// The refresh configuration is set to hold the refresh token of the cookie before RotateTokenPair runs.
+// Returns ErrNoTokenFound without the cookie, or the error of RotateTokenPair.
+func (t *TokenConfig) RefreshFromCookie(w http.ResponseWriter, r *http.Request, refreshConfig *TokenConfig, opts CookieOptions) (*TokenPair, error) {
+	if refreshConfig == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	_, refreshName := opts.names()
+	cookie, err := r.Cookie(refreshName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoTokenFound
+	}
+
+	pair, err := t.rotatePresented(refreshConfig, cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetAuthCookies(w, pair, opts); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// rotatePresented sets the refresh configuration to hold the presented refresh token and rotates the pair with it.
+func (t *TokenConfig) rotatePresented(refreshConfig *TokenConfig, refreshToken string) (*TokenPair, error) {
+	claims := make(jwt.MapClaims)
+	if _, _, err := new(jwt.Parser).ParseUnverified(refreshToken, claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	refreshConfig.setToken(refreshToken, claims)
+
+	accessToken, rotatedToken, err := t.RotateTokenPair(refreshConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: rotatedToken, PairID: t.pairID}, nil
+}
+
+// authCookie returns the cookie holding the token, expiring with it.
+func (t *TokenConfig) authCookie(name, token string, opts CookieOptions) (*http.Cookie, error) {
+	claims := make(jwt.MapClaims)
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	cookie := opts.cookie(name, token)
+	if exp, ok := numericClaim(claims["exp"]); ok {
+		cookie.Expires = time.Unix(exp, 0)
+		cookie.MaxAge = int(exp - t.now().Unix())
+		if cookie.MaxAge <= 0 {
+			cookie.MaxAge = -1
+		}
+	}
+
+	return cookie, nil
+}
+
+// names returns the names of the access and refresh token cookies.
+func (o CookieOptions) names() (string, string) {
+	accessName, refreshName := o.AccessName, o.RefreshName
+	if accessName == "" {
+		accessName = defaultAccessCookie
+	}
+	if refreshName == "" {
+		refreshName = defaultRefreshCookie
+	}
+
+	return accessName, refreshName
+}
+
+// cookie returns an HttpOnly cookie with the attributes of the options.
+func (o CookieOptions) cookie(name, value string) *http.Cookie {
+	sameSite := o.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		Secure:   o.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
@@ -0,0 +1,107 @@
+package hydrate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setupCookiePair(t *testing.T, clock *FakeClock) (*TokenConfig, *TokenConfig, *TokenPair) {
+	accessConfig, refreshConfig, err := setupTokens(t, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pair, err := IssueTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+
+	return accessConfig, refreshConfig, pair
+}
+
+func TestSetAuthCookies(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	accessConfig, _, pair := setupCookiePair(t, clock)
+	clock.Advance(10 * time.Minute)
+
+	opts := CookieOptions{AccessName: "at", Path: "/api", Domain: "example.com", Secure: true, SameSite: http.SameSiteStrictMode}
+	recorder := httptest.NewRecorder()
+	if err := accessConfig.SetAuthCookies(recorder, pair, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("Expected two cookies, got %d", len(cookies))
+	}
+
+	cases := []struct {
+		name   string
+		value  []byte
+		maxAge int
+	}{
+		{"at", pair.AccessToken, int((50 * time.Minute).Seconds())},
+		{"refresh_token", pair.RefreshToken, int((24*time.Hour - 10*time.Minute).Seconds())},
+	}
+
+	for i, c := range cases {
+		cookie := cookies[i]
+		if cookie.Name != c.name || cookie.Value != string(c.value) {
+			t.Errorf("Expected cookie %s with its token, got %s=%s", c.name, cookie.Name, cookie.Value)
+		}
+
+		if cookie.MaxAge < c.maxAge-1 || cookie.MaxAge > c.maxAge {
+			t.Errorf("%s: Expected Max-Age near %d, got %d", c.name, c.maxAge, cookie.MaxAge)
+		}
+
+		if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || cookie.Path != "/api" || cookie.Domain != "example.com" {
+			t.Errorf("%s: Unexpected attributes %s", c.name, cookie.String())
+		}
+	}
+}
+
+func TestClearAuthCookies(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ClearAuthCookies(recorder, CookieOptions{})
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].Name != "access_token" || cookies[1].Name != "refresh_token" {
+		t.Fatalf("Expected the default cookies to be cleared, got %v", cookies)
+	}
+
+	for _, cookie := range cookies {
+		if cookie.Value != "" || cookie.MaxAge != -1 || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("Expected %s to be expired, got %s", cookie.Name, cookie.String())
+		}
+	}
+}
+
+func TestRefreshFromCookie(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	accessConfig, refreshConfig, pair := setupCookiePair(t, clock)
+	clock.Advance(1 * time.Second)
+
+	request := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	request.AddCookie(&http.Cookie{Name: "refresh_token", Value: string(pair.RefreshToken)})
+
+	recorder := httptest.NewRecorder()
+	rotated, err := accessConfig.RefreshFromCookie(recorder, request, refreshConfig, CookieOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(rotated.RefreshToken) == string(pair.RefreshToken) || rotated.PairID != pair.PairID {
+		t.Errorf("Expected a rotated refresh token in the same pair")
+	}
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].Value != string(rotated.AccessToken) || cookies[1].Value != string(rotated.RefreshToken) {
+		t.Errorf("Expected both cookies to be rewritten, got %v", cookies)
+	}
+
+	if _, err := accessConfig.RefreshFromCookie(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/refresh", nil), refreshConfig, CookieOptions{}); err != ErrNoTokenFound {
+		t.Errorf("Expected error: %v, got: %v", ErrNoTokenFound, err)
+	}
+}
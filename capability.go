@@ -0,0 +1,123 @@
+package hydrate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// capabilityMethodClaim is the claim holding the HTTP method a capability token authorizes.
+	capabilityMethodClaim = "cap_method"
+	// capabilityPathClaim is the claim holding the path pattern a capability token authorizes.
+	capabilityPathClaim = "cap_path"
+)
+
+// GenerateCapabilityToken signs a token authorizing one HTTP method and path for the ttl, like a signed URL carried
+// in the Authorization header. The path is exact, e.g. "/uploads/abc123", or a template whose "{name}" segments
+// match any single segment, e.g. "/uploads/{id}". The extra claims are added, except exp, iat, and the capability
+// claims, which are set by the function. The token held by the configuration is left untouched.
+// Returns the token, or an error if one occurs.
+func (t *TokenConfig) GenerateCapabilityToken(method, path string, ttl time.Duration, extra map[string]interface{}) ([]byte, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	if !t.canSign() {
+		return nil, ErrSigningNotConfigured
+	}
+
+	if method == "" || ttl <= 0 {
+		return nil, fmt.Errorf("%w: capability tokens need a method and a positive lifetime", ErrInvalidTokenConfig)
+	}
+
+	if !validPathPattern(path) {
+		return nil, fmt.Errorf("%w: capability path %q must be absolute and clean", ErrInvalidTokenConfig, path)
+	}
+
+	claims := make(jwt.MapClaims, len(extra)+4)
+	copyCustomClaims(&claims, extra)
+
+	now := t.now()
+	claims[capabilityMethodClaim] = strings.ToUpper(method)
+	claims[capabilityPathClaim] = path
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+
+	signedToken, err := t.signClaims(claims, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(signedToken), nil
+}
+
+// RequireCapability returns middleware rejecting requests whose method and path are not the ones authorized by
+// the capability token, with 403 Forbidden and ErrCapabilityMismatch. It reads the claims from the context, so it
+// must run behind an AuthMiddleware. Paths with empty, "." or ".." segments never match, so a token for
+// "/uploads/abc123" does not authorize "/uploads/abc123/../secret".
+func RequireCapability() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := ClaimsFromContext(r.Context())
+			if !matchCapability(claims, r.Method, r.URL.Path) {
+				writeAuthError(w, r, http.StatusForbidden, ErrCapabilityMismatch)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchCapability reports whether the capability claims authorize the method and path.
+// The pattern is anchored at both ends: every segment of the path must match the segment of the pattern at the same position.
+func matchCapability(claims jwt.MapClaims, method, path string) bool {
+	capMethod, _ := claims[capabilityMethodClaim].(string)
+	pattern, _ := claims[capabilityPathClaim].(string)
+	if capMethod == "" || capMethod != method || !validPathPattern(pattern) || !validPathPattern(path) {
+		return false
+	}
+
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if !isPathParam(segment) && segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validPathPattern reports whether the path is absolute, without empty, "." or ".." segments.
+// "/" alone is valid.
+func validPathPattern(path string) bool {
+	if path == "/" {
+		return true
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		return false
+	}
+
+	for _, segment := range strings.Split(path[1:], "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPathParam reports whether the pattern segment is a "{name}" template parameter.
+func isPathParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
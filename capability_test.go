@@ -0,0 +1,107 @@
+package hydrate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serveCapability(t *testing.T, token []byte, method, target string) int {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware, err := NewAuthMiddleware(verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler := middleware.Handler(RequireCapability()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	request := httptest.NewRequest(method, target, nil)
+	request.Header.Set("Authorization", "Bearer "+string(token))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder.Code
+}
+
+func TestCapabilityToken(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	exact, err := config.GenerateCapabilityToken("put", "/uploads/abc123", 5*time.Minute, map[string]interface{}{"sub": "user"})
+	if err != nil {
+		t.Fatalf("Unexpected error generating capability token: %v", err)
+	}
+
+	template, err := config.GenerateCapabilityToken(http.MethodGet, "/uploads/{id}/parts/{part}", 5*time.Minute, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error generating capability token: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		token  []byte
+		method string
+		target string
+		status int
+	}{
+		{"exact match", exact, http.MethodPut, "/uploads/abc123", http.StatusNoContent},
+		{"other method", exact, http.MethodGet, "/uploads/abc123", http.StatusForbidden},
+		{"other path", exact, http.MethodPut, "/uploads/abc124", http.StatusForbidden},
+		{"longer path", exact, http.MethodPut, "/uploads/abc123/more", http.StatusForbidden},
+		{"prefix path", exact, http.MethodPut, "/uploads", http.StatusForbidden},
+		{"trailing slash", exact, http.MethodPut, "/uploads/abc123/", http.StatusForbidden},
+		{"traversal", exact, http.MethodPut, "/uploads/abc123/../secret", http.StatusForbidden},
+		{"encoded traversal", exact, http.MethodPut, "/uploads/abc123%2F..%2Fsecret", http.StatusForbidden},
+		{"dot segment", exact, http.MethodPut, "/uploads/./abc123", http.StatusForbidden},
+		{"template match", template, http.MethodGet, "/uploads/abc123/parts/7", http.StatusNoContent},
+		{"template traversal", template, http.MethodGet, "/uploads/../parts/7", http.StatusForbidden},
+		{"template empty segment", template, http.MethodGet, "/uploads//parts/7", http.StatusForbidden},
+		{"template extra segment", template, http.MethodGet, "/uploads/abc123/parts/7/8", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		if status := serveCapability(t, c.token, c.method, c.target); status != c.status {
+			t.Errorf("%s: Expected status %d, got %d", c.name, c.status, status)
+		}
+	}
+
+	if status := serveCapability(t, []byte(*config.token), http.MethodPut, "/uploads/abc123"); status != http.StatusForbidden {
+		t.Errorf("Expected a token without capability to be forbidden, got %d", status)
+	}
+}
+
+func TestInvalidCapabilityToken(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		ttl    time.Duration
+	}{
+		{"", "/uploads", time.Minute},
+		{http.MethodPut, "/uploads", 0},
+		{http.MethodPut, "uploads", time.Minute},
+		{http.MethodPut, "/uploads/../secret", time.Minute},
+		{http.MethodPut, "/uploads/", time.Minute},
+	}
+
+	for _, c := range cases {
+		if _, err := config.GenerateCapabilityToken(c.method, c.path, c.ttl, nil); !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("%s %s: Expected error: %v, got: %v", c.method, c.path, ErrInvalidTokenConfig, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package hydrate
+
+import (
+	"encoding/json"
+)
+
+// numericClaim converts a numeric claim value to an int64.
+// It is a utility function used to read time claims before and after parsing.
+func numericClaim(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			f, err := v.Float64()
+			if err != nil {
+				return 0, false
+			}
+			return int64(f), true
+		}
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// stringSlice converts a claim value to a slice of strings.
+// It is a utility function used to read list claims before and after parsing.
+func stringSlice(value interface{}) []string {
+	switch values := value.(type) {
+	case []string:
+		return values
+	case []interface{}:
+		strValues := make([]string, 0, len(values))
+		for _, v := range values {
+			if strValue, ok := v.(string); ok {
+				strValues = append(strValues, strValue)
+			}
+		}
+		return strValues
+	default:
+		return nil
+	}
+}
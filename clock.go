@@ -0,0 +1,103 @@
+package hydrate
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Clock tells the configuration the current time.
+// It is used for expiration math and time-based claim checks, so tests can control time.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default clock, reading the system time.
+type realClock struct{}
+
+// Now returns the current system time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock optionally sets the clock used to compute and check the exp, iat, and nbf claims.
+// If you don't call this function, the system clock is used.
+func WithClock(clock Clock) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if clock == nil {
+			return ErrClockNil
+		}
+
+		t.clock = clock
+		return nil
+	}
+}
+
+// FakeClock is a Clock for tests that only moves when told to.
+type FakeClock struct {
+	now  time.Time  // Current time of the clock
+	lock sync.Mutex // Synchronize access to the time
+}
+
+// NewFakeClock returns a fake clock set to the provided time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// Advance moves the fake clock forward by the provided duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// now returns the current time of the configured clock.
+func (t *TokenConfig) now() time.Time {
+	return t.clock.Now()
+}
+
+// validateTimeClaims checks the exp, iat, and nbf claims against the configured clock.
+// It mirrors jwt.MapClaims.Valid, which only reads the package-wide jwt.TimeFunc.
+func (t *TokenConfig) validateTimeClaims(claims jwt.MapClaims) error {
+	validationErr := new(jwt.ValidationError)
+	now := t.now().Unix()
+
+	if !claims.VerifyExpiresAt(now, false) {
+		validationErr.Inner = errors.New("Token is expired")
+		validationErr.Errors |= jwt.ValidationErrorExpired
+	}
+
+	if !claims.VerifyIssuedAt(now, false) {
+		validationErr.Inner = errors.New("Token used before issued")
+		validationErr.Errors |= jwt.ValidationErrorIssuedAt
+	}
+
+	if !claims.VerifyNotBefore(now, false) {
+		validationErr.Inner = errors.New("Token is not valid yet")
+		validationErr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+
+	if validationErr.Errors == 0 {
+		return nil
+	}
+
+	return validationErr
+}
+
+// wallNow returns the current wall clock time stripped of its monotonic reading.
+// The monotonic clock stops while the machine is suspended, so stored times compared with it
+// would not account for the time spent suspended; wall clock comparisons do.
+func wallNow() time.Time {
+	return time.Now().Round(0)
+}
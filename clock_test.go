@@ -0,0 +1,160 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// hasMonotonic reports whether the time carries a monotonic clock reading.
+func hasMonotonic(t time.Time) bool {
+	return t != t.Round(0)
+}
+
+func TestStoredTimesUseWallClock(t *testing.T) {
+	if hasMonotonic(wallNow()) {
+		t.Fatalf("Expected wallNow to strip the monotonic reading")
+	}
+
+	fetcher := NewCachedGroupFetcher(GroupFetcherFunc(func(ctx context.Context, subject string) ([]string, error) {
+		return []string{"admins"}, nil
+	}), time.Hour)
+
+	if _, err := fetcher.FetchGroups(context.Background(), "user"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if hasMonotonic(fetcher.cache["user"].expiresAt) {
+		t.Errorf("Expected cached groups to expire by the wall clock, so suspended time counts")
+	}
+
+	issuer, token := newRemoteIssuer(t, "v1")
+	verifier := newRemoteVerifier(t, newJWKSServer(t, issuer).URL, time.Hour, realClock{})
+
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if hasMonotonic(verifier.remoteKeys.fetchedAt) {
+		t.Errorf("Expected JWKS keys to age by the wall clock, so suspended time counts")
+	}
+}
+
+// withWallClock makes the configuration read the wall clock from the clock, simulating the wall clock jumping on
+// resume from suspend. Stores and caches take the clock through their now field instead.
+func withWallClock(clock Clock) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.wallClock = func() time.Time {
+			return clock.Now().Round(0)
+		}
+		return nil
+	}
+}
+
+func TestWallClockJumpExpiresStoredTimes(t *testing.T) {
+	wall := NewFakeClock(time.Now())
+
+	fetches := 0
+	fetcher := NewCachedGroupFetcher(GroupFetcherFunc(func(ctx context.Context, subject string) ([]string, error) {
+		fetches++
+		return []string{"admins"}, nil
+	}), time.Hour)
+	fetcher.now = wall.Now
+
+	store := NewMemoryRevocationStore()
+	store.now = wall.Now
+	if err := store.Revoke(context.Background(), "jti", wall.Now().Add(30*time.Minute)); err != nil {
+		t.Fatalf("Unexpected error revoking token: %v", err)
+	}
+
+	if _, err := fetcher.FetchGroups(context.Background(), "user"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The machine resumes two hours later; almost no time passed for the process.
+	wall.Advance(2 * time.Hour)
+
+	if _, err := fetcher.FetchGroups(context.Background(), "user"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if fetches != 2 {
+		t.Errorf("Expected the cached groups to expire across the jump, got %d fetches", fetches)
+	}
+
+	if revoked, err := store.IsRevoked(context.Background(), "jti"); err != nil || revoked {
+		t.Errorf("Expected the revocation to lapse across the jump, got %v, %v", revoked, err)
+	}
+}
+
+func TestFakeClockExpiresToken(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: clock.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithClock(clock),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	clock.Advance(30 * time.Minute)
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error regenerating token: %v", err)
+	}
+
+	if exp := config.tokenExpiry; exp != clock.Now().Add(1*time.Hour).Unix() {
+		t.Errorf("Expected exp one hour after the fake clock, got %d", exp)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(1*time.Hour + time.Second)
+
+	if err := config.Validate(); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+
+	if state := config.State(); state != StateExpired {
+		t.Errorf("Expected state %v, got %v", StateExpired, state)
+	}
+}
+
+func TestFakeClockNotBefore(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	token := signTestClaims(t, jwt.MapClaims{"nbf": clock.Now().Add(1 * time.Minute).Unix()})
+	config := newExpectingVerifier(t, token, WithClock(clock))
+
+	if err := config.Validate(); err != ErrTokenNotYetValid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenNotYetValid, err)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestNilClock(t *testing.T) {
+	_, err := NewToken(SecretKey(secretKey), WithClock(nil))
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	if !errors.Is(err, ErrClockNil) {
+		t.Errorf("Expected error: %v, got: %v", ErrClockNil, err)
+	}
+}
@@ -0,0 +1,191 @@
+package hydrate
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var update = flag.Bool("update", false, "update the golden tokens in testdata")
+
+// conformanceSecret is the fixture secret for the HMAC algorithms.
+var conformanceSecret = []byte("conformance-hmac-secret-0123456789abcdefghijklmnopqrstuvwxyz-0123")
+
+// conformanceClaims are the canonical claims signed by every algorithm.
+var conformanceClaims = jwt.StandardClaims{
+	ExpiresAt: 4102444800,
+	IssuedAt:  1700000000,
+	Issuer:    "conformance",
+	Subject:   "user",
+	Audience:  "suite",
+}
+
+// conformanceCase describes a supported signing method and how to configure its fixture key.
+type conformanceCase struct {
+	alg           string                                        // Name of the signing method
+	deterministic bool                                          // Whether the algorithm produces a stable token
+	key           func(t *testing.T) []func(*TokenConfig) error // Options configuring the fixture key
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Unexpected error reading fixture %s: %v", name, err)
+	}
+
+	return data
+}
+
+func hmacKey(method jwt.SigningMethod) func(t *testing.T) []func(*TokenConfig) error {
+	return func(t *testing.T) []func(*TokenConfig) error {
+		return []func(*TokenConfig) error{SecretKey(conformanceSecret), WithSigningMethod(method)}
+	}
+}
+
+func rsaKey(method jwt.SigningMethod) func(t *testing.T) []func(*TokenConfig) error {
+	return func(t *testing.T) []func(*TokenConfig) error {
+		return []func(*TokenConfig) error{WithRSAKeys(readFixture(t, "rsa_private.pem"), nil), WithSigningMethod(method)}
+	}
+}
+
+func ecdsaKey(fixture string) func(t *testing.T) []func(*TokenConfig) error {
+	return func(t *testing.T) []func(*TokenConfig) error {
+		key, err := jwt.ParseECPrivateKeyFromPEM(readFixture(t, fixture))
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %v", fixture, err)
+		}
+		return []func(*TokenConfig) error{WithECDSAKey(key)}
+	}
+}
+
+func ed25519Key(t *testing.T) []func(*TokenConfig) error {
+	key, err := jwt.ParseEdPrivateKeyFromPEM(readFixture(t, "ed25519_private.pem"))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing ed25519_private.pem: %v", err)
+	}
+	return []func(*TokenConfig) error{WithEd25519Key(key.(ed25519.PrivateKey))}
+}
+
+var conformanceCases = []conformanceCase{
+	{"HS256", true, hmacKey(jwt.SigningMethodHS256)},
+	{"HS384", true, hmacKey(jwt.SigningMethodHS384)},
+	{"HS512", true, hmacKey(jwt.SigningMethodHS512)},
+	{"RS256", true, rsaKey(jwt.SigningMethodRS256)},
+	{"RS384", true, rsaKey(jwt.SigningMethodRS384)},
+	{"RS512", true, rsaKey(jwt.SigningMethodRS512)},
+	{"PS256", false, rsaKey(jwt.SigningMethodPS256)},
+	{"ES256", false, ecdsaKey("ec256_private.pem")},
+	{"ES384", false, ecdsaKey("ec384_private.pem")},
+	{"ES512", false, ecdsaKey("ec521_private.pem")},
+	{"EdDSA", true, ed25519Key},
+}
+
+func newConformanceToken(t *testing.T, c conformanceCase) *TokenConfig {
+	options := append(c.key(t),
+		WithStandardClaims(conformanceClaims),
+		WithCustomClaims(map[string]interface{}{"role": "admin"}),
+	)
+
+	config, err := NewToken(options...)
+	if err != nil {
+		t.Fatalf("Unexpected error creating %s config: %v", c.alg, err)
+	}
+
+	if alg := config.signingMethod.Alg(); alg != c.alg {
+		t.Fatalf("Expected signing method %s, got %s", c.alg, alg)
+	}
+
+	return config
+}
+
+func TestConformanceGolden(t *testing.T) {
+	for _, c := range conformanceCases {
+		if !c.deterministic {
+			continue
+		}
+
+		t.Run(c.alg, func(t *testing.T) {
+			token, err := newConformanceToken(t, c).GenerateToken()
+			if err != nil {
+				t.Fatalf("Unexpected error generating token: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "golden", c.alg+".jwt")
+			if *update {
+				if err := os.WriteFile(golden, append(token, '\n'), 0o644); err != nil {
+					t.Fatalf("Unexpected error updating golden token: %v", err)
+				}
+			}
+
+			expected := strings.TrimSpace(string(readFixture(t, filepath.Join("golden", c.alg+".jwt"))))
+			if string(token) != expected {
+				t.Errorf("Expected token %s, got %s", expected, token)
+			}
+		})
+	}
+}
+
+func TestConformanceRoundTrip(t *testing.T) {
+	for _, c := range conformanceCases {
+		t.Run(c.alg, func(t *testing.T) {
+			access := newConformanceToken(t, c)
+			refresh := newConformanceToken(t, c)
+
+			if _, _, err := GenerateTokenPair(access, refresh); err != nil {
+				t.Fatalf("Unexpected error generating token pair: %v", err)
+			}
+
+			if !access.IsValid() {
+				t.Errorf("Expected token to be valid")
+			}
+
+			claims, err := access.ExtractClaims()
+			if err != nil {
+				t.Fatalf("Unexpected error extracting claims: %v", err)
+			}
+
+			if claims["sub"] != "user" || claims["role"] != "admin" {
+				t.Errorf("Expected canonical claims, got %v", claims)
+			}
+
+			if _, err := access.RefreshToken(refresh); err != nil {
+				t.Errorf("Unexpected error refreshing token: %v", err)
+			}
+
+			if !access.IsValid() {
+				t.Errorf("Expected refreshed token to be valid")
+			}
+		})
+	}
+}
+
+func TestConformanceCrossAlgorithm(t *testing.T) {
+	tokens := make(map[string]*string, len(conformanceCases))
+	for _, c := range conformanceCases {
+		config := newConformanceToken(t, c)
+		if _, err := config.GenerateToken(); err != nil {
+			t.Fatalf("Unexpected error generating %s token: %v", c.alg, err)
+		}
+		tokens[c.alg] = config.token
+	}
+
+	for _, verifier := range conformanceCases {
+		for _, signer := range conformanceCases {
+			if verifier.alg == signer.alg {
+				continue
+			}
+
+			config := newConformanceToken(t, verifier)
+			config.token = tokens[signer.alg]
+
+			if _, err := config.ExtractClaims(); err != ErrUnexpectedSigningMethod {
+				t.Errorf("Expected %s config verifying %s token to fail with %v, got: %v", verifier.alg, signer.alg, ErrUnexpectedSigningMethod, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package hydrate
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// claimsContextKey is the context key of the claims stored by WithClaims.
+type claimsContextKey struct{}
+
+// WithClaims returns a copy of the context holding the verified claims of a request.
+// Middlewares store the claims with it so handlers read them with ClaimsFromContext.
+func WithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored in the context by WithClaims.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(jwt.MapClaims)
+	return claims, ok && claims != nil
+}
+
+// SubjectFromContext returns the sub claim of the claims stored in the context.
+// Returns false without claims, or if sub is missing, empty, or not a string.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := CustomClaim[string](ctx, "sub")
+	return subject, ok && subject != ""
+}
+
+// CustomClaim returns the claim of the claims stored in the context as a T.
+// Returns false without claims, or if the claim is missing or of another type. JSON numbers decode as float64.
+func CustomClaim[T any](ctx context.Context, key string) (T, bool) {
+	var zero T
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return zero, false
+	}
+
+	value, ok := claims[key].(T)
+	if !ok {
+		return zero, false
+	}
+
+	return value, true
+}
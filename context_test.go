@@ -0,0 +1,78 @@
+package hydrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestClaimsFromContext(t *testing.T) {
+	if _, ok := ClaimsFromContext(context.Background()); ok {
+		t.Errorf("Expected no claims in an empty context")
+	}
+
+	if _, ok := SubjectFromContext(context.Background()); ok {
+		t.Errorf("Expected no subject in an empty context")
+	}
+
+	if _, ok := ClaimsFromContext(WithClaims(context.Background(), nil)); ok {
+		t.Errorf("Expected nil claims not to be found")
+	}
+
+	ctx := WithClaims(context.Background(), jwt.MapClaims{"sub": "alice"})
+	if claims, ok := ClaimsFromContext(ctx); !ok || claims["sub"] != "alice" {
+		t.Errorf("Expected the stored claims, got %v, %v", claims, ok)
+	}
+
+	if subject, ok := SubjectFromContext(ctx); !ok || subject != "alice" {
+		t.Errorf("Expected subject alice, got %q, %v", subject, ok)
+	}
+
+	cases := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"missing subject", jwt.MapClaims{}},
+		{"empty subject", jwt.MapClaims{"sub": ""}},
+		{"numeric subject", jwt.MapClaims{"sub": float64(42)}},
+	}
+
+	for _, c := range cases {
+		if subject, ok := SubjectFromContext(WithClaims(context.Background(), c.claims)); ok {
+			t.Errorf("%s: Expected no subject, got %q", c.name, subject)
+		}
+	}
+}
+
+func TestCustomClaim(t *testing.T) {
+	ctx := WithClaims(context.Background(), jwt.MapClaims{"tenant": "acme", "admin": true, "level": float64(3)})
+
+	if tenant, ok := CustomClaim[string](ctx, "tenant"); !ok || tenant != "acme" {
+		t.Errorf("Expected tenant acme, got %q, %v", tenant, ok)
+	}
+
+	if admin, ok := CustomClaim[bool](ctx, "admin"); !ok || !admin {
+		t.Errorf("Expected admin true, got %v, %v", admin, ok)
+	}
+
+	if level, ok := CustomClaim[float64](ctx, "level"); !ok || level != 3 {
+		t.Errorf("Expected level 3, got %v, %v", level, ok)
+	}
+
+	if level, ok := CustomClaim[int](ctx, "level"); ok || level != 0 {
+		t.Errorf("Expected a wrongly typed claim to be zero, got %v, %v", level, ok)
+	}
+
+	if tenant, ok := CustomClaim[bool](ctx, "tenant"); ok || tenant {
+		t.Errorf("Expected a wrongly typed claim to be zero, got %v, %v", tenant, ok)
+	}
+
+	if _, ok := CustomClaim[string](ctx, "missing"); ok {
+		t.Errorf("Expected a missing claim not to be found")
+	}
+
+	if _, ok := CustomClaim[string](context.Background(), "tenant"); ok {
+		t.Errorf("Expected no claim without claims in the context")
+	}
+}
@@ -0,0 +1,216 @@
+package hydrate
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// cookieSessionInfo separates the cookie session key from other keys derived from the secret.
+const cookieSessionInfo = "hydrate cookie session v1"
+
+// CookieSession encodes session claims into an authenticated-encrypted cookie value.
+// It is an alternative to JWTs for small applications that keep the whole session in a cookie.
+type CookieSession struct {
+	aead  cipher.AEAD // AES-GCM cipher keyed from the secret key
+	rand  io.Reader   // Source of randomness for nonces
+	clock Clock       // Clock used to expire sessions
+}
+
+// CookieSession creates a cookie session codec keyed from the configured secret key.
+// Returns the codec, or an error if one occurs.
+func (t *TokenConfig) CookieSession() (*CookieSession, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	key, err := t.deriveKey(cookieSessionInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	return &CookieSession{aead: aead, rand: t.rand, clock: t.clock}, nil
+}
+
+// Encode encrypts the claims into a cookie value that expires after the provided duration.
+// Any existing exp claim is overwritten. Returns the cookie value, or an error if one occurs.
+func (c *CookieSession) Encode(claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	sessionClaims := make(jwt.MapClaims, len(claims)+1)
+	copyCustomClaims(&sessionClaims, claims)
+	sessionClaims["exp"] = c.clock.Now().Add(ttl).Unix()
+
+	plaintext, err := json.Marshal(sessionClaims)
+	if err != nil {
+		return "", ErrClaimsInvalid
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(c.rand, nonce); err != nil {
+		return "", ErrInsecureRandomSource
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, []byte(cookieSessionInfo))
+
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode decrypts and verifies a cookie value produced by Encode.
+// Returns ErrTokenInvalid for tampered values and ErrTokenExpired for expired sessions.
+func (c *CookieSession) Decode(value string) (jwt.MapClaims, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, ErrTokenInvalid
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(cookieSessionInfo))
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	var claims jwt.MapClaims
+	if err := json.Unmarshal(plaintext, &claims); err != nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, ErrClaimsInvalid
+	}
+
+	if int64(exp) < c.clock.Now().Unix() {
+		return nil, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// sessionCookie is the encrypted cookie an AuthMiddleware reads the claims of requests from.
+type sessionCookie struct {
+	name  string         // Name of the cookie
+	codec *CookieSession // Codec encrypting the claims
+	opts  CookieOptions  // Attributes of the re-encoded cookie
+}
+
+// WithCookieSession optionally reads the claims of requests from the cookie encoded by the session, instead of
+// verifying a token. Missing cookies fail with ErrNoTokenFound, tampered ones with ErrTokenInvalid, and expired
+// ones with ErrTokenExpired, like tokens. When the handler enriches the claims of the context, e.g. by setting
+// claims["groups"], the cookie is re-encoded with them before the response is written, keeping its expiration.
+// The re-encoded cookie gets the Path, Domain, Secure, and SameSite attributes of opts.
+func WithCookieSession(name string, session *CookieSession, opts CookieOptions) func(*AuthMiddleware) error {
+	return func(m *AuthMiddleware) error {
+		if name == "" || session == nil {
+			return ErrInvalidTokenConfig
+		}
+
+		m.session = &sessionCookie{name: name, codec: session, opts: opts}
+		return nil
+	}
+}
+
+// serveSession serves the request with the claims of the session cookie, rejecting requests without a valid one.
+func (m *AuthMiddleware) serveSession(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	cookie, err := r.Cookie(m.session.name)
+	if err != nil {
+		m.reject(w, r, ErrNoTokenFound)
+		return
+	}
+
+	value, err := nonEmptyToken(cookie.Value)
+	if err != nil {
+		m.reject(w, r, err)
+		return
+	}
+
+	claims, err := m.session.codec.Decode(value)
+	if err != nil {
+		m.reject(w, r, err)
+		return
+	}
+
+	decoded, err := json.Marshal(claims)
+	if err != nil {
+		m.reject(w, r, ErrClaimsInvalid)
+		return
+	}
+
+	exp, _ := numericClaim(claims["exp"])
+	writer := &sessionWriter{ResponseWriter: w, session: m.session, claims: claims, decoded: decoded, exp: exp}
+	next.ServeHTTP(writer, r.WithContext(WithClaims(r.Context(), claims)))
+	writer.reencode()
+}
+
+// sessionWriter re-encodes the session cookie before the response is written if the handler enriched the claims.
+type sessionWriter struct {
+	http.ResponseWriter
+	session *sessionCookie // Cookie holding the claims
+	claims  jwt.MapClaims  // Claims in the context of the request, enriched in place by the handler
+	decoded []byte         // JSON of the claims as decoded from the cookie
+	exp     int64          // Expiration time of the decoded cookie
+	done    bool           // Whether the claims were already compared
+}
+
+// WriteHeader re-encodes the session cookie if needed, then writes the status.
+func (s *sessionWriter) WriteHeader(status int) {
+	s.reencode()
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write re-encodes the session cookie if needed, then writes the body.
+func (s *sessionWriter) Write(b []byte) (int, error) {
+	s.reencode()
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (s *sessionWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// reencode sets the session cookie to the claims once, if they differ from the decoded ones.
+// The response can no longer be rejected, so claims that fail to encode leave the cookie unchanged.
+func (s *sessionWriter) reencode() {
+	if s.done {
+		return
+	}
+	s.done = true
+
+	enriched, err := json.Marshal(s.claims)
+	if err != nil || bytes.Equal(enriched, s.decoded) {
+		return
+	}
+
+	now := s.session.codec.clock.Now()
+	expiresAt := time.Unix(s.exp, 0)
+
+	value, err := s.session.codec.Encode(s.claims, expiresAt.Sub(now))
+	if err != nil {
+		return
+	}
+
+	cookie := s.session.opts.cookie(s.session.name, value)
+	cookie.Expires = expiresAt
+	cookie.MaxAge = int(s.exp - now.Unix())
+	if cookie.MaxAge <= 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(s.ResponseWriter, cookie)
+}
@@ -0,0 +1,217 @@
+package hydrate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupCookieSession(t *testing.T, key []byte) *CookieSession {
+	config, err := NewToken(
+		SecretKey(key),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	session, err := config.CookieSession()
+	if err != nil {
+		t.Fatalf("Unexpected error creating cookie session: %v", err)
+	}
+
+	return session
+}
+
+func TestValidCookieSession(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+
+	value, err := session.Encode(jwt.MapClaims{"sub": "user", "role": "admin"}, time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error encoding cookie: %v", err)
+	}
+
+	claims, err := session.Decode(value)
+	if err != nil {
+		t.Errorf("Unexpected error decoding cookie: %v", err)
+	}
+
+	if claims["sub"] != "user" || claims["role"] != "admin" {
+		t.Errorf("Expected claims to round trip, got %v", claims)
+	}
+}
+
+func TestTamperedCookieSession(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+
+	value, err := session.Encode(jwt.MapClaims{"sub": "user"}, time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error encoding cookie: %v", err)
+	}
+
+	tampered := []byte(value)
+	middle := len(tampered) / 2
+	if tampered[middle] == 'A' {
+		tampered[middle] = 'B'
+	} else {
+		tampered[middle] = 'A'
+	}
+
+	_, err = session.Decode(string(tampered))
+	if err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestCookieSessionWrongSecret(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+	other := setupCookieSession(t, otherSecretKey)
+
+	value, err := session.Encode(jwt.MapClaims{"sub": "user"}, time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error encoding cookie: %v", err)
+	}
+
+	_, err = other.Decode(value)
+	if err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestExpiredCookieSession(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+
+	value, err := session.Encode(jwt.MapClaims{"sub": "user"}, -time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error encoding cookie: %v", err)
+	}
+
+	_, err = session.Decode(value)
+	if err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func serveCookieSession(t *testing.T, session *CookieSession, value string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware, err := NewAuthMiddleware(verifier, WithCookieSession("session", session, CookieOptions{Path: "/"}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if value != "" {
+		request.AddCookie(&http.Cookie{Name: "session", Value: value})
+	}
+
+	recorder := httptest.NewRecorder()
+	middleware.Handler(handler).ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestCookieSessionMiddleware(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+
+	valid, _ := session.Encode(jwt.MapClaims{"sub": "user"}, time.Hour)
+	expired, _ := session.Encode(jwt.MapClaims{"sub": "user"}, -time.Hour)
+	foreign, _ := setupCookieSession(t, otherSecretKey).Encode(jwt.MapClaims{"sub": "user"}, time.Hour)
+
+	cases := []struct {
+		name   string
+		value  string
+		status int
+		code   string
+	}{
+		{"valid cookie", valid, http.StatusNoContent, ""},
+		{"missing cookie", "", http.StatusUnauthorized, "no_token_found"},
+		{"foreign cookie", foreign, http.StatusUnauthorized, "token_invalid"},
+		{"expired cookie", expired, http.StatusUnauthorized, "token_expired"},
+	}
+
+	for _, c := range cases {
+		var rejected error
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			if subject, ok := SubjectFromContext(r.Context()); !ok || subject != "user" {
+				t.Errorf("%s: Expected the subject in the context, got %q", c.name, subject)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		verifier, _ := NewVerifier(SecretKey(secretKey))
+		middleware, err := NewAuthMiddleware(verifier,
+			WithCookieSession("session", session, CookieOptions{}),
+			WithErrorWriter(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+				rejected = err
+				w.WriteHeader(status)
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.value != "" {
+			request.AddCookie(&http.Cookie{Name: "session", Value: c.value})
+		}
+
+		recorder := httptest.NewRecorder()
+		middleware.Handler(http.HandlerFunc(handler)).ServeHTTP(recorder, request)
+
+		if recorder.Code != c.status {
+			t.Errorf("%s: Expected status %d, got %d", c.name, c.status, recorder.Code)
+		}
+
+		if c.code != "" && DescribeError(rejected).Code != c.code {
+			t.Errorf("%s: Expected code %s, got: %v", c.name, c.code, rejected)
+		}
+
+		if c.code == "" && len(recorder.Result().Cookies()) != 0 {
+			t.Errorf("%s: Expected no cookie for unchanged claims, got %v", c.name, recorder.Result().Cookies())
+		}
+	}
+
+	if _, err := NewAuthMiddleware(&TokenConfig{}, WithCookieSession("", session, CookieOptions{})); err != ErrInvalidTokenConfig {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestCookieSessionEnrichment(t *testing.T) {
+	session := setupCookieSession(t, secretKey)
+
+	value, _ := session.Encode(jwt.MapClaims{"sub": "user"}, time.Hour)
+	before, _ := session.Decode(value)
+
+	recorder := serveCookieSession(t, session, value, func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		claims["groups"] = []string{"admins"}
+		w.Write([]byte("ok"))
+	})
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Path != "/" || !cookies[0].HttpOnly {
+		t.Fatalf("Expected the session cookie to be re-encoded, got %v", cookies)
+	}
+
+	after, err := session.Decode(cookies[0].Value)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding cookie: %v", err)
+	}
+
+	if groups, _ := after["groups"].([]interface{}); len(groups) != 1 || groups[0] != "admins" {
+		t.Errorf("Expected the enriched claims in the cookie, got %v", after)
+	}
+
+	if after["exp"] != before["exp"] {
+		t.Errorf("Expected the expiration to be kept, got %v instead of %v", after["exp"], before["exp"])
+	}
+}
@@ -0,0 +1,96 @@
+package hydrate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultRefreshThreshold is the remaining lifetime below which TokenCredentials refreshes the access token.
+const defaultRefreshThreshold = 30 * time.Second
+
+// TokenCredentials attaches the access token of a token pair to every RPC, refreshing it with the refresh token
+// when it nears its expiration. It implements the gRPC credentials.PerRPCCredentials interface without depending
+// on gRPC; pass it to grpc.WithPerRPCCredentials.
+type TokenCredentials struct {
+	accessConfig  *TokenConfig  // Configuration holding the access token
+	refreshConfig *TokenConfig  // Configuration holding the refresh token
+	threshold     time.Duration // Remaining lifetime below which the access token is refreshed
+	insecure      bool          // Whether the credentials may be sent over an insecure transport
+	lock          sync.Mutex    // Ensures a single refresh is in flight
+}
+
+// NewTokenCredentials instantiates TokenCredentials for a pair generated by GenerateTokenPair.
+// The access token is refreshed with RefreshToken, so the access configuration must be able to sign.
+func NewTokenCredentials(accessConfig, refreshConfig *TokenConfig, options ...func(*TokenCredentials) error) (*TokenCredentials, error) {
+	if accessConfig == nil || refreshConfig == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	credentials := &TokenCredentials{
+		accessConfig:  accessConfig,
+		refreshConfig: refreshConfig,
+		threshold:     defaultRefreshThreshold,
+	}
+	for _, option := range options {
+		if err := option(credentials); err != nil {
+			return nil, err
+		}
+	}
+
+	return credentials, nil
+}
+
+// WithRefreshThreshold optionally sets the remaining lifetime below which the access token is refreshed.
+// If you don't call this function, the access token is refreshed 30 seconds before it expires.
+func WithRefreshThreshold(threshold time.Duration) func(*TokenCredentials) error {
+	return func(c *TokenCredentials) error {
+		if threshold < 0 {
+			return ErrInvalidTokenConfig
+		}
+
+		c.threshold = threshold
+		return nil
+	}
+}
+
+// WithInsecureTransport optionally allows the credentials over connections without transport security, for local development.
+func WithInsecureTransport() func(*TokenCredentials) error {
+	return func(c *TokenCredentials) error {
+		c.insecure = true
+		return nil
+	}
+}
+
+// GetRequestMetadata returns the "authorization" metadata of an RPC, refreshing the access token first if needed.
+// Concurrent RPCs wait for the refresh in flight instead of starting their own.
+func (c *TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	access := c.accessConfig
+	if access.closed {
+		return nil, ErrConfigClosed
+	}
+
+	if access.token == nil {
+		return nil, ErrTokenNotGenerated
+	}
+
+	if access.tokenExpiry != 0 && time.Unix(access.tokenExpiry, 0).Sub(access.now()) < c.threshold {
+		if _, err := access.RefreshToken(c.refreshConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]string{"authorization": "Bearer " + *access.token}, nil
+}
+
+// RequireTransportSecurity reports whether the credentials require transport security, unless WithInsecureTransport is set.
+func (c *TokenCredentials) RequireTransportSecurity() bool {
+	return !c.insecure
+}
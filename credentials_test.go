@@ -0,0 +1,113 @@
+package hydrate
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupTokenCredentials(t *testing.T, clock *FakeClock, options ...func(*TokenCredentials) error) (*TokenCredentials, *TokenConfig) {
+	accessConfig, refreshConfig, err := setupTokens(t, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+
+	credentials, err := NewTokenCredentials(accessConfig, refreshConfig, options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return credentials, accessConfig
+}
+
+func bearerFromMetadata(t *testing.T, credentials *TokenCredentials) string {
+	metadata, err := credentials.GetRequestMetadata(context.Background(), "/service.Method")
+	if err != nil {
+		t.Fatalf("Unexpected error getting metadata: %v", err)
+	}
+
+	token, ok := strings.CutPrefix(metadata["authorization"], "Bearer ")
+	if !ok {
+		t.Fatalf("Expected a bearer authorization, got %q", metadata["authorization"])
+	}
+
+	return token
+}
+
+func TestTokenCredentialsRefreshNearExpiry(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	credentials, accessConfig := setupTokenCredentials(t, clock, WithRefreshThreshold(5*time.Minute))
+
+	first := bearerFromMetadata(t, credentials)
+
+	clock.Advance(50 * time.Minute)
+	if token := bearerFromMetadata(t, credentials); token != first {
+		t.Errorf("Expected the access token to be kept outside the threshold")
+	}
+
+	clock.Advance(6 * time.Minute)
+	refreshed := bearerFromMetadata(t, credentials)
+	if refreshed == first {
+		t.Fatalf("Expected the access token to be refreshed inside the threshold")
+	}
+
+	clock.Advance(10 * time.Minute)
+	if err := accessConfig.ValidateString(first); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+
+	if err := accessConfig.ValidateString(refreshed); err != nil {
+		t.Errorf("Unexpected error validating the refreshed token: %v", err)
+	}
+}
+
+func TestTokenCredentialsConcurrentRefresh(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	credentials, _ := setupTokenCredentials(t, clock)
+	first := bearerFromMetadata(t, credentials)
+
+	clock.Advance(59*time.Minute + 45*time.Second)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			metadata, err := credentials.GetRequestMetadata(context.Background())
+			if err != nil {
+				t.Errorf("Unexpected error getting metadata: %v", err)
+			}
+			tokens[i] = strings.TrimPrefix(metadata["authorization"], "Bearer ")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, token := range tokens {
+		if token == first || token != tokens[0] {
+			t.Fatalf("Expected every RPC to get the single refreshed token")
+		}
+	}
+}
+
+func TestTokenCredentialsTransportSecurity(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+
+	if credentials, _ := setupTokenCredentials(t, clock); !credentials.RequireTransportSecurity() {
+		t.Errorf("Expected transport security to be required by default")
+	}
+
+	if credentials, _ := setupTokenCredentials(t, clock, WithInsecureTransport()); credentials.RequireTransportSecurity() {
+		t.Errorf("Expected transport security not to be required")
+	}
+
+	if _, err := NewTokenCredentials(nil, nil); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
@@ -0,0 +1,95 @@
+package hydrate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// DecisionHeader is the header carrying the signed verification decision to downstream services.
+const DecisionHeader = "X-Auth-Decision"
+
+// Decision describes why a request was allowed by the gateway.
+type Decision struct {
+	Subject   string   `json:"sub"`           // Subject of the verified token
+	Policy    string   `json:"pol,omitempty"` // Name of the policy rule that passed
+	Scopes    []string `json:"scp,omitempty"` // Scopes that matched
+	ExpiresAt int64    `json:"exp"`           // Expiration time of the decision
+}
+
+// EncodeDecisionHeader encodes the decision as a compact HMAC-SHA256 signed header value.
+// The key must be at least 32 bytes and shared with the downstream services.
+func EncodeDecisionHeader(decision Decision, key []byte) (string, error) {
+	if len(key) < minSecretKeySize {
+		return "", ErrWeakSecretKey
+	}
+
+	payload, err := json.Marshal(decision)
+	if err != nil {
+		return "", ErrClaimsInvalid
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + decisionMAC(encoded, key), nil
+}
+
+// ParseDecisionHeader validates a header value produced by EncodeDecisionHeader and returns the decision.
+// Returns ErrTokenInvalid for tampered values and ErrTokenExpired once the decision expired.
+func ParseDecisionHeader(value string, key []byte) (Decision, error) {
+	return parseDecisionHeader(value, key, realClock{})
+}
+
+// ParseDecisionHeader validates the header value like the package-level ParseDecisionHeader, reading the time
+// from the configured clock.
+func (t *TokenConfig) ParseDecisionHeader(value string, key []byte) (Decision, error) {
+	return parseDecisionHeader(value, key, t.clock)
+}
+
+// parseDecisionHeader validates the header value, checking its expiration against the clock.
+func parseDecisionHeader(value string, key []byte, clock Clock) (Decision, error) {
+	if len(key) < minSecretKeySize {
+		return Decision{}, ErrWeakSecretKey
+	}
+
+	encoded, sum, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sum), []byte(decisionMAC(encoded, key))) {
+		return Decision{}, ErrTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Decision{}, ErrTokenInvalid
+	}
+
+	var decision Decision
+	if err := json.Unmarshal(payload, &decision); err != nil {
+		return Decision{}, ErrClaimsInvalid
+	}
+
+	if decision.ExpiresAt < clock.Now().Unix() {
+		return Decision{}, ErrTokenExpired
+	}
+
+	return decision, nil
+}
+
+// StripDecisionHeader removes any decision header from inbound requests before calling the next handler.
+// Wrap the gateway's verification handler with it so external clients cannot spoof a decision.
+func StripDecisionHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(DecisionHeader)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decisionMAC returns the base64url encoded HMAC-SHA256 of the encoded decision.
+func decisionMAC(encoded string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
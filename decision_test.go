@@ -0,0 +1,108 @@
+package hydrate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionHeaderRoundTrip(t *testing.T) {
+	decision := Decision{
+		Subject:   "user-1",
+		Policy:    "admin-read",
+		Scopes:    []string{"read", "write"},
+		ExpiresAt: time.Now().Add(1 * time.Minute).Unix(),
+	}
+
+	value, err := EncodeDecisionHeader(decision, secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	parsed, err := ParseDecisionHeader(value, secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(parsed, decision) {
+		t.Errorf("Expected decision %+v, got %+v", decision, parsed)
+	}
+}
+
+func TestDecisionHeaderTampered(t *testing.T) {
+	value, err := EncodeDecisionHeader(Decision{Subject: "user-1", ExpiresAt: time.Now().Add(1 * time.Minute).Unix()}, secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	forged, err := EncodeDecisionHeader(Decision{Subject: "admin", ExpiresAt: time.Now().Add(1 * time.Minute).Unix()}, otherSecretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	payload, sum, _ := strings.Cut(value, ".")
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for _, tampered := range []string{forged, forgedPayload + "." + sum, payload, ""} {
+		if _, err := ParseDecisionHeader(tampered, secretKey); err != ErrTokenInvalid {
+			t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+		}
+	}
+}
+
+func TestDecisionHeaderExpired(t *testing.T) {
+	value, err := EncodeDecisionHeader(Decision{Subject: "user-1", ExpiresAt: time.Now().Add(-1 * time.Minute).Unix()}, secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := ParseDecisionHeader(value, secretKey); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestDecisionHeaderUsesClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	value, err := EncodeDecisionHeader(Decision{Subject: "user-1", ExpiresAt: clock.Now().Add(1 * time.Minute).Unix()}, secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	config, err := NewVerifier(SecretKey(secretKey), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.ParseDecisionHeader(value, secretKey); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err := config.ParseDecisionHeader(value, secretKey); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestDecisionHeaderWeakKey(t *testing.T) {
+	if _, err := EncodeDecisionHeader(Decision{}, []byte("short")); err != ErrWeakSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrWeakSecretKey, err)
+	}
+}
+
+func TestStripDecisionHeader(t *testing.T) {
+	var received string
+	handler := StripDecisionHeader(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(DecisionHeader)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(DecisionHeader, "spoofed")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if received != "" {
+		t.Errorf("Expected the inbound decision header to be stripped, got %s", received)
+	}
+}
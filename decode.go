@@ -0,0 +1,172 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// requiredClaimTag is the struct tag marking the fields whose claim must be present, as in `hydrate:"required"`.
+const requiredClaimTag = "hydrate"
+
+// timeType is the type of time.Time fields, which may be decoded from NumericDate claims.
+var timeType = reflect.TypeOf(time.Time{})
+
+// ExtractClaimsInto verifies the token string with the configuration and decodes its claims into a T, as DecodeClaims does.
+// The token held by the configuration is left untouched.
+func ExtractClaimsInto[T any](tokenString string, t *TokenConfig) (T, error) {
+	var claims T
+	if t == nil {
+		return claims, ErrTokenConfigNil
+	}
+
+	mapClaims, err := t.ExtractClaimsFrom(tokenString)
+	if err != nil {
+		return claims, err
+	}
+
+	if err := DecodeClaims(mapClaims, &claims); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return claims, nil
+}
+
+// ExtractClaimsInto extracts the claims from the token like ExtractClaims, then decodes them into dst as DecodeClaims does.
+func (t *TokenConfig) ExtractClaimsInto(dst interface{}) error {
+	claims, err := t.ExtractClaims()
+	if err != nil {
+		return err
+	}
+
+	return DecodeClaims(claims, dst)
+}
+
+// DecodeClaims decodes the claims into dst, a pointer to a struct, honoring its json tags.
+// NumericDate claims such as exp decode into int64 or time.Time fields. Fields tagged `hydrate:"required"` must have
+// a claim. Missing required claims and claims of the wrong type return ErrClaimsInvalid, naming the claim.
+func DecodeClaims(claims jwt.MapClaims, dst interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("%w: claims must decode into a non-nil pointer", ErrInvalidTokenConfig)
+	}
+
+	prepared, err := prepareClaim(map[string]interface{}(claims), value.Type().Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(prepared)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrClaimsInvalid, err)
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: claim %q is a JSON %s, not a %s", ErrClaimsInvalid, typeErr.Field, typeErr.Value, typeErr.Type)
+		}
+
+		return fmt.Errorf("%w: %v", ErrClaimsInvalid, err)
+	}
+
+	return nil
+}
+
+// prepareClaim converts the claim for decoding into the type: NumericDates bound for time.Time become RFC 3339
+// strings, and the required fields of structs are checked. The path prefixes the names of nested claims in errors.
+func prepareClaim(value interface{}, typ reflect.Type, path string) (interface{}, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == timeType:
+		if seconds, ok := numericClaim(value); ok {
+			return time.Unix(seconds, 0).UTC().Format(time.RFC3339), nil
+		}
+	case typ.Kind() == reflect.Struct:
+		if object, ok := value.(map[string]interface{}); ok {
+			prepared := make(map[string]interface{}, len(object))
+			for key, claim := range object {
+				prepared[key] = claim
+			}
+
+			if err := prepareObject(prepared, typ, path); err != nil {
+				return nil, err
+			}
+			return prepared, nil
+		}
+	case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+		if values, ok := value.([]interface{}); ok {
+			prepared := make([]interface{}, len(values))
+			for i, claim := range values {
+				converted, err := prepareClaim(claim, typ.Elem(), fmt.Sprintf("%s%d.", path, i))
+				if err != nil {
+					return nil, err
+				}
+				prepared[i] = converted
+			}
+			return prepared, nil
+		}
+	}
+
+	return value, nil
+}
+
+// prepareObject prepares in place the claims of the object bound for the fields of the struct type.
+// Untagged embedded structs are flattened, as encoding/json does.
+func prepareObject(object map[string]interface{}, typ reflect.Type, path string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if err := prepareObject(object, embedded, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		claim, ok := object[name]
+		if !ok || claim == nil {
+			if field.Tag.Get(requiredClaimTag) == "required" {
+				return fmt.Errorf("%w: claim %q is missing", ErrClaimsInvalid, path+name)
+			}
+			continue
+		}
+
+		converted, err := prepareClaim(claim, field.Type, path+name+".")
+		if err != nil {
+			return err
+		}
+		object[name] = converted
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package hydrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type testProfile struct {
+	Name    string    `json:"name"`
+	Roles   []string  `json:"roles"`
+	Created time.Time `json:"created"`
+}
+
+type testSessionClaims struct {
+	Expires  time.Time  `json:"exp"`
+	IssuedAt *time.Time `json:"iat"`
+}
+
+type testUserClaims struct {
+	jwt.StandardClaims
+	UserID  int64         `json:"user_id" hydrate:"required"`
+	Admin   bool          `json:"admin"`
+	Profile *testProfile  `json:"profile"`
+	History []testProfile `json:"history"`
+}
+
+func TestExtractClaimsInto(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	exp := time.Now().Add(1 * time.Hour).Unix()
+	created := time.Now().Add(-24 * time.Hour).Unix()
+	token := signTestClaims(t, jwt.MapClaims{
+		"sub":     "alice",
+		"exp":     exp,
+		"user_id": 42,
+		"admin":   true,
+		"profile": map[string]interface{}{"name": "Alice", "roles": []string{"reader", "writer"}, "created": created},
+		"history": []interface{}{map[string]interface{}{"name": "A.", "created": created}},
+	})
+
+	claims, err := ExtractClaimsInto[testUserClaims](token, verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if claims.Subject != "alice" || claims.ExpiresAt != exp {
+		t.Errorf("Expected the standard claims to be decoded, got %+v", claims.StandardClaims)
+	}
+
+	if claims.UserID != 42 || !claims.Admin {
+		t.Errorf("Expected the custom claims to be decoded, got %+v", claims)
+	}
+
+	if claims.Profile == nil || claims.Profile.Name != "Alice" || len(claims.Profile.Roles) != 2 || !claims.Profile.Created.Equal(time.Unix(created, 0)) {
+		t.Errorf("Expected the nested claims to be decoded, got %+v", claims.Profile)
+	}
+
+	if len(claims.History) != 1 || !claims.History[0].Created.Equal(time.Unix(created, 0)) {
+		t.Errorf("Expected the nested list to be decoded, got %+v", claims.History)
+	}
+
+	session, err := ExtractClaimsInto[testSessionClaims](token, verifier)
+	if err != nil || !session.Expires.Equal(time.Unix(exp, 0)) || session.IssuedAt != nil {
+		t.Errorf("Expected exp to decode into a time.Time, got %+v, %v", session, err)
+	}
+
+	if _, err := ExtractClaimsInto[testUserClaims]("not-a-token", verifier); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+
+	if _, err := ExtractClaimsInto[testUserClaims](token, nil); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
+
+func TestDecodeClaimsErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		claims jwt.MapClaims
+		claim  string
+	}{
+		{"mistyped claim", jwt.MapClaims{"user_id": "42"}, `"user_id"`},
+		{"mistyped nested claim", jwt.MapClaims{"user_id": 42, "profile": map[string]interface{}{"roles": "admin"}}, `"profile.roles"`},
+		{"missing required claim", jwt.MapClaims{"admin": true}, `"user_id"`},
+	}
+
+	for _, c := range cases {
+		var claims testUserClaims
+		err := DecodeClaims(c.claims, &claims)
+		if !errors.Is(err, ErrClaimsInvalid) || !strings.Contains(err.Error(), c.claim) {
+			t.Errorf("%s: Expected error: %v naming %s, got: %v", c.name, ErrClaimsInvalid, c.claim, err)
+		}
+	}
+
+	if err := DecodeClaims(jwt.MapClaims{}, testUserClaims{}); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestExtractClaimsIntoMethod(t *testing.T) {
+	_, config, err := setupToken(t, WithCustomClaims(map[string]interface{}{"user_id": 7}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var claims testUserClaims
+	if err := config.ExtractClaimsInto(&claims); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if claims.UserID != 7 || claims.ExpiresAt == 0 {
+		t.Errorf("Expected the claims of the held token, got %+v", claims)
+	}
+}
@@ -0,0 +1,95 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// detachedHeader is the protected header of a detached, unencoded payload JWS (RFC 7797).
+type detachedHeader struct {
+	Alg  string   `json:"alg"`
+	B64  *bool    `json:"b64,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+	Kid  string   `json:"kid,omitempty"`
+}
+
+// SignDetached signs the payload as an RFC 7797 JWS with an unencoded, detached payload.
+// The key is resolved like GenerateToken's, including key files and secret providers.
+// Returns the compact serialization with an empty payload segment, or an error if one occurs.
+func (t *TokenConfig) SignDetached(payload []byte) (string, error) {
+	if t.closed {
+		return "", ErrConfigClosed
+	}
+
+	if !t.canSign() {
+		return "", ErrSigningNotConfigured
+	}
+
+	kid, key, err := t.currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	b64 := false
+	header, err := json.Marshal(detachedHeader{
+		Alg:  t.signingMethod.Alg(),
+		B64:  &b64,
+		Crit: []string{"b64"},
+		Kid:  kid,
+	})
+	if err != nil {
+		return "", ErrSigningToken
+	}
+
+	encodedHeader := jwt.EncodeSegment(header)
+	signature, err := t.signingMethod.Sign(encodedHeader+"."+string(payload), key)
+	if err != nil {
+		return "", ErrSigningToken
+	}
+
+	return encodedHeader + ".." + signature, nil
+}
+
+// VerifyDetached verifies an RFC 7797 detached JWS against the provided payload.
+// Returns an error if the JWS is malformed, uses unsupported critical headers, or the signature doesn't match.
+func (t *TokenConfig) VerifyDetached(jws string, payload []byte) error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return ErrInvalidDetachedJWS
+	}
+
+	rawHeader, err := jwt.DecodeSegment(parts[0])
+	if err != nil {
+		return ErrInvalidDetachedJWS
+	}
+
+	var header detachedHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return ErrInvalidDetachedJWS
+	}
+
+	if header.Alg != t.signingMethod.Alg() {
+		return ErrUnexpectedSigningMethod
+	}
+
+	if header.B64 == nil || *header.B64 || len(header.Crit) != 1 || header.Crit[0] != "b64" {
+		return ErrInvalidDetachedJWS
+	}
+
+	key, err := t.verificationKey(header.Kid)
+	if err != nil {
+		return err
+	}
+
+	if err := t.signingMethod.Verify(parts[0]+"."+string(payload), parts[2], key); err != nil {
+		return ErrTokenInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package hydrate
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// rfc7797Key is the HMAC key from RFC 7515 Appendix A.1, used by the RFC 7797 example.
+const rfc7797Key = "AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow"
+
+// rfc7797JWS is the detached JWS of the payload "$.02" from RFC 7797 Section 4.2.
+const rfc7797JWS = "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+
+func setupDetached(t *testing.T, key []byte) *TokenConfig {
+	config, err := NewToken(
+		SecretKey(key),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestValidDetachedRoundTrip(t *testing.T) {
+	config := setupDetached(t, secretKey)
+	payload := []byte(`{"document":"large","items":[1,2,3]}`)
+
+	jws, err := config.SignDetached(payload)
+	if err != nil {
+		t.Errorf("Unexpected error signing payload: %v", err)
+	}
+
+	if err := config.VerifyDetached(jws, payload); err != nil {
+		t.Errorf("Unexpected error verifying payload: %v", err)
+	}
+}
+
+func TestTamperedDetachedPayload(t *testing.T) {
+	config := setupDetached(t, secretKey)
+
+	jws, err := config.SignDetached([]byte(`{"amount":100}`))
+	if err != nil {
+		t.Errorf("Unexpected error signing payload: %v", err)
+	}
+
+	err = config.VerifyDetached(jws, []byte(`{"amount":900}`))
+	if err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestDetachedFixture(t *testing.T) {
+	key, err := base64.RawURLEncoding.DecodeString(rfc7797Key)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding key: %v", err)
+	}
+
+	config := setupDetached(t, key)
+
+	if err := config.VerifyDetached(rfc7797JWS, []byte("$.02")); err != nil {
+		t.Errorf("Unexpected error verifying fixture: %v", err)
+	}
+
+	jws, err := config.SignDetached([]byte("$.02"))
+	if err != nil {
+		t.Errorf("Unexpected error signing payload: %v", err)
+	}
+
+	if jws != rfc7797JWS {
+		t.Errorf("Expected JWS to be %v, got %v", rfc7797JWS, jws)
+	}
+}
+
+func TestInvalidDetachedHeaders(t *testing.T) {
+	config := setupDetached(t, secretKey)
+	payload := []byte("payload")
+
+	headers := map[string]string{
+		"missing crit":    `{"alg":"HS256","b64":false}`,
+		"missing b64":     `{"alg":"HS256","crit":["b64"]}`,
+		"encoded payload": `{"alg":"HS256","b64":true,"crit":["b64"]}`,
+		"unknown crit":    `{"alg":"HS256","b64":false,"crit":["b64","exp"]}`,
+	}
+
+	for name, header := range headers {
+		encodedHeader := jwt.EncodeSegment([]byte(header))
+		signature, err := config.signingMethod.Sign(encodedHeader+"."+string(payload), secretKey)
+		if err != nil {
+			t.Fatalf("Unexpected error signing %s: %v", name, err)
+		}
+
+		err = config.VerifyDetached(encodedHeader+".."+signature, payload)
+		if err != ErrInvalidDetachedJWS {
+			t.Errorf("Expected error for %s: %v, got: %v", name, ErrInvalidDetachedJWS, err)
+		}
+	}
+}
+
+func TestDetachedWrongAlgorithm(t *testing.T) {
+	config := setupDetached(t, secretKey)
+	payload := []byte("payload")
+
+	encodedHeader := jwt.EncodeSegment([]byte(`{"alg":"HS512","b64":false,"crit":["b64"]}`))
+	signature, err := jwt.SigningMethodHS512.Sign(encodedHeader+"."+string(payload), secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error signing payload: %v", err)
+	}
+
+	err = config.VerifyDetached(encodedHeader+".."+signature, payload)
+	if err != ErrUnexpectedSigningMethod {
+		t.Errorf("Expected error: %v, got: %v", ErrUnexpectedSigningMethod, err)
+	}
+}
+
+func TestAttachedPayloadRejected(t *testing.T) {
+	config := setupDetached(t, secretKey)
+	payload := []byte("payload")
+
+	jws, err := config.SignDetached(payload)
+	if err != nil {
+		t.Errorf("Unexpected error signing payload: %v", err)
+	}
+
+	parts := strings.Split(jws, ".")
+	attached := parts[0] + "." + jwt.EncodeSegment(payload) + "." + parts[2]
+
+	err = config.VerifyDetached(attached, payload)
+	if err != ErrInvalidDetachedJWS {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidDetachedJWS, err)
+	}
+}
+
+func TestDetachedWithSecretProvider(t *testing.T) {
+	provider := &rotatingProvider{keys: map[string][]byte{"v1": keyV1}, current: "v1"}
+	config := newProviderToken(t, provider)
+	payload := []byte(`{"amount":100}`)
+
+	before, err := config.SignDetached(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error signing payload: %v", err)
+	}
+
+	provider.rotate("v2", keyV2)
+
+	after, err := config.SignDetached(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error signing payload: %v", err)
+	}
+
+	rawHeader, _ := jwt.DecodeSegment(strings.Split(after, ".")[0])
+	if !strings.Contains(string(rawHeader), `"kid":"v2"`) {
+		t.Errorf("Expected the payload signed with the rotated key v2, got header %s", rawHeader)
+	}
+
+	for _, jws := range []string{before, after} {
+		if err := config.VerifyDetached(jws, payload); err != nil {
+			t.Errorf("Unexpected error verifying payload: %v", err)
+		}
+	}
+
+	provider.err = errVaultSealed
+	if _, err := config.SignDetached(payload); !errors.Is(err, errVaultSealed) {
+		t.Errorf("Expected error: %v, got: %v", errVaultSealed, err)
+	}
+}
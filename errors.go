@@ -1,19 +1,150 @@
 package hydrate
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 // These errors are returned when an error occurs during token generation, verification, or refreshing.
 var (
-	ErrInvalidSecretKey     = errors.New("invalid secret key")
-	ErrTokenInvalid         = errors.New("invalid token")
-	ErrTokenExpired         = errors.New("token expired")
-	ErrClaimsInvalid        = errors.New("invalid claims in token")
-	ErrSigningMethodNil     = errors.New("signing method cannot be nil")
-	ErrStandardClaimMissing = errors.New("standard claim 'exp' is required")
-	ErrCustomClaimsMissing  = errors.New("custom claims are required")
-	ErrTokenNotGenerated    = errors.New("token not generated")
-	ErrSigningToken         = errors.New("error signing token")
-	ErrStoringToken         = errors.New("error storing token")
-	ErrInvalidTokenConfig   = errors.New("invalid token configuration")
-	ErrTokenConfigNil       = errors.New("token configuration cannot be nil")
+	ErrInvalidSecretKey        = errors.New("invalid secret key")
+	ErrTokenInvalid            = errors.New("invalid token")
+	ErrTokenExpired            = errors.New("token expired")
+	ErrClaimsInvalid           = errors.New("invalid claims in token")
+	ErrSigningMethodNil        = errors.New("signing method cannot be nil")
+	ErrStandardClaimMissing    = errors.New("standard claim 'exp' is required")
+	ErrCustomClaimsMissing     = errors.New("custom claims are required")
+	ErrTokenNotGenerated       = errors.New("token not generated")
+	ErrSigningToken            = errors.New("error signing token")
+	ErrStoringToken            = errors.New("error storing token")
+	ErrInvalidTokenConfig      = errors.New("invalid token configuration")
+	ErrTokenConfigNil          = errors.New("token configuration cannot be nil")
+	ErrInsecureRandomSource    = errors.New("insecure random source")
+	ErrInvalidDetachedJWS      = errors.New("invalid detached JWS")
+	ErrInvalidMaxGroups        = errors.New("maximum groups must be positive")
+	ErrGroupFetcherNil         = errors.New("group fetcher is required for overflowed groups")
+	ErrConfigClosed            = errors.New("token configuration is closed")
+	ErrInvalidAudienceExpiry   = errors.New("audience expiry requires an audience and a positive duration")
+	ErrInvalidKeyPEM           = errors.New("invalid PEM encoded key")
+	ErrInvalidKey              = errors.New("invalid or unsupported key")
+	ErrSecureMemoryFailed      = errors.New("secret key could not be stored in secure memory")
+	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
+	ErrSigningNotConfigured    = errors.New("signing is not configured")
+	ErrUnknownKeyID            = errors.New("unknown key ID")
+	ErrClaimLimitExceeded      = errors.New("claim limit exceeded")
+	ErrInvalidClaimLimit       = errors.New("claim limit must be positive")
+	ErrJWKSUnavailable         = errors.New("JWKS keys are unavailable")
+	ErrSecretProvider          = errors.New("secret provider failed")
+	ErrUnknownIssuer           = errors.New("unknown token issuer")
+	ErrWeakSecretKey           = errors.New("secret key is too short for the signing method")
+	ErrSignerUnavailable       = errors.New("signer is unavailable")
+	ErrIssuerMismatch          = errors.New("token issuer does not match the expected issuer")
+	ErrAudienceMismatch        = errors.New("token audience does not match the expected audience")
+	ErrInvalidJWSJSON          = errors.New("invalid JWS JSON serialization")
+	ErrUnsupportedJWSJSON      = errors.New("general JWS JSON serialization with multiple signatures is not supported")
+	ErrTokenNotYetValid        = errors.New("token is not valid yet")
+	ErrSignatureInvalid        = errors.New("token signature is invalid")
+	ErrTokenTooOld             = errors.New("token is older than the maximum age")
+	ErrClockNil                = errors.New("clock cannot be nil")
+	ErrWrongTokenType          = errors.New("token is not of the expected type")
+	ErrRefreshTokenReused      = errors.New("refresh token was already used")
+	ErrInjectedFault           = errors.New("injected synthetic fault")
+	ErrSessionExpired          = errors.New("session reached its maximum lifetime")
+	ErrTokenPairMismatch       = errors.New("refresh token belongs to another token pair")
+	ErrTokenRevoked            = errors.New("token has been revoked")
+	ErrTokenVersionStale       = errors.New("token version is older than the current version")
+	ErrNoTokenFound            = errors.New("no token found in request")
+	ErrMalformedCredentials    = errors.New("malformed token in request")
+	ErrExpirationOverridden    = errors.New("exp claim is overridden by the configured expiration duration")
+	ErrClaimConflict           = errors.New("custom claims conflict with standard claims")
+	ErrCapabilityMismatch      = errors.New("token does not authorize the method and path of the request")
+	ErrRequestMismatch         = errors.New("token is bound to another request method or path")
+	ErrBodyHashMismatch        = errors.New("request body does not match the body hash of the token")
+	ErrRequestTokenReplayed    = errors.New("request token was already used")
+	ErrRequestBodyTooLarge     = errors.New("request body exceeds the limit")
 )
+
+// ErrorDescriptor describes an error returned by the package for clients and HTTP surfaces.
+type ErrorDescriptor struct {
+	Code        string // Stable machine-readable code
+	Err         error  // Sentinel error, matched with errors.Is
+	Status      int    // Default HTTP status
+	Retriable   bool   // Whether retrying the same request may succeed
+	Description string // Human-readable description
+}
+
+// errorCatalog lists every sentinel error. New sentinels must be added here.
+var errorCatalog = []ErrorDescriptor{
+	{"invalid_secret_key", ErrInvalidSecretKey, http.StatusInternalServerError, false, "The secret key is missing or invalid."},
+	{"token_invalid", ErrTokenInvalid, http.StatusUnauthorized, false, "The token is malformed or could not be verified."},
+	{"token_expired", ErrTokenExpired, http.StatusUnauthorized, false, "The token has expired; refresh it."},
+	{"claims_invalid", ErrClaimsInvalid, http.StatusUnauthorized, false, "The token claims are invalid."},
+	{"signing_method_nil", ErrSigningMethodNil, http.StatusInternalServerError, false, "No signing method is configured."},
+	{"standard_claim_missing", ErrStandardClaimMissing, http.StatusInternalServerError, false, "The exp standard claim is required."},
+	{"custom_claims_missing", ErrCustomClaimsMissing, http.StatusInternalServerError, false, "Custom claims are required."},
+	{"token_not_generated", ErrTokenNotGenerated, http.StatusInternalServerError, false, "No token has been generated."},
+	{"signing_token", ErrSigningToken, http.StatusInternalServerError, false, "The token could not be signed."},
+	{"storing_token", ErrStoringToken, http.StatusInternalServerError, true, "The token could not be stored."},
+	{"invalid_token_config", ErrInvalidTokenConfig, http.StatusInternalServerError, false, "The token configuration is invalid."},
+	{"token_config_nil", ErrTokenConfigNil, http.StatusInternalServerError, false, "A token configuration is missing."},
+	{"insecure_random_source", ErrInsecureRandomSource, http.StatusInternalServerError, false, "The source of randomness is insecure."},
+	{"invalid_detached_jws", ErrInvalidDetachedJWS, http.StatusBadRequest, false, "The detached JWS is malformed."},
+	{"invalid_max_groups", ErrInvalidMaxGroups, http.StatusInternalServerError, false, "The maximum number of groups must be positive."},
+	{"group_fetcher_nil", ErrGroupFetcherNil, http.StatusInternalServerError, false, "A group fetcher is required for overflowed groups."},
+	{"config_closed", ErrConfigClosed, http.StatusInternalServerError, false, "The token configuration is closed."},
+	{"invalid_audience_expiry", ErrInvalidAudienceExpiry, http.StatusInternalServerError, false, "The audience expiry is invalid."},
+	{"invalid_key_pem", ErrInvalidKeyPEM, http.StatusInternalServerError, false, "The PEM encoded key is invalid."},
+	{"invalid_key", ErrInvalidKey, http.StatusInternalServerError, false, "The key is invalid or unsupported."},
+	{"secure_memory_failed", ErrSecureMemoryFailed, http.StatusInternalServerError, false, "The secret key could not be stored in secure memory."},
+	{"unexpected_signing_method", ErrUnexpectedSigningMethod, http.StatusUnauthorized, false, "The token uses a signing method that is not allowed."},
+	{"signing_not_configured", ErrSigningNotConfigured, http.StatusInternalServerError, false, "The configuration cannot sign tokens."},
+	{"unknown_key_id", ErrUnknownKeyID, http.StatusUnauthorized, false, "The token names an unknown key."},
+	{"claim_limit_exceeded", ErrClaimLimitExceeded, http.StatusInternalServerError, false, "The claims exceed the configured limits."},
+	{"invalid_claim_limit", ErrInvalidClaimLimit, http.StatusInternalServerError, false, "Claim limits must be positive."},
+	{"jwks_unavailable", ErrJWKSUnavailable, http.StatusServiceUnavailable, true, "The issuer's keys could not be fetched."},
+	{"secret_provider", ErrSecretProvider, http.StatusServiceUnavailable, true, "The secret provider failed."},
+	{"unknown_issuer", ErrUnknownIssuer, http.StatusUnauthorized, false, "The token issuer is not registered."},
+	{"weak_secret_key", ErrWeakSecretKey, http.StatusInternalServerError, false, "The secret key is too short for the signing method."},
+	{"signer_unavailable", ErrSignerUnavailable, http.StatusServiceUnavailable, true, "The signer is unavailable."},
+	{"issuer_mismatch", ErrIssuerMismatch, http.StatusUnauthorized, false, "The token issuer is not the expected issuer."},
+	{"audience_mismatch", ErrAudienceMismatch, http.StatusUnauthorized, false, "The token audience is not the expected audience."},
+	{"invalid_jws_json", ErrInvalidJWSJSON, http.StatusBadRequest, false, "The JWS JSON serialization is malformed."},
+	{"unsupported_jws_json", ErrUnsupportedJWSJSON, http.StatusBadRequest, false, "The general JWS JSON serialization is not supported."},
+	{"token_not_yet_valid", ErrTokenNotYetValid, http.StatusUnauthorized, true, "The token is not valid yet."},
+	{"signature_invalid", ErrSignatureInvalid, http.StatusUnauthorized, false, "The token signature is invalid."},
+	{"token_too_old", ErrTokenTooOld, http.StatusUnauthorized, false, "The token was issued too long ago; reauthenticate."},
+	{"clock_nil", ErrClockNil, http.StatusInternalServerError, false, "A clock is required."},
+	{"wrong_token_type", ErrWrongTokenType, http.StatusUnauthorized, false, "The token is a refresh token where an access token is expected, or the reverse."},
+	{"refresh_token_reused", ErrRefreshTokenReused, http.StatusUnauthorized, false, "The refresh token was already rotated; reauthenticate."},
+	{"injected_fault", ErrInjectedFault, http.StatusServiceUnavailable, true, "A synthetic failure was injected for chaos testing."},
+	{"session_expired", ErrSessionExpired, http.StatusUnauthorized, false, "The session reached its maximum lifetime; reauthenticate."},
+	{"token_pair_mismatch", ErrTokenPairMismatch, http.StatusUnauthorized, false, "The refresh token was not issued with this access token."},
+	{"token_revoked", ErrTokenRevoked, http.StatusUnauthorized, false, "The token was revoked before its expiration; reauthenticate."},
+	{"token_version_stale", ErrTokenVersionStale, http.StatusUnauthorized, false, "The token predates the current token version of its subject; reauthenticate."},
+	{"no_token_found", ErrNoTokenFound, http.StatusUnauthorized, false, "The request carries no token; authenticate."},
+	{"malformed_credentials", ErrMalformedCredentials, http.StatusBadRequest, false, "The token of the request cannot be read."},
+	{"expiration_overridden", ErrExpirationOverridden, http.StatusInternalServerError, false, "Both an exp claim and an expiration duration are configured; the duration is used."},
+	{"claim_conflict", ErrClaimConflict, http.StatusInternalServerError, false, "Custom claims use keys set by the standard claims."},
+	{"capability_mismatch", ErrCapabilityMismatch, http.StatusForbidden, false, "The capability token authorizes another method or path."},
+	{"request_mismatch", ErrRequestMismatch, http.StatusUnauthorized, false, "The request token was issued for another method or path."},
+	{"body_hash_mismatch", ErrBodyHashMismatch, http.StatusUnauthorized, false, "The request body was altered after the request token was issued."},
+	{"request_token_replayed", ErrRequestTokenReplayed, http.StatusUnauthorized, false, "The request token was already used; sign the request again."},
+	{"request_body_too_large", ErrRequestBodyTooLarge, http.StatusRequestEntityTooLarge, false, "The request body is larger than the configured limit."},
+}
+
+// ErrorCatalog returns the descriptors of every sentinel error, in a stable order.
+func ErrorCatalog() []ErrorDescriptor {
+	return append([]ErrorDescriptor(nil), errorCatalog...)
+}
+
+// DescribeError returns the descriptor of the first sentinel matched by the error with errors.Is.
+// Errors outside the catalog are described as an internal error with code "internal".
+func DescribeError(err error) ErrorDescriptor {
+	for _, descriptor := range errorCatalog {
+		if errors.Is(err, descriptor.Err) {
+			return descriptor
+		}
+	}
+
+	return ErrorDescriptor{Code: "internal", Err: err, Status: http.StatusInternalServerError, Description: "An internal error occurred."}
+}
@@ -0,0 +1,148 @@
+package hydrate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sentinelMessages returns the message of every Err* sentinel declared in errors.go, keyed by name.
+func sentinelMessages(t *testing.T) map[string]string {
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing errors.go: %v", err)
+	}
+
+	messages := make(map[string]string)
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Err") || i >= len(spec.Values) {
+				continue
+			}
+
+			call, ok := spec.Values[i].(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				continue
+			}
+
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok {
+				continue
+			}
+
+			message, err := strconv.Unquote(literal.Value)
+			if err != nil {
+				t.Fatalf("Unexpected error unquoting %s: %v", name.Name, err)
+			}
+			messages[name.Name] = message
+		}
+
+		return true
+	})
+
+	return messages
+}
+
+func TestErrorCatalogCoversSentinels(t *testing.T) {
+	messages := sentinelMessages(t)
+	if len(messages) == 0 {
+		t.Fatal("Expected sentinel errors in errors.go")
+	}
+
+	catalog := make(map[string]bool)
+	for _, descriptor := range ErrorCatalog() {
+		catalog[descriptor.Err.Error()] = true
+	}
+
+	for name, message := range messages {
+		if !catalog[message] {
+			t.Errorf("Expected %s to have an ErrorCatalog entry", name)
+		}
+	}
+
+	if len(ErrorCatalog()) != len(messages) {
+		t.Errorf("Expected %d catalog entries, got: %d", len(messages), len(ErrorCatalog()))
+	}
+}
+
+func TestErrorCatalogEntries(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, descriptor := range ErrorCatalog() {
+		if descriptor.Code == "" || codes[descriptor.Code] {
+			t.Errorf("Expected a unique code for %v, got: %q", descriptor.Err, descriptor.Code)
+		}
+		codes[descriptor.Code] = true
+
+		if http.StatusText(descriptor.Status) == "" {
+			t.Errorf("Expected an HTTP status for %v, got: %d", descriptor.Err, descriptor.Status)
+		}
+
+		if descriptor.Description == "" {
+			t.Errorf("Expected a description for %v", descriptor.Err)
+		}
+	}
+}
+
+func TestErrorCatalogIsCopy(t *testing.T) {
+	catalog := ErrorCatalog()
+	catalog[0].Code = "changed"
+
+	if ErrorCatalog()[0].Code == "changed" {
+		t.Error("Expected ErrorCatalog to return a copy")
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   string
+		status int
+	}{
+		{ErrTokenExpired, "token_expired", http.StatusUnauthorized},
+		{fmt.Errorf("%w: %w", ErrSecretProvider, errVaultSealed), "secret_provider", http.StatusServiceUnavailable},
+		{errVaultSealed, "internal", http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		descriptor := DescribeError(test.err)
+		if descriptor.Code != test.code || descriptor.Status != test.status {
+			t.Errorf("Expected %s (%d) for %v, got: %s (%d)", test.code, test.status, test.err, descriptor.Code, descriptor.Status)
+		}
+	}
+
+	if !DescribeError(ErrSignerUnavailable).Retriable {
+		t.Error("Expected ErrSignerUnavailable to be retriable")
+	}
+}
+
+func TestDescribeOptionErrors(t *testing.T) {
+	options := []func(*TokenConfig) error{
+		WithMaxAge(0),
+		WithExpectedIssuer(""),
+		WithAudiences(nil),
+		WithMaxSessionLifetime(0),
+		WithTokenStore(nil),
+		WithReuseDetector(nil),
+		WithRevocationStore(nil),
+		WithTokenVersionResolver(nil),
+		WithExpectedTokenUse("id"),
+		WithActiveKeyID(""),
+	}
+
+	for _, option := range options {
+		_, err := NewToken(SecretKey(secretKey), option)
+		if descriptor := DescribeError(err); descriptor.Code != "invalid_token_config" || descriptor.Status != http.StatusInternalServerError {
+			t.Errorf("Expected invalid_token_config (500) for %v, got: %s (%d)", err, descriptor.Code, descriptor.Status)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package hydrate
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Extractor finds the token carried by a request.
+// Extract returns ErrNoTokenFound when the request does not carry a token where the extractor looks,
+// and ErrMalformedCredentials when it carries one that cannot be read.
+type Extractor interface {
+	Extract(r *http.Request) (string, error)
+}
+
+// ExtractorFunc adapts a function to the Extractor interface.
+type ExtractorFunc func(r *http.Request) (string, error)
+
+// Extract calls the function.
+func (f ExtractorFunc) Extract(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// FromAuthHeader extracts the token of the Authorization header using the scheme, e.g. "Bearer".
+// The scheme is matched case-insensitively; headers with another scheme are treated as carrying no token.
+func FromAuthHeader(scheme string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			return "", ErrNoTokenFound
+		}
+
+		prefix, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(prefix, scheme) {
+			return "", ErrNoTokenFound
+		}
+
+		if token = strings.TrimSpace(token); token == "" {
+			return "", ErrMalformedCredentials
+		}
+
+		return token, nil
+	})
+}
+
+// FromHeader extracts the token from the value of the header, e.g. "X-Api-Token".
+func FromHeader(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		return nonEmptyToken(r.Header.Get(name))
+	})
+}
+
+// FromCookie extracts the token from the value of the cookie, e.g. "access_token".
+func FromCookie(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return "", ErrNoTokenFound
+		}
+
+		return nonEmptyToken(cookie.Value)
+	})
+}
+
+// FromQuery extracts the token from the query parameter, e.g. "token".
+// Tokens in URLs end up in logs and browser history; prefer a header or cookie when possible.
+func FromQuery(name string) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		return nonEmptyToken(r.URL.Query().Get(name))
+	})
+}
+
+// ChainExtractors tries the extractors in order, returning the first token found.
+// Extraction stops at the first error other than ErrNoTokenFound.
+func ChainExtractors(extractors ...Extractor) Extractor {
+	return ExtractorFunc(func(r *http.Request) (string, error) {
+		for _, extractor := range extractors {
+			token, err := extractor.Extract(r)
+			if err != ErrNoTokenFound {
+				return token, err
+			}
+		}
+
+		return "", ErrNoTokenFound
+	})
+}
+
+// nonEmptyToken returns the trimmed value, or ErrNoTokenFound if it is empty.
+func nonEmptyToken(value string) (string, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return "", ErrNoTokenFound
+	}
+
+	return value, nil
+}
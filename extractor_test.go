@@ -0,0 +1,107 @@
+package hydrate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestExtractors(t *testing.T) {
+	cases := []struct {
+		name      string
+		extractor Extractor
+		prepare   func(r *http.Request)
+		token     string
+		expected  error
+	}{
+		{"auth header", FromAuthHeader("Bearer"), func(r *http.Request) { r.Header.Set("Authorization", "Bearer abc") }, "abc", nil},
+		{"auth header other scheme", FromAuthHeader("Bearer"), func(r *http.Request) { r.Header.Set("Authorization", "Basic abc") }, "", ErrNoTokenFound},
+		{"auth header without token", FromAuthHeader("Bearer"), func(r *http.Request) { r.Header.Set("Authorization", "Bearer") }, "", ErrMalformedCredentials},
+		{"auth header missing", FromAuthHeader("Bearer"), func(r *http.Request) {}, "", ErrNoTokenFound},
+		{"header", FromHeader("X-Api-Token"), func(r *http.Request) { r.Header.Set("X-Api-Token", "abc") }, "abc", nil},
+		{"header missing", FromHeader("X-Api-Token"), func(r *http.Request) {}, "", ErrNoTokenFound},
+		{"cookie", FromCookie("access_token"), func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "access_token", Value: "abc"}) }, "abc", nil},
+		{"cookie missing", FromCookie("access_token"), func(r *http.Request) {}, "", ErrNoTokenFound},
+		{"query", FromQuery("token"), func(r *http.Request) { r.URL.RawQuery = "token=abc" }, "abc", nil},
+		{"query empty", FromQuery("token"), func(r *http.Request) { r.URL.RawQuery = "token=" }, "", ErrNoTokenFound},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		c.prepare(request)
+
+		token, err := c.extractor.Extract(request)
+		if token != c.token || err != c.expected {
+			t.Errorf("%s: Expected %q, %v, got %q, %v", c.name, c.token, c.expected, token, err)
+		}
+	}
+}
+
+func TestChainExtractors(t *testing.T) {
+	chain := ChainExtractors(FromAuthHeader("Bearer"), FromCookie("access_token"), FromQuery("token"))
+
+	cases := []struct {
+		name     string
+		prepare  func(r *http.Request)
+		token    string
+		expected error
+	}{
+		{"header first", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer header")
+			r.AddCookie(&http.Cookie{Name: "access_token", Value: "cookie"})
+		}, "header", nil},
+		{"cookie fallback", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "access_token", Value: "cookie"})
+			r.URL.RawQuery = "token=query"
+		}, "cookie", nil},
+		{"query fallback", func(r *http.Request) { r.URL.RawQuery = "token=query" }, "query", nil},
+		{"malformed header stops", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer ")
+			r.URL.RawQuery = "token=query"
+		}, "", ErrMalformedCredentials},
+		{"nothing found", func(r *http.Request) {}, "", ErrNoTokenFound},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		c.prepare(request)
+
+		token, err := chain.Extract(request)
+		if token != c.token || err != c.expected {
+			t.Errorf("%s: Expected %q, %v, got %q, %v", c.name, c.token, c.expected, token, err)
+		}
+	}
+}
+
+func TestMiddlewareWithExtractors(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware, err := NewAuthMiddleware(verifier, WithExtractors(FromHeader("X-Api-Token"), FromCookie("access_token")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "access_token", Value: signTestClaims(t, jwt.MapClaims{"sub": "user"})})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, recorder.Code)
+	}
+
+	if _, err := NewAuthMiddleware(verifier, WithExtractors()); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
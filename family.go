@@ -0,0 +1,201 @@
+package hydrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// familyClaim is the claim holding the ID shared by every refresh token rotated from the same login.
+const familyClaim = "family_id"
+
+// ReuseDetector tracks rotated refresh tokens by family, so replaying a rotated token revokes its whole family.
+// Implementations must be safe for concurrent use, and MarkRotated must be atomic across instances sharing it.
+type ReuseDetector interface {
+	// MarkRotated records the token ID of the family as rotated until expiresAt, reporting whether it already was.
+	MarkRotated(ctx context.Context, jti, family string, expiresAt time.Time) (bool, error)
+	// IsRotated reports whether the token ID was rotated.
+	IsRotated(ctx context.Context, jti string) (bool, error)
+	// RevokeFamily revokes every token of the family until expiresAt.
+	RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error
+	// IsFamilyRevoked reports whether the family was revoked.
+	IsFamilyRevoked(ctx context.Context, family string) (bool, error)
+}
+
+// WithReuseDetector optionally detects replayed refresh tokens in RotateTokenPair with the detector.
+// Refresh tokens generated by the configuration carry a "family_id" claim kept across rotations;
+// presenting a rotated token fails with ErrRefreshTokenReused and revokes its family, logging out the attacker and the user alike.
+// Tokens of a revoked family then fail RefreshToken, RotateTokenPair, Validate, and ExtractClaims.
+func WithReuseDetector(detector ReuseDetector) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if detector == nil {
+			return fmt.Errorf("%w: reuse detector cannot be nil", ErrInvalidTokenConfig)
+		}
+
+		t.reuseDetector = detector
+		return nil
+	}
+}
+
+// checkReuse marks the refresh token of the claims as rotated until it expires, revoking its family if it already was.
+// Returns ErrClaimsInvalid if the claims lack a jti, a family, or an exp, ErrRefreshTokenReused for replayed tokens
+// and tokens of revoked families, or ErrStoringToken if the detector fails.
+func (t *TokenConfig) checkReuse(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	family, _ := claims[familyClaim].(string)
+	exp, ok := numericClaim(claims["exp"])
+	if jti == "" || family == "" || !ok {
+		return ErrClaimsInvalid
+	}
+
+	if err := t.checkFamilyRevocation(claims); err != nil {
+		return err
+	}
+
+	rotated, err := t.reuseDetector.MarkRotated(context.Background(), jti, family, time.Unix(exp, 0))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if !rotated {
+		return nil
+	}
+
+	return t.revokeFamily(claims)
+}
+
+// checkReplay returns ErrRefreshTokenReused, revoking the family, if the refresh token of the claims was already
+// rotated. Unlike checkReuse, it does not mark the token as rotated, so RefreshToken can run it on every use.
+func (t *TokenConfig) checkReplay(claims jwt.MapClaims) error {
+	if t.reuseDetector == nil {
+		return nil
+	}
+
+	if err := t.checkFamilyRevocation(claims); err != nil {
+		return err
+	}
+
+	jti, _ := claims["jti"].(string)
+	family, _ := claims[familyClaim].(string)
+	if jti == "" || family == "" {
+		return nil
+	}
+
+	rotated, err := t.reuseDetector.IsRotated(context.Background(), jti)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if !rotated {
+		return nil
+	}
+
+	return t.revokeFamily(claims)
+}
+
+// checkFamilyRevocation returns ErrRefreshTokenReused if the family of the claims was revoked by the reuse detector.
+// Tokens without a family, or configurations without a detector, pass.
+func (t *TokenConfig) checkFamilyRevocation(claims jwt.MapClaims) error {
+	family, _ := claims[familyClaim].(string)
+	if t.reuseDetector == nil || family == "" {
+		return nil
+	}
+
+	revoked, err := t.reuseDetector.IsFamilyRevoked(context.Background(), family)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if revoked {
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// revokeFamily revokes the family of the replayed claims and returns ErrRefreshTokenReused.
+// The family stays revoked until the replayed token expires or, if later, until any token rotated from it now
+// would, so the newer tokens of the family cannot outlive the revocation.
+func (t *TokenConfig) revokeFamily(claims jwt.MapClaims) error {
+	family, _ := claims[familyClaim].(string)
+	exp, _ := numericClaim(claims["exp"])
+
+	expiresAt := time.Unix(exp, 0)
+	if latest := t.now().Add(t.expiration); latest.After(expiresAt) {
+		expiresAt = latest
+	}
+
+	if err := t.reuseDetector.RevokeFamily(context.Background(), family, expiresAt); err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// MemoryReuseDetector is a ReuseDetector kept in process memory.
+// It suits a single instance; deployments with several instances need a shared store.
+type MemoryReuseDetector struct {
+	rotated  map[string]time.Time // Expiration times by rotated token ID
+	families map[string]time.Time // Expiration times by revoked family
+	sweeper  expirySweeper        // Forgets the expired token IDs and families
+	now      func() time.Time     // Wall clock expiring the token IDs and families
+	lock     sync.Mutex           // Synchronize access to the rotated tokens and revoked families
+}
+
+// NewMemoryReuseDetector instantiates an empty MemoryReuseDetector.
+func NewMemoryReuseDetector() *MemoryReuseDetector {
+	return &MemoryReuseDetector{
+		rotated:  make(map[string]time.Time),
+		families: make(map[string]time.Time),
+		now:      wallNow,
+	}
+}
+
+// MarkRotated records the token ID as rotated until expiresAt, reporting whether it already was.
+// Token IDs and families past their expiration are ignored, and forgotten at most once per sweepInterval.
+func (d *MemoryReuseDetector) MarkRotated(ctx context.Context, jti, family string, expiresAt time.Time) (bool, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.now()
+	d.sweeper.sweep(now, d.rotated, d.families)
+
+	if unexpired(d.rotated, jti, now) {
+		return true, nil
+	}
+
+	d.rotated[jti] = expiresAt.Round(0)
+	return false, nil
+}
+
+// IsRotated reports whether the token ID was rotated.
+func (d *MemoryReuseDetector) IsRotated(ctx context.Context, jti string) (bool, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return unexpired(d.rotated, jti, d.now()), nil
+}
+
+// RevokeFamily revokes every token of the family until expiresAt.
+// A family is kept revoked until the latest expiration it was revoked with.
+func (d *MemoryReuseDetector) RevokeFamily(ctx context.Context, family string, expiresAt time.Time) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if expiry, ok := d.families[family]; !ok || expiry.Before(expiresAt) {
+		d.families[family] = expiresAt.Round(0)
+	}
+
+	return nil
+}
+
+// IsFamilyRevoked reports whether the family was revoked.
+func (d *MemoryReuseDetector) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return unexpired(d.families, family, d.now()), nil
+}
@@ -0,0 +1,237 @@
+package hydrate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func replayRefreshToken(t *testing.T, detector ReuseDetector, token string) *TokenConfig {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithReuseDetector(detector),
+		WithToken(token),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestTokenFamilyKeptAcrossRotation(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t, WithReuseDetector(NewMemoryReuseDetector()))
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	before, err := refreshConfig.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if family, _ := before[familyClaim].(string); family == "" {
+		t.Fatalf("Expected a %s claim, got %v", familyClaim, before[familyClaim])
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	after, err := refreshConfig.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if after[familyClaim] != before[familyClaim] || after["jti"] == before["jti"] {
+		t.Errorf("Expected the family to be kept and the jti to change")
+	}
+}
+
+func TestReplayedRefreshTokenRevokesFamily(t *testing.T) {
+	detector := NewMemoryReuseDetector()
+	accessConfig, refreshConfig, _ := setupTokens(t, WithReuseDetector(detector))
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	stolen := *refreshConfig.token
+
+	// The user rotates first, then the attacker replays the stolen token.
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	attacker := replayRefreshToken(t, detector, stolen)
+	if _, _, err := accessConfig.RotateTokenPair(attacker); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	// The family is revoked, so the user's current refresh token no longer rotates either.
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	// Other families are unaffected.
+	otherAccess, otherRefresh, _ := setupTokens(t, WithReuseDetector(detector))
+	if _, _, err := GenerateTokenPair(otherAccess, otherRefresh); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	if _, _, err := otherAccess.RotateTokenPair(otherRefresh); err != nil {
+		t.Errorf("Unexpected error rotating another family: %v", err)
+	}
+}
+
+func TestRefreshTokenWithoutFamily(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t)
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	unfamiliar := replayRefreshToken(t, NewMemoryReuseDetector(), *refreshConfig.token)
+	if _, _, err := accessConfig.RotateTokenPair(unfamiliar); err != ErrClaimsInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrClaimsInvalid, err)
+	}
+	unpaired, _, _ := setupTokens(t)
+	if _, err := unpaired.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	unexpiring := replayRefreshToken(t, NewMemoryReuseDetector(), signWithKey(t, secretKey, jwt.MapClaims{"jti": "id", familyClaim: "family"}))
+	if _, _, err := unpaired.RotateTokenPair(unexpiring); err != ErrClaimsInvalid {
+		t.Errorf("Expected error: %v for a token without exp, got: %v", ErrClaimsInvalid, err)
+	}
+}
+
+func TestMemoryReuseDetectorConcurrentRotation(t *testing.T) {
+	detector := NewMemoryReuseDetector()
+	expiresAt := time.Now().Add(1 * time.Hour)
+
+	var fresh atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rotated, _ := detector.MarkRotated(context.Background(), "jti", "family", expiresAt); !rotated {
+				fresh.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fresh.Load() != 1 {
+		t.Errorf("Expected exactly one rotation to succeed, got %d", fresh.Load())
+	}
+
+	if rotated, _ := detector.IsRotated(context.Background(), "jti"); !rotated {
+		t.Errorf("Expected the token ID to be rotated")
+	}
+}
+
+func TestReplayWithTokenStoreRevokesFamily(t *testing.T) {
+	detector := NewMemoryReuseDetector()
+	store := NewMemoryTokenStore()
+	accessConfig, refreshConfig, _ := setupTokens(t, WithReuseDetector(detector), WithTokenStore(store))
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	stolen := *refreshConfig.token
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	attacker, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithReuseDetector(detector),
+		WithTokenStore(store),
+		WithToken(stolen),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(attacker); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != ErrRefreshTokenReused {
+		t.Errorf("Expected the family to be revoked, got: %v", err)
+	}
+}
+
+func TestRefreshTokenChecksFamilyRevocation(t *testing.T) {
+	detector := NewMemoryReuseDetector()
+	accessConfig, refreshConfig, _ := setupTokens(t, WithReuseDetector(detector))
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	stolen := *refreshConfig.token
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	// Refreshing with the rotated token is a replay, and revokes the family.
+	if _, err := accessConfig.RefreshToken(replayRefreshToken(t, detector, stolen)); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v for the current token of the family, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if err := refreshConfig.Validate(); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if _, err := refreshConfig.ExtractClaims(); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+}
+
+func TestFamilyRevocationOutlivesReplayedToken(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	detector := NewMemoryReuseDetector()
+	accessConfig, refreshConfig, _ := setupTokens(t, WithClock(clock), WithReuseDetector(detector))
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	stolen := replayRefreshToken(t, detector, *refreshConfig.token)
+	stolen.clock = clock
+	stolenClaims, err := stolen.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	// The user rotates an hour later, so the current token expires after the stolen one.
+	clock.Advance(1 * time.Hour)
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	current, err := refreshConfig.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(stolen); err != ErrRefreshTokenReused {
+		t.Fatalf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	stolenExp, _ := numericClaim(stolenClaims["exp"])
+	currentExp, _ := numericClaim(current["exp"])
+	family, _ := current[familyClaim].(string)
+	if expiry := detector.families[family]; expiry.Unix() < currentExp || currentExp <= stolenExp {
+		t.Errorf("Expected the revocation to last until %v, got %v", time.Unix(currentExp, 0), expiry)
+	}
+}
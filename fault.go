@@ -0,0 +1,174 @@
+package hydrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Fault names a failure injected by WithFaultInjection.
+type Fault string
+
+// These are the faults WithFaultInjection can inject.
+const (
+	FaultExpiredToken    Fault = "expired_token"     // Verified tokens are reported as expired
+	FaultStoreTimeout    Fault = "store_timeout"     // Token store and reuse detector calls time out
+	FaultSignerLatency   Fault = "signer_latency"    // Signatures are delayed, then fail as if the signer timed out
+	FaultKeyRotationRace Fault = "key_rotation_race" // Key lookups miss, as if the key was just rotated out
+)
+
+// FaultConfig configures the failures injected by WithFaultInjection.
+// Rates are probabilities between 0 and 1 of injecting the fault into each matching operation.
+type FaultConfig struct {
+	ExpiredToken    float64                         // Rate of verifications failing with ErrTokenExpired
+	StoreTimeout    float64                         // Rate of store calls failing with context.DeadlineExceeded
+	SignerLatency   float64                         // Rate of signatures delayed by Latency, then failing with ErrSignerUnavailable
+	Latency         time.Duration                   // Delay added to signatures by SignerLatency
+	KeyRotationRace float64                         // Rate of key lookups failing with ErrUnknownKeyID
+	Match           func(claims jwt.MapClaims) bool // Restricts faults to tokens with matching claims, or every token if nil
+	Rand            func() float64                  // Source of numbers in [0, 1), or math/rand if nil
+	OnFault         func(fault Fault)               // Called for every injected fault, in addition to the log line
+}
+
+// faultInjector injects the failures of a FaultConfig.
+type faultInjector struct {
+	config FaultConfig // Faults to inject
+	lock   sync.Mutex  // Synchronize access to the random source
+}
+
+// WithFaultInjection optionally injects failures into verification, signing, and stores for chaos testing.
+// Injected errors wrap ErrInjectedFault as well as the error they simulate, and every fault is logged as synthetic.
+// It must never be set in production; configurations without it carry no injection code path.
+func WithFaultInjection(config FaultConfig) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		for _, rate := range []float64{config.ExpiredToken, config.StoreTimeout, config.SignerLatency, config.KeyRotationRace} {
+			if rate < 0 || rate > 1 {
+				return fmt.Errorf("%w: fault rates must be within [0, 1] and signer latency positive", ErrInvalidTokenConfig)
+			}
+		}
+
+		if config.SignerLatency > 0 && config.Latency <= 0 {
+			return fmt.Errorf("%w: fault rates must be within [0, 1] and signer latency positive", ErrInvalidTokenConfig)
+		}
+
+		t.faults = &faultInjector{config: config}
+		return nil
+	}
+}
+
+// inject reports whether to inject the fault into an operation on the claims, recording it if so.
+func (f *faultInjector) inject(fault Fault, rate float64, claims jwt.MapClaims) bool {
+	if rate <= 0 || (f.config.Match != nil && !f.config.Match(claims)) {
+		return false
+	}
+
+	f.lock.Lock()
+	roll := rand.Float64
+	if f.config.Rand != nil {
+		roll = f.config.Rand
+	}
+	injected := roll() < rate
+	f.lock.Unlock()
+
+	if !injected {
+		return false
+	}
+
+	log.Printf("hydrate: injecting synthetic fault %s", fault)
+	if f.config.OnFault != nil {
+		f.config.OnFault(fault)
+	}
+
+	return true
+}
+
+// injectedFault tags the simulated error as injected, so errors.Is matches both.
+func injectedFault(err error) error {
+	return fmt.Errorf("%w: %w", ErrInjectedFault, err)
+}
+
+// wrapStores wraps the token store and reuse detector of the configuration so their calls can time out.
+// It runs once all options are applied since the stores can be set after the fault injection.
+func (f *faultInjector) wrapStores(t *TokenConfig) {
+	if t.tokenStore != nil && f.config.StoreTimeout > 0 {
+		t.tokenStore = &faultyTokenStore{TokenStore: t.tokenStore, faults: f}
+	}
+
+	if t.reuseDetector != nil && f.config.StoreTimeout > 0 {
+		t.reuseDetector = &faultyReuseDetector{ReuseDetector: t.reuseDetector, faults: f}
+	}
+}
+
+// storeTimeout returns an injected timeout, or nil if the store call should go through.
+func (f *faultInjector) storeTimeout() error {
+	if f.inject(FaultStoreTimeout, f.config.StoreTimeout, nil) {
+		return injectedFault(context.DeadlineExceeded)
+	}
+	return nil
+}
+
+// faultyTokenStore is a TokenStore whose calls may time out.
+type faultyTokenStore struct {
+	TokenStore
+	faults *faultInjector // Injector deciding when calls time out
+}
+
+// Consume times out or calls the wrapped store.
+func (s *faultyTokenStore) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	if err := s.faults.storeTimeout(); err != nil {
+		return false, err
+	}
+	return s.TokenStore.Consume(ctx, jti, expiresAt)
+}
+
+// faultyReuseDetector is a ReuseDetector whose calls may time out.
+type faultyReuseDetector struct {
+	ReuseDetector
+	faults *faultInjector // Injector deciding when calls time out
+}
+
+// MarkRotated times out or calls the wrapped detector.
+func (d *faultyReuseDetector) MarkRotated(ctx context.Context, jti, family string, expiresAt time.Time) (bool, error) {
+	if err := d.faults.storeTimeout(); err != nil {
+		return false, err
+	}
+	return d.ReuseDetector.MarkRotated(ctx, jti, family, expiresAt)
+}
+
+// IsFamilyRevoked times out or calls the wrapped detector.
+func (d *faultyReuseDetector) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	if err := d.faults.storeTimeout(); err != nil {
+		return false, err
+	}
+	return d.ReuseDetector.IsFamilyRevoked(ctx, family)
+}
+
+// expiredToken returns an injected expiration, or nil if the verified token should be accepted.
+func (f *faultInjector) expiredToken(claims jwt.MapClaims, err error) error {
+	if f.inject(FaultExpiredToken, f.config.ExpiredToken, claims) {
+		return injectedFault(err)
+	}
+	return nil
+}
+
+// signerLatency delays the signature and returns an injected signer failure, or nil if the signature should go through.
+func (f *faultInjector) signerLatency(claims jwt.MapClaims) error {
+	if f.inject(FaultSignerLatency, f.config.SignerLatency, claims) {
+		time.Sleep(f.config.Latency)
+		return injectedFault(ErrSignerUnavailable)
+	}
+	return nil
+}
+
+// keyRotationRace returns an injected key lookup miss, or nil if the key should be looked up.
+func (f *faultInjector) keyRotationRace(claims jwt.MapClaims) error {
+	if f.inject(FaultKeyRotationRace, f.config.KeyRotationRace, claims) {
+		return injectedFault(ErrUnknownKeyID)
+	}
+	return nil
+}
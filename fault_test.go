@@ -0,0 +1,148 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestFaultInjection(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   FaultConfig
+		fault    Fault
+		expected error
+		run      func(t *testing.T, accessConfig, refreshConfig *TokenConfig) error
+	}{
+		{
+			name:     "expired token",
+			config:   FaultConfig{ExpiredToken: 1},
+			fault:    FaultExpiredToken,
+			expected: ErrTokenExpired,
+			run: func(t *testing.T, accessConfig, refreshConfig *TokenConfig) error {
+				return accessConfig.Validate()
+			},
+		},
+		{
+			name:     "store timeout",
+			config:   FaultConfig{StoreTimeout: 1},
+			fault:    FaultStoreTimeout,
+			expected: context.DeadlineExceeded,
+			run: func(t *testing.T, accessConfig, refreshConfig *TokenConfig) error {
+				_, _, err := accessConfig.RotateTokenPair(refreshConfig)
+				return err
+			},
+		},
+		{
+			name:     "signer latency",
+			config:   FaultConfig{SignerLatency: 1, Latency: 10 * time.Millisecond},
+			fault:    FaultSignerLatency,
+			expected: ErrSignerUnavailable,
+			run: func(t *testing.T, accessConfig, refreshConfig *TokenConfig) error {
+				_, err := accessConfig.GenerateToken()
+				return err
+			},
+		},
+		{
+			name:     "key rotation race",
+			config:   FaultConfig{KeyRotationRace: 1},
+			fault:    FaultKeyRotationRace,
+			expected: ErrUnknownKeyID,
+			run: func(t *testing.T, accessConfig, refreshConfig *TokenConfig) error {
+				_, err := accessConfig.ExtractClaims()
+				return err
+			},
+		},
+	}
+
+	for _, c := range cases {
+		var injected []Fault
+		armed := false
+		c.config.OnFault = func(fault Fault) { injected = append(injected, fault) }
+		c.config.Match = func(jwt.MapClaims) bool { return armed }
+
+		accessConfig, refreshConfig, _ := setupTokens(t, WithTokenStore(NewMemoryTokenStore()), WithFaultInjection(c.config))
+		if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+			t.Fatalf("%s: Unexpected error generating token pair: %v", c.name, err)
+		}
+
+		armed = true
+		err := c.run(t, accessConfig, refreshConfig)
+		if !errors.Is(err, ErrInjectedFault) || !errors.Is(err, c.expected) {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if len(injected) == 0 || injected[0] != c.fault {
+			t.Errorf("%s: Expected the %s fault to be reported, got %v", c.name, c.fault, injected)
+		}
+	}
+}
+
+func TestFaultInjectionMatch(t *testing.T) {
+	config := FaultConfig{
+		ExpiredToken: 1,
+		Match: func(claims jwt.MapClaims) bool {
+			return claims["sub"] == "chaos"
+		},
+	}
+
+	cases := []struct {
+		subject  string
+		expected error
+	}{
+		{"chaos", ErrTokenExpired},
+		{"user", nil},
+	}
+
+	for _, c := range cases {
+		verifier := newExpectingVerifier(t, signTestClaims(t, jwt.MapClaims{"sub": c.subject}), WithFaultInjection(config))
+
+		err := verifier.Validate()
+		if c.expected == nil && err != nil {
+			t.Errorf("%s: Unexpected error: %v", c.subject, err)
+		}
+
+		if c.expected != nil && (!errors.Is(err, c.expected) || !errors.Is(err, ErrInjectedFault)) {
+			t.Errorf("%s: Expected error: %v, got: %v", c.subject, c.expected, err)
+		}
+	}
+}
+
+func TestFaultInjectionRate(t *testing.T) {
+	rolls := []float64{0.1, 0.9}
+	config := FaultConfig{
+		ExpiredToken: 0.5,
+		Rand: func() float64 {
+			roll := rolls[0]
+			rolls = rolls[1:]
+			return roll
+		},
+	}
+
+	verifier := newExpectingVerifier(t, signTestClaims(t, jwt.MapClaims{}), WithFaultInjection(config))
+
+	if err := verifier.Validate(); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected a roll under the rate to inject a fault, got: %v", err)
+	}
+
+	if err := verifier.Validate(); err != nil {
+		t.Errorf("Expected a roll over the rate to pass, got: %v", err)
+	}
+}
+
+func TestInvalidFaultInjection(t *testing.T) {
+	configs := []FaultConfig{
+		{ExpiredToken: 1.5},
+		{StoreTimeout: -1},
+		{SignerLatency: 0.5},
+	}
+
+	for _, config := range configs {
+		if _, err := NewToken(SecretKey(secretKey), WithFaultInjection(config)); !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+}
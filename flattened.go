@@ -0,0 +1,75 @@
+package hydrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// flattenedJWS is a JWS in the flattened JSON Serialization of RFC 7515 Section 7.2.2.
+type flattenedJWS struct {
+	Protected  string          `json:"protected"`            // Base64url encoded protected header
+	Header     json.RawMessage `json:"header,omitempty"`     // Unprotected header
+	Payload    string          `json:"payload"`              // Base64url encoded payload
+	Signature  string          `json:"signature"`            // Base64url encoded signature
+	Signatures json.RawMessage `json:"signatures,omitempty"` // Signatures of the general serialization
+}
+
+// DecodeFlattenedJWS converts a JWS in the flattened JSON Serialization to the compact serialization.
+// The unprotected header cannot be represented in the compact form and is dropped; only the protected header is signed.
+// The general JSON Serialization with multiple signatures fails with ErrUnsupportedJWSJSON.
+func DecodeFlattenedJWS(data []byte) (string, error) {
+	var jws flattenedJWS
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return "", ErrInvalidJWSJSON
+	}
+
+	if jws.Signatures != nil {
+		return "", ErrUnsupportedJWSJSON
+	}
+
+	if jws.Protected == "" || jws.Payload == "" || jws.Signature == "" {
+		return "", ErrInvalidJWSJSON
+	}
+
+	for _, segment := range []string{jws.Protected, jws.Payload, jws.Signature} {
+		if strings.Contains(segment, ".") {
+			return "", ErrInvalidJWSJSON
+		}
+	}
+
+	return jws.Protected + "." + jws.Payload + "." + jws.Signature, nil
+}
+
+// EncodeFlattenedJWS converts a compact JWS, such as a token returned by GenerateToken, to the flattened JSON Serialization.
+func EncodeFlattenedJWS(compact string) ([]byte, error) {
+	segments := strings.Split(compact, ".")
+	if len(segments) != 3 || segments[0] == "" || segments[1] == "" || segments[2] == "" {
+		return nil, ErrInvalidJWSJSON
+	}
+
+	return json.Marshal(flattenedJWS{
+		Protected: segments[0],
+		Payload:   segments[1],
+		Signature: segments[2],
+	})
+}
+
+// WithFlattenedJWS optionally accepts tokens in the flattened JSON Serialization when parsing token strings.
+// JSON input is detected and converted to the compact serialization before it is verified.
+func WithFlattenedJWS() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.flattenedJWS = true
+		return nil
+	}
+}
+
+// compactToken converts a flattened JSON token to the compact serialization when flattened input is accepted.
+func (t *TokenConfig) compactToken(tokenString string) (string, error) {
+	trimmed := bytes.TrimSpace([]byte(tokenString))
+	if !t.flattenedJWS || len(trimmed) == 0 || trimmed[0] != '{' {
+		return tokenString, nil
+	}
+
+	return DecodeFlattenedJWS(trimmed)
+}
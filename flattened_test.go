@@ -0,0 +1,81 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// The fixtures in testdata/jws are the RFC 7515 Appendix A.6 and A.7 JSON Serialization examples.
+const rfc7515CompactES256 = "eyJhbGciOiJFUzI1NiJ9" +
+	".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+	".DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q"
+
+func TestDecodeFlattenedJWS(t *testing.T) {
+	compact, err := DecodeFlattenedJWS(readFixture(t, filepath.Join("jws", "flattened.json")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if compact != rfc7515CompactES256 {
+		t.Errorf("Expected compact serialization %s, got %s", rfc7515CompactES256, compact)
+	}
+}
+
+func TestDecodeRejectedJWSShapes(t *testing.T) {
+	if _, err := DecodeFlattenedJWS(readFixture(t, filepath.Join("jws", "general.json"))); err != ErrUnsupportedJWSJSON {
+		t.Errorf("Expected error: %v, got: %v", ErrUnsupportedJWSJSON, err)
+	}
+
+	rejected := map[string]string{
+		"malformed JSON":        `{"protected":`,
+		"missing protected":     `{"payload":"eyJ9","signature":"c2ln"}`,
+		"missing signature":     `{"protected":"eyJhbGciOiJIUzI1NiJ9","payload":"eyJ9"}`,
+		"dot in payload":        `{"protected":"eyJhbGciOiJIUzI1NiJ9","payload":"a.b","signature":"c2ln"}`,
+		"compact serialization": `"` + rfc7515CompactES256 + `"`,
+	}
+
+	for name, data := range rejected {
+		if _, err := DecodeFlattenedJWS([]byte(data)); err != ErrInvalidJWSJSON {
+			t.Errorf("%s: Expected error: %v, got: %v", name, ErrInvalidJWSJSON, err)
+		}
+	}
+}
+
+func TestFlattenedJWSRoundTrip(t *testing.T) {
+	token, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	flattened, err := EncodeFlattenedJWS(string(token))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(flattened, &fields); err != nil || len(fields) != 3 {
+		t.Errorf("Expected protected, payload, and signature members, got %s", flattened)
+	}
+
+	if _, err := config.ParseTokenString(string(flattened)); err != ErrTokenInvalid {
+		t.Errorf("Expected flattened input to be rejected by default, got: %v", err)
+	}
+
+	verifier, err := NewVerifier(SecretKey(secretKey), WithFlattenedJWS())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := verifier.ParseTokenString(string(flattened)); err != nil {
+		t.Errorf("Unexpected error parsing flattened token: %v", err)
+	}
+
+	if _, err := verifier.ParseTokenString(string(token)); err != nil {
+		t.Errorf("Unexpected error parsing compact token: %v", err)
+	}
+
+	if _, err := EncodeFlattenedJWS("not.a-token"); err != ErrInvalidJWSJSON {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidJWSJSON, err)
+	}
+}
@@ -7,11 +7,11 @@ toolchain go1.22.0
 require (
 	github.com/garrettladley/mattress v0.4.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
+	golang.org/x/crypto v0.19.0
 )
 
 require (
 	github.com/awnumar/memcall v0.2.0 // indirect
 	github.com/awnumar/memguard v0.22.4 // indirect
-	golang.org/x/crypto v0.19.0 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 )
@@ -0,0 +1,118 @@
+package hydrate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	groupsClaim         = "groups"          // Claim holding the inline groups
+	groupsOverflowClaim = "groups_overflow" // Claim marking that the groups must be fetched
+)
+
+// GroupFetcher fetches the groups of a subject whose groups claim overflowed.
+type GroupFetcher interface {
+	FetchGroups(ctx context.Context, subject string) ([]string, error)
+}
+
+// GroupFetcherFunc adapts an ordinary function to the GroupFetcher interface.
+type GroupFetcherFunc func(ctx context.Context, subject string) ([]string, error)
+
+// FetchGroups calls f(ctx, subject).
+func (f GroupFetcherFunc) FetchGroups(ctx context.Context, subject string) ([]string, error) {
+	return f(ctx, subject)
+}
+
+// WithMaxGroups optionally limits the number of groups carried inline in the "groups" custom claim.
+// Groups beyond the limit are truncated and the "groups_overflow" claim is set to true.
+func WithMaxGroups(n int) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if n <= 0 {
+			return ErrInvalidMaxGroups
+		}
+
+		t.maxGroups = n
+		return nil
+	}
+}
+
+// GroupsFromClaims returns the groups of the token.
+// If the groups claim overflowed, the groups are fetched for the subject using the fetcher.
+func GroupsFromClaims(ctx context.Context, claims jwt.MapClaims, fetcher GroupFetcher) ([]string, error) {
+	if overflow, _ := claims[groupsOverflowClaim].(bool); !overflow {
+		return stringSlice(claims[groupsClaim]), nil
+	}
+
+	if fetcher == nil {
+		return nil, ErrGroupFetcherNil
+	}
+
+	subject, ok := claims["sub"].(string)
+	if !ok || subject == "" {
+		return nil, ErrClaimsInvalid
+	}
+
+	return fetcher.FetchGroups(ctx, subject)
+}
+
+// cachedGroups holds the fetched groups of a subject and when they expire.
+type cachedGroups struct {
+	groups    []string
+	expiresAt time.Time
+}
+
+// CachedGroupFetcher caches the groups returned by a GroupFetcher per subject.
+type CachedGroupFetcher struct {
+	fetcher GroupFetcher            // Fetcher used on cache misses
+	ttl     time.Duration           // How long fetched groups are cached
+	cache   map[string]cachedGroups // Cached groups by subject
+	now     func() time.Time        // Wall clock expiring the cached groups
+	lock    sync.Mutex              // Synchronize access to the cache
+}
+
+// NewCachedGroupFetcher wraps the fetcher so groups are cached per subject for the provided duration.
+func NewCachedGroupFetcher(fetcher GroupFetcher, ttl time.Duration) *CachedGroupFetcher {
+	return &CachedGroupFetcher{
+		fetcher: fetcher,
+		ttl:     ttl,
+		cache:   make(map[string]cachedGroups),
+		now:     wallNow,
+	}
+}
+
+// FetchGroups returns the cached groups of the subject, fetching them if they are missing or stale.
+func (c *CachedGroupFetcher) FetchGroups(ctx context.Context, subject string) ([]string, error) {
+	c.lock.Lock()
+	cached, ok := c.cache[subject]
+	c.lock.Unlock()
+
+	if ok && c.now().Before(cached.expiresAt) {
+		return cached.groups, nil
+	}
+
+	groups, err := c.fetcher.FetchGroups(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.cache[subject] = cachedGroups{groups: groups, expiresAt: c.now().Add(c.ttl)}
+	c.lock.Unlock()
+
+	return groups, nil
+}
+
+// truncateGroups truncates the groups claim to the maximum count and marks the overflow.
+// It is a utility function used to keep the token size bounded.
+func truncateGroups(claims jwt.MapClaims, maxGroups int) {
+	groups := stringSlice(claims[groupsClaim])
+	if len(groups) <= maxGroups {
+		return
+	}
+
+	claims[groupsClaim] = groups[:maxGroups]
+	claims[groupsOverflowClaim] = true
+}
@@ -0,0 +1,132 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupGroupsToken(t *testing.T, groups []string) jwt.MapClaims {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Subject:   "user",
+		}),
+		WithCustomClaims(map[string]interface{}{
+			"groups": groups,
+		}),
+		WithMaxGroups(2),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	return claims
+}
+
+func TestInlineGroups(t *testing.T) {
+	claims := setupGroupsToken(t, []string{"a", "b"})
+
+	if _, ok := claims["groups_overflow"]; ok {
+		t.Errorf("Expected groups_overflow to be absent")
+	}
+
+	groups, err := GroupsFromClaims(context.Background(), claims, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(groups, []string{"a", "b"}) {
+		t.Errorf("Expected groups to be %v, got %v", []string{"a", "b"}, groups)
+	}
+}
+
+func TestOverflowGroups(t *testing.T) {
+	source := []string{"a", "b", "c", "d"}
+	claims := setupGroupsToken(t, source)
+
+	if claims["groups_overflow"] != true {
+		t.Errorf("Expected groups_overflow to be true")
+	}
+
+	if inline := stringSlice(claims["groups"]); len(inline) != 2 {
+		t.Errorf("Expected 2 inline groups, got %v", inline)
+	}
+
+	if len(source) != 4 {
+		t.Errorf("Expected source groups to be unchanged, got %v", source)
+	}
+
+	fetcher := GroupFetcherFunc(func(ctx context.Context, subject string) ([]string, error) {
+		if subject != "user" {
+			t.Errorf("Expected subject to be user, got %v", subject)
+		}
+		return source, nil
+	})
+
+	groups, err := GroupsFromClaims(context.Background(), claims, fetcher)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(groups, source) {
+		t.Errorf("Expected groups to be %v, got %v", source, groups)
+	}
+}
+
+func TestOverflowGroupsWithoutFetcher(t *testing.T) {
+	claims := setupGroupsToken(t, []string{"a", "b", "c"})
+
+	_, err := GroupsFromClaims(context.Background(), claims, nil)
+	if err != ErrGroupFetcherNil {
+		t.Errorf("Expected error: %v, got: %v", ErrGroupFetcherNil, err)
+	}
+}
+
+func TestCachedGroupFetcher(t *testing.T) {
+	calls := 0
+	fetcher := NewCachedGroupFetcher(GroupFetcherFunc(func(ctx context.Context, subject string) ([]string, error) {
+		calls++
+		return []string{subject}, nil
+	}), time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := fetcher.FetchGroups(context.Background(), "user"); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, err := fetcher.FetchGroups(context.Background(), "other"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected 2 fetches, got %d", calls)
+	}
+}
+
+func TestInvalidMaxGroups(t *testing.T) {
+	_, err := NewToken(
+		SecretKey(secretKey),
+		WithMaxGroups(0),
+	)
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
@@ -24,7 +24,7 @@
 //			WithCustomClaims(map[string]interface{}{
 //				"role": "admin",
 //			}),
-//			SecretKey([]byte("access_secret")),
+//			SecretKey([]byte("access-secret-of-at-least-32-bytes")),
 //		)
 //
 //		if err != nil {
@@ -36,7 +36,7 @@
 //			WithStandardClaims(jwt.StandardClaims{
 //				ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
 //			}),
-//			SecretKey([]byte("refresh_secret")),
+//			SecretKey([]byte("refresh-secret-of-at-least-32-bytes")),
 //		)
 //
 //		if err != nil {
@@ -56,55 +56,190 @@
 package hydrate
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"time"
 
-	m "github.com/garrettladley/mattress"
 	"github.com/golang-jwt/jwt"
 )
 
 // TokenConfig defines the configuration for tokens.
 // These include the secret key, standard claims, and custom claims.
 type TokenConfig struct {
-	secretKey      *m.Secret[[]byte]      // Secret key used to sign the token
-	signingMethod  jwt.SigningMethod      // Signing method used to sign the token
-	standardClaims jwt.StandardClaims     // Standard claims for the token
-	customClaims   map[string]interface{} // Custom claims for the token
-	token          *string                // Token generated using the configuration
-	expiration     time.Duration          // Expiration time for the token
+	secretKey           secretStore              // Secret key used to sign the token
+	secretErr           error                    // Error from storing the secret key in secure memory
+	insecureSecret      bool                     // Whether the secret key may fall back to plain memory
+	allowWeakKeys       bool                     // Whether secret keys shorter than the minimum are accepted
+	eagerInit           bool                     // Whether the signer is prewarmed when the configuration is created
+	pendingSecret       []byte                   // Secret key awaiting the fallback decision
+	allowedMethods      []jwt.SigningMethod      // Signing methods accepted when verifying the token
+	verifyOnly          bool                     // Whether the configuration may only verify tokens
+	keySet              map[string]secretStore   // Secret keys by key ID for rotation
+	activeKeyID         string                   // Key ID used to sign new tokens
+	remoteKeys          *remoteKeySet            // Public keys fetched from a JWKS URL
+	secretProvider      SecretProvider           // Provider of the secret keys
+	keyFiles            *keyFiles                // Keys loaded from files and reloaded on change
+	keyReload           *keyReload               // Reload settings for the key files
+	expectedIssuer      string                   // Issuer required in parsed tokens
+	expectedAudiences   []string                 // Audiences required in parsed tokens
+	audienceMatch       AudienceMatch            // Whether any or all expected audiences are required
+	audiences           []string                 // Audiences written to the aud claim as an array
+	sensitiveClaims     map[string]bool          // Claims redacted by RedactClaims
+	flattenedJWS        bool                     // Whether flattened JSON tokens are accepted
+	claimValidators     []ClaimValidator         // Application-specific checks of the claims
+	maxAge              time.Duration            // Maximum age of a token, measured from its iat claim
+	clock               Clock                    // Clock used for expiration math and time-based claim checks
+	wallClock           func() time.Time         // Wall clock aging replaced keys and cached versions, wallNow outside tests
+	reloadTicker        reloadTicker             // Ticks driving the reloads of key files, newReloadTicker outside tests
+	tokenUse            string                   // Value of the "token_use" claim stamped on generated tokens
+	expectedTokenUse    string                   // Value of the "token_use" claim required in parsed tokens
+	noTokenUse          bool                     // Whether GenerateTokenPair leaves the "token_use" claim out
+	tokenStore          TokenStore               // Store recording the IDs of rotated refresh tokens
+	reuseDetector       ReuseDetector            // Detector revoking refresh token families on replay
+	faults              *faultInjector           // Failures injected for chaos testing
+	slidingWindow       time.Duration            // Remaining lifetime below which ValidateAndSlide re-issues tokens
+	maxSessionLifetime  time.Duration            // Maximum lifetime of a session across refreshes
+	authTime            int64                    // Value of the "auth_time" claim stamped on generated tokens
+	pairID              string                   // Value of the "pair_id" claim linking an access and refresh token
+	revocationStore     RevocationStore          // Denylist of revoked token IDs checked when parsing tokens
+	optionalTokenID     bool                     // Whether tokens without a jti claim pass the revocation check
+	versionResolver     TokenVersionResolver     // Source of the current token version of subjects
+	versionCache        *versionCache            // Optional cache of the resolved token versions
+	maxClaimValueSize   int                      // Maximum encoded size of a claim value
+	maxClaimCount       int                      // Maximum number of claims
+	maxEncodedTokenSize int                      // Maximum size of the encoded token
+	requestBodyLimit    int64                    // Maximum size of the request bodies hashed by VerifyRequestToken
+	privateKey          interface{}              // Private key used to sign the token with asymmetric methods
+	publicKey           interface{}              // Public key used to verify the token with asymmetric methods
+	signingMethod       jwt.SigningMethod        // Signing method used to sign the token
+	standardClaims      jwt.StandardClaims       // Standard claims for the token
+	customClaims        map[string]interface{}   // Custom claims for the token
+	typedClaims         jwt.MapClaims            // Claims encoded from the struct set by WithTypedClaims
+	typedStruct         jwt.Claims               // Struct set by WithTypedClaims, signed as is unless claims are stamped onto it
+	allowClaimOverride  bool                     // Whether standard claims may silently replace custom claims
+	token               *string                  // Token generated using the configuration
+	expiration          time.Duration            // Expiration time for the token
+	fixedExpiration     bool                     // Whether the expiration was set as a duration by WithExpiration
+	missingExpiration   bool                     // Whether the claims were set without an exp claim
+	rand                io.Reader                // Source of randomness for the token
+	provenance          bool                     // Whether issuer-set claim keys are recorded in the token
+	maxGroups           int                      // Maximum number of inline groups before overflowing
+	audienceExpiry      map[string]time.Duration // Shorter lifetimes for specific audiences
+	tokenExpiry         int64                    // Expiration time of the generated token, if any
+	closed              bool                     // Whether the configuration has been closed
 }
 
 // NewToken instantiates a new instance of TokenConfig with the provided options.
-// If the secret key is nil, an error is returned.
+// If neither a secret key nor a key is set, or the random source is broken, an error is returned.
+// Errors of the options are wrapped in ErrInvalidTokenConfig, so errors.Is matches both.
+// If WithExpiration is combined with an exp claim, the duration wins and the configuration is returned along with
+// the ErrExpirationOverridden warning.
 func NewToken(options ...func(*TokenConfig) error) (*TokenConfig, error) {
 	token := &TokenConfig{
 		signingMethod: jwt.SigningMethodHS256,
+		rand:          rand.Reader,
+		clock:         realClock{},
+		wallClock:     wallNow,
+		reloadTicker:  newReloadTicker,
 	}
 
 	var err error
 	for _, option := range options {
 		err = option(token)
-		if err != nil {
-			return nil, ErrInvalidTokenConfig
+		if errors.Is(err, ErrInvalidTokenConfig) {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTokenConfig, err)
+		}
+	}
+
+	if token.faults != nil {
+		token.faults.wrapStores(token)
+	}
+
+	if token.typedClaims != nil && (token.standardClaims != (jwt.StandardClaims{}) || len(token.customClaims) > 0) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTokenConfig, ErrClaimConflict)
+	}
+
+	var warning error
+	typedExp, hasTypedExp := numericClaim(token.typedClaims["exp"])
+	switch {
+	case token.fixedExpiration:
+		if token.standardClaims.ExpiresAt != 0 || (hasTypedExp && typedExp != 0) {
+			warning = ErrExpirationOverridden
 		}
+	case token.missingExpiration:
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTokenConfig, ErrStandardClaimMissing)
+	case token.standardClaims.ExpiresAt != 0:
+		token.expiration = time.Duration(token.standardClaims.ExpiresAt-token.now().Unix()) * time.Second
+	case hasTypedExp:
+		token.expiration = time.Duration(typedExp-token.now().Unix()) * time.Second
 	}
 
-	if token.secretKey == nil {
+	if token.secretErr != nil {
+		if err := token.fallbackSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	if token.secretKey == nil && token.privateKey == nil && token.publicKey == nil && len(token.keySet) == 0 && token.remoteKeys == nil && token.secretProvider == nil && token.keyFiles == nil {
 		return nil, ErrInvalidSecretKey
 	}
 
-	return token, nil
+	if _, ok := token.keySet[token.activeKeyID]; token.activeKeyID != "" && token.publicKey == nil && !ok {
+		return nil, fmt.Errorf("%w: active key ID %q is not in the key set", ErrInvalidTokenConfig, token.activeKeyID)
+	}
+
+	if err := token.checkSecretStrength(); err != nil {
+		return nil, err
+	}
+
+	if err := checkRandSource(token.rand); err != nil {
+		return nil, err
+	}
+
+	if method, ok := token.signingMethod.(*signerMethod); ok {
+		method.rand = token.rand
+	}
+
+	if token.eagerInit {
+		if err := token.prewarm(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if token.remoteKeys != nil {
+		token.remoteKeys.clock = token.clock
+		token.remoteKeys.start()
+	}
+
+	if token.keyFiles != nil {
+		token.keyFiles.start(token.keyReload, token.reloadTicker, token.wallClock)
+	}
+
+	return token, warning
 }
 
-// SecretKey sets the secret key for the token.
-// If the secret key is nil, an error is returned.
+// SecretKey sets the secret key for the token, stored in secure memory.
+// If secure memory is unavailable, NewToken reports why unless WithInsecureSecretFallback is set.
 func SecretKey(key []byte) func(*TokenConfig) error {
 	return func(t *TokenConfig) error {
-		secretKey, err := m.NewSecret(key)
+		secretKey, err := newSecureSecret(key)
 		if err != nil {
-			return ErrInvalidSecretKey
+			t.secretKey = nil
+			t.secretErr = err
+			t.pendingSecret = append([]byte(nil), key...)
+			return nil
 		}
 
 		t.secretKey = secretKey
+		t.secretErr = nil
+		t.pendingSecret = nil
 		return nil
 	}
 }
@@ -123,15 +258,11 @@ func WithSigningMethod(method jwt.SigningMethod) func(*TokenConfig) error {
 }
 
 // WithStandardClaims optionally sets the standard claims for the token.
-// Requires the expiration time to be set.
+// Requires the expiration time to be set, unless WithExpiration is.
 func WithStandardClaims(claims jwt.StandardClaims) func(*TokenConfig) error {
 	return func(t *TokenConfig) error {
-		if claims.ExpiresAt == 0 {
-			return ErrStandardClaimMissing
-		}
-
 		t.standardClaims = claims
-		t.expiration = time.Duration(claims.ExpiresAt-time.Now().Unix()) * time.Second
+		t.missingExpiration = claims.ExpiresAt == 0
 		return nil
 	}
 }
@@ -148,13 +279,51 @@ func WithCustomClaims(claims map[string]interface{}) func(*TokenConfig) error {
 	}
 }
 
+// WithAllowClaimOverride optionally lets the standard claims replace custom claims with the same key, e.g. a custom
+// "sub", instead of GenerateToken failing with ErrClaimConflict.
+func WithAllowClaimOverride() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.allowClaimOverride = true
+		return nil
+	}
+}
+
+// WithExpiration optionally sets the lifetime of the tokens, so the exp claim is computed when each token is
+// generated or regenerated rather than fixed when the configuration is created.
+// The duration wins over an exp claim set by WithStandardClaims or WithTypedClaims; NewToken then returns
+// ErrExpirationOverridden along with the configuration.
+func WithExpiration(d time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if d <= 0 {
+			return fmt.Errorf("%w: expiration must be positive", ErrInvalidTokenConfig)
+		}
+
+		t.expiration = d
+		t.fixedExpiration = true
+		return nil
+	}
+}
+
 // GenerateTokenPair generates a new access and refresh token pair using the configured options.
+// The tokens are stamped with a "token_use" claim, which each configuration then expects, unless WithoutTokenUse is set.
+// Both tokens share a random "pair_id" claim, so the access configuration only refreshes with its own refresh token.
 // Returns the access and refresh tokens, or an error if one occurs.
 func GenerateTokenPair(accessConfig, refreshConfig *TokenConfig) ([]byte, []byte, error) {
 	if accessConfig == nil || refreshConfig == nil {
 		return nil, nil, ErrTokenConfigNil
 	}
 
+	accessConfig.stampTokenUse(TokenUseAccess)
+	refreshConfig.stampTokenUse(TokenUseRefresh)
+
+	authTime := accessConfig.now().Unix()
+	accessConfig.stampAuthTime(authTime)
+	refreshConfig.stampAuthTime(authTime)
+
+	if err := stampPairID(accessConfig, refreshConfig); err != nil {
+		return nil, nil, err
+	}
+
 	accessToken, err := accessConfig.GenerateToken()
 	if err != nil {
 		return nil, nil, err
@@ -169,55 +338,185 @@ func GenerateTokenPair(accessConfig, refreshConfig *TokenConfig) ([]byte, []byte
 }
 
 // GenerateToken generates a new token using the configured options.
-// Will overwrite any custom claims with the provided standard claims.
+// Custom claims sharing a key with the standard claims, or with the audiences, fail with ErrClaimConflict listing the
+// keys, whether the token is generated or regenerated. With WithAllowClaimOverride, the standard claims win instead.
 // Returns the access token, or an error if one occurs.
 func (t *TokenConfig) GenerateToken() ([]byte, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	if !t.canSign() {
+		return nil, ErrSigningNotConfigured
+	}
+
+	if err := t.checkClaimConflicts(); err != nil {
+		return nil, err
+	}
+
 	if t.token != nil {
 		return t.regenerateToken()
 	}
 
 	combinedClaims := make(jwt.MapClaims)
 
-	copyClaims(&combinedClaims, t.standardClaims, t.customClaims)
+	if t.typedClaims != nil {
+		copyCustomClaims(&combinedClaims, t.typedClaims)
+	} else {
+		copyClaims(&combinedClaims, t.standardClaims, t.customClaims)
+	}
+	if t.fixedExpiration {
+		combinedClaims["exp"] = t.now().Add(t.expiration).Unix()
+	}
+
+	if len(t.audiences) > 0 {
+		combinedClaims["aud"] = t.audiences
+	}
+
+	if t.maxGroups > 0 {
+		truncateGroups(combinedClaims, t.maxGroups)
+	}
+
+	if t.provenance {
+		combinedClaims[provenanceClaim] = issuerClaimKeys(t.standardClaims)
+	}
+
+	t.updateAudienceExpiry(combinedClaims)
+	t.updateTokenUse(combinedClaims)
+	t.updateAuthTime(combinedClaims)
+	t.updatePairID(combinedClaims)
+	t.capExpiration(combinedClaims)
 
-	token := jwt.NewWithClaims(t.signingMethod, jwt.MapClaims(combinedClaims))
-	signedToken, err := token.SignedString(t.secretKey.Expose())
+	if _, ok := combinedClaims["jti"]; !ok && (t.tokenStore != nil || t.reuseDetector != nil || t.revocationStore != nil) {
+		jti, err := newTokenID(t.rand)
+		if err != nil {
+			return nil, err
+		}
+		combinedClaims["jti"] = jti
+	}
+
+	if err := t.updateTokenVersion(combinedClaims); err != nil {
+		return nil, err
+	}
+
+	if _, ok := combinedClaims["iat"]; !ok && t.revocationStore != nil {
+		combinedClaims["iat"] = t.now().Unix()
+	}
+
+	if _, ok := combinedClaims[familyClaim]; !ok && t.reuseDetector != nil {
+		family, err := newTokenID(t.rand)
+		if err != nil {
+			return nil, err
+		}
+		combinedClaims[familyClaim] = family
+	}
+
+	var payload jwt.Claims = combinedClaims
+	if t.typedStruct != nil && reflect.DeepEqual(combinedClaims, t.typedClaims) {
+		payload = t.typedStruct
+	}
+
+	signedToken, err := t.signClaims(payload, combinedClaims)
 	if err != nil {
-		return nil, ErrSigningToken
+		return nil, err
 	}
 
-	t.token = &signedToken
+	t.setToken(signedToken, combinedClaims)
 
 	return []byte(signedToken), nil
 }
 
+// checkClaimConflicts returns ErrClaimConflict listing the custom claim keys the standard claims or the audiences
+// would replace, unless WithAllowClaimOverride is set.
+func (t *TokenConfig) checkClaimConflicts() error {
+	if t.allowClaimOverride || len(t.customClaims) == 0 {
+		return nil
+	}
+
+	var conflicts []string
+	for _, key := range issuerClaimKeys(t.standardClaims) {
+		if _, ok := t.customClaims[key]; ok {
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	if _, ok := t.customClaims["aud"]; ok && len(t.audiences) > 0 && t.standardClaims.Audience == "" {
+		conflicts = append(conflicts, "aud")
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrClaimConflict, strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
 // regenerateToken generates a new token using the configured options.
+// The held token is verified without its time claims, so an expired token is re-issued with a fresh exp and iat
+// instead of failing, while a token with an invalid signature is still rejected.
 // Returns the token, or an error if one occurs.
 func (t *TokenConfig) regenerateToken() ([]byte, error) {
 	if t.token == nil {
 		return nil, ErrTokenNotGenerated
 	}
 
-	token, err := t.ParseToken()
+	claims, err := t.verifySignature(*t.token)
 	if err != nil {
 		return nil, err
 	}
 
+	return t.reissueToken(claims)
+}
+
+// verifySignature verifies the signature and the expected issuer and audience of a token string, ignoring its exp,
+// nbf, and iat claims. Returns the claims, or ErrTokenInvalid wrapping ErrSignatureInvalid if the signature does not verify.
+func (t *TokenConfig) verifySignature(tokenString string) (jwt.MapClaims, error) {
+	tokenString, err := t.compactToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, t.keyFunc)
+	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok {
+			if inner := keyLookupError(validationErr.Inner); inner != nil {
+				return nil, inner
+			}
+			return nil, invalidTokenError(err)
+		}
+		return nil, err
+	}
+
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return nil, ErrClaimsInvalid
 	}
 
+	if err := t.checkExpectedClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// reissueToken signs the claims of a previous token with fresh expiration times.
+// Returns the token, or an error if one occurs.
+func (t *TokenConfig) reissueToken(claims jwt.MapClaims) ([]byte, error) {
 	claims = t.updateExpiration(claims)
 	claims = t.updateIssuedAt(claims)
+	t.updateAudienceExpiry(claims)
+	t.updateTokenUse(claims)
+	t.updateAuthTime(claims)
+	t.updatePairID(claims)
+	t.capExpiration(claims)
 
-	token = jwt.NewWithClaims(t.signingMethod, claims)
-	signedToken, err := token.SignedString(t.secretKey.Expose())
+	signedToken, err := t.signClaims(claims, claims)
 	if err != nil {
-		return nil, ErrSigningToken
+		return nil, err
 	}
 
-	t.token = &signedToken
+	t.setToken(signedToken, claims)
 
 	return []byte(signedToken), nil
 }
@@ -226,7 +525,7 @@ func (t *TokenConfig) regenerateToken() ([]byte, error) {
 // If the expiration claim is not present, it won't be added.
 func (t *TokenConfig) updateExpiration(claims jwt.MapClaims) jwt.MapClaims {
 	if _, ok := claims["exp"]; ok {
-		claims["exp"] = time.Now().Add(t.expiration).Unix()
+		claims["exp"] = t.now().Add(t.expiration).Unix()
 	}
 	return claims
 }
@@ -235,40 +534,201 @@ func (t *TokenConfig) updateExpiration(claims jwt.MapClaims) jwt.MapClaims {
 // If the issued at claim is not present, it won't be added.
 func (t *TokenConfig) updateIssuedAt(claims jwt.MapClaims) jwt.MapClaims {
 	if _, ok := claims["iat"]; ok {
-		claims["iat"] = time.Now().Unix()
+		claims["iat"] = t.now().Unix()
 	}
 	return claims
 }
 
 // RefreshToken takes a refresh config and generates a new access token using the configured options.
+// If the configuration was paired by GenerateTokenPair, the refresh token must carry the same pair ID,
+// otherwise ErrTokenPairMismatch is returned. If the refresh config has a TokenStore, the refresh token is
+// consumed, and presenting it again fails with ErrRefreshTokenReused.
 // Returns the access token, or an error if one occurs.
 func (t *TokenConfig) RefreshToken(refreshConfig *TokenConfig) ([]byte, error) {
-	if t.token == nil || refreshConfig == nil {
+	if t.closed || (refreshConfig != nil && refreshConfig.closed) {
+		return nil, ErrConfigClosed
+	}
+
+	if t.token == nil || refreshConfig == nil || refreshConfig.token == nil {
 		return nil, ErrTokenNotGenerated
 	}
 
-	isValid := refreshConfig.IsValid()
+	if !t.canSign() {
+		return nil, ErrSigningNotConfigured
+	}
+
+	refreshClaims, err := refreshConfig.validateClaims(*refreshConfig.token)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil, err
+	} else if err != nil {
+		return nil, invalidTokenError(err)
+	}
+
+	if err := refreshConfig.checkReplay(refreshClaims); err != nil {
+		return nil, err
+	}
 
-	if !isValid {
-		return nil, ErrTokenInvalid
+	if err := t.checkPairID(refreshClaims); err != nil {
+		return nil, err
+	}
+
+	if err := t.checkSessionLifetime(refreshClaims); err != nil {
+		return nil, err
+	}
+
+	if err := refreshConfig.checkSessionLifetime(refreshClaims); err != nil {
+		return nil, err
 	}
 
+	// Sign before consuming the refresh token, so a signing failure can be retried with it.
+	accessState := t.saveToken()
 	accessToken, err := t.GenerateToken()
 	if err != nil {
 		return nil, err
 	}
 
+	if refreshConfig.tokenStore != nil {
+		if err := refreshConfig.consumeToken(refreshClaims); err != nil {
+			t.restoreToken(accessState)
+			return nil, err
+		}
+	}
+
 	return accessToken, nil
 }
 
+// RotateTokenPair takes a refresh config and generates a new access token and a new refresh token.
+// The new refresh token gets a fresh jti, iat, and exp. If the refresh config has a TokenStore, the
+// previous refresh token is recorded as used, and presenting it again fails with ErrRefreshTokenReused.
+// With a ReuseDetector, presenting it again also revokes every token rotated from the same login.
+// Returns the access and refresh tokens, or an error if one occurs.
+func (t *TokenConfig) RotateTokenPair(refreshConfig *TokenConfig) ([]byte, []byte, error) {
+	if t.closed || (refreshConfig != nil && refreshConfig.closed) {
+		return nil, nil, ErrConfigClosed
+	}
+
+	if t.token == nil || refreshConfig == nil || refreshConfig.token == nil {
+		return nil, nil, ErrTokenNotGenerated
+	}
+
+	if !t.canSign() || !refreshConfig.canSign() {
+		return nil, nil, ErrSigningNotConfigured
+	}
+
+	claims, err := refreshConfig.ExtractClaims()
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return nil, nil, err
+	} else if err != nil {
+		return nil, nil, invalidTokenError(err)
+	}
+
+	if err := t.checkPairID(claims); err != nil {
+		return nil, nil, err
+	}
+
+	if err := t.checkSessionLifetime(claims); err != nil {
+		return nil, nil, err
+	}
+
+	if err := refreshConfig.checkSessionLifetime(claims); err != nil {
+		return nil, nil, err
+	}
+
+	// Sign both tokens before marking the presented one as used, so a signing failure can be retried with it.
+	previous := make(jwt.MapClaims, len(claims))
+	copyCustomClaims(&previous, claims)
+	accessState, refreshState := t.saveToken(), refreshConfig.saveToken()
+
+	accessToken, refreshToken, err := t.rotateTokens(refreshConfig, claims)
+	if err == nil && refreshConfig.reuseDetector != nil {
+		err = refreshConfig.checkReuse(previous)
+	}
+	if err == nil && refreshConfig.tokenStore != nil {
+		err = refreshConfig.consumeToken(previous)
+	}
+
+	if err != nil {
+		t.restoreToken(accessState)
+		refreshConfig.restoreToken(refreshState)
+		return nil, nil, err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// rotateTokens signs a new access token, and a new refresh token from the claims with a fresh jti and iat.
+func (t *TokenConfig) rotateTokens(refreshConfig *TokenConfig, claims jwt.MapClaims) ([]byte, []byte, error) {
+	accessToken, err := t.GenerateToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if claims["jti"], err = newTokenID(refreshConfig.rand); err != nil {
+		return nil, nil, err
+	}
+	claims["iat"] = refreshConfig.now().Unix()
+
+	refreshToken, err := refreshConfig.reissueToken(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// consumeToken records the jti of the claims as used in the token store until the token expires.
+// Returns ErrClaimsInvalid if the claims lack a jti or an exp, since the store could not tell how long to keep it,
+// ErrRefreshTokenReused if it was already used, or ErrStoringToken if the store fails.
+func (t *TokenConfig) consumeToken(claims jwt.MapClaims) error {
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return ErrClaimsInvalid
+	}
+
+	exp, ok := numericClaim(claims["exp"])
+	if !ok {
+		return ErrClaimsInvalid
+	}
+
+	used, err := t.tokenStore.Consume(context.Background(), jti, time.Unix(exp, 0))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if used {
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
 // ExtractClaims extracts the claims from the token using the configured options.
 // Returns the claims, or an error if one occurs.
 func (t *TokenConfig) ExtractClaims() (jwt.MapClaims, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
 	if t.token == nil {
 		return nil, ErrTokenNotGenerated
 	}
 
-	token, err := t.ParseToken()
+	return t.extractClaims(*t.token)
+}
+
+// ExtractClaimsFrom extracts the claims from the provided token string using the configured options.
+// The token held by the configuration is left untouched. Returns the claims, or an error if one occurs.
+func (t *TokenConfig) ExtractClaimsFrom(tokenString string) (jwt.MapClaims, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	return t.extractClaims(tokenString)
+}
+
+// extractClaims parses a token string and runs the claim validators on its claims.
+func (t *TokenConfig) extractClaims(tokenString string) (jwt.MapClaims, error) {
+	token, err := t.parseToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -278,50 +738,134 @@ func (t *TokenConfig) ExtractClaims() (jwt.MapClaims, error) {
 		return nil, ErrClaimsInvalid
 	}
 
+	if err := t.checkRevocation(claims); err != nil {
+		return nil, err
+	}
+
+	if err := t.checkFamilyRevocation(claims); err != nil {
+		return nil, err
+	}
+
+	if err := t.checkTokenVersion(claims); err != nil {
+		return nil, err
+	}
+
+	if err := t.runClaimValidators(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
 // IsValid checks if the token is valid using the configured options.
-// Returns true if the token is valid, or false if it is not.
+// Returns true if the token is valid, or false if it is not. Use Validate to find out why a token is invalid.
 func (t *TokenConfig) IsValid() bool {
+	return t.Validate() == nil
+}
+
+// ParseToken parses the token using the configured options.
+// Returns the token, or an error if one occurs.
+func (t *TokenConfig) ParseToken() (*jwt.Token, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
 	if t.token == nil {
-		return false
+		return nil, ErrTokenNotGenerated
 	}
 
-	token, err := t.ParseToken()
+	return t.parseToken(*t.token)
+}
+
+// ParseTokenString parses the provided token string using the configured options.
+// The token held by the configuration is left untouched. Returns the token, or an error if one occurs.
+func (t *TokenConfig) ParseTokenString(tokenString string) (*jwt.Token, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	return t.parseToken(tokenString)
+}
+
+// parseToken parses and verifies a token string using the configured options.
+// The expected issuer and audience, if any, are checked. Returns the token, or an error if one occurs.
+func (t *TokenConfig) parseToken(tokenString string) (*jwt.Token, error) {
+	token, err := t.verifyToken(tokenString)
 	if err != nil {
-		return false
+		if validationErr, ok := err.(*jwt.ValidationError); ok {
+			if inner := keyLookupError(validationErr.Inner); inner != nil {
+				return nil, inner
+			}
+
+			if validationErr.Errors&jwt.ValidationErrorNotValidYet != 0 {
+				return nil, ErrTokenNotYetValid
+			}
+			return nil, invalidTokenError(err)
+		}
+		return nil, err
+	}
+
+	if t.faults != nil {
+		if err := t.faults.expiredToken(token.Claims.(jwt.MapClaims), ErrTokenInvalid); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
+// verifyToken parses and verifies a token string, checking the expected issuer and audience.
+// Verification failures are returned as the *jwt.ValidationError reported by the jwt package.
+func (t *TokenConfig) verifyToken(tokenString string) (*jwt.Token, error) {
+	tokenString, err := t.compactToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, t.keyFunc)
+	if err != nil {
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return false
+		return nil, ErrClaimsInvalid
 	}
 
-	if !token.Valid {
-		return false
+	if err := t.validateTimeClaims(claims); err != nil {
+		return nil, err
 	}
 
-	if _, ok := claims["exp"]; ok {
-		if int64(claims["exp"].(float64)) < time.Now().Unix() {
-			return false
-		}
+	if err := t.checkExpectedClaims(claims); err != nil {
+		return nil, err
 	}
 
-	return true
+	return token, nil
 }
 
-// ParseToken parses the token using the configured options.
-// Returns the token, or an error if one occurs.
-func (t *TokenConfig) ParseToken() (*jwt.Token, error) {
-	token, err := jwt.Parse(*t.token, func(token *jwt.Token) (interface{}, error) {
-		return t.secretKey.Expose(), nil
-	})
-	if err != nil {
-		return nil, ErrTokenInvalid
+// invalidTokenError returns ErrTokenInvalid for the verification failure. Signature failures also wrap
+// ErrSignatureInvalid, so LikelyWrongKey recognizes them.
+func invalidTokenError(err error) error {
+	if LikelyWrongKey(err) {
+		return fmt.Errorf("%w: %w", ErrTokenInvalid, ErrSignatureInvalid)
 	}
 
-	return token, nil
+	return ErrTokenInvalid
+}
+
+// keyLookupError returns the error raised while looking up the verification key, or nil for any other error.
+func keyLookupError(err error) error {
+	switch err {
+	case ErrUnexpectedSigningMethod, ErrUnknownKeyID, ErrJWKSUnavailable:
+		return err
+	}
+
+	if errors.Is(err, ErrSecretProvider) || errors.Is(err, ErrInjectedFault) {
+		return err
+	}
+
+	return nil
 }
 
 // copyStandardClaims copies the standard claims from a jwt.StandardClaims instance to a jwt.MapClaims instance.
@@ -1,15 +1,20 @@
 package hydrate
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-var secretKey = []byte("secret")
+var (
+	secretKey      = []byte("hydrate-test-secret-key-0123456789")
+	otherSecretKey = []byte("hydrate-other-secret-key-0123456789")
+)
 
 func compareTokens(t1, t2 []byte) (bool, error) {
 	token1, err := jwt.Parse(string(t1), func(token *jwt.Token) (interface{}, error) {
@@ -46,7 +51,7 @@ func compareClaims(c1, c2 jwt.MapClaims) bool {
 	return reflect.DeepEqual(c1, c2)
 }
 
-func setupToken(t *testing.T) ([]byte, *TokenConfig, error) {
+func setupToken(t *testing.T, options ...func(*TokenConfig) error) ([]byte, *TokenConfig, error) {
 	secretKey := secretKey
 	claims := jwt.StandardClaims{
 		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
@@ -54,10 +59,10 @@ func setupToken(t *testing.T) ([]byte, *TokenConfig, error) {
 		Audience:  "test",
 	}
 
-	tokenConfig, err := NewToken(
+	tokenConfig, err := NewToken(append(options,
 		SecretKey(secretKey),
 		WithStandardClaims(claims),
-	)
+	)...)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -70,7 +75,7 @@ func setupToken(t *testing.T) ([]byte, *TokenConfig, error) {
 	return token, tokenConfig, nil
 }
 
-func setupTokens(t *testing.T) (*TokenConfig, *TokenConfig, error) {
+func setupTokens(t *testing.T, options ...func(*TokenConfig) error) (*TokenConfig, *TokenConfig, error) {
 	accessClaims := jwt.StandardClaims{
 		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
 		Issuer:    "test",
@@ -81,18 +86,18 @@ func setupTokens(t *testing.T) (*TokenConfig, *TokenConfig, error) {
 		ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
 	}
 
-	accessConfig, err := NewToken(
+	accessConfig, err := NewToken(append(options,
 		SecretKey(secretKey),
 		WithStandardClaims(accessClaims),
-	)
+	)...)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	refreshConfig, err := NewToken(
+	refreshConfig, err := NewToken(append(options,
 		SecretKey(secretKey),
 		WithStandardClaims(refreshClaims),
-	)
+	)...)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -128,8 +133,8 @@ func TestMissingStandardClaims(t *testing.T) {
 		WithStandardClaims(jwt.StandardClaims{}),
 	)
 
-	if err != ErrInvalidTokenConfig {
-		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	if !errors.Is(err, ErrInvalidTokenConfig) || !errors.Is(err, ErrStandardClaimMissing) {
+		t.Errorf("Expected error: %v, got: %v", ErrStandardClaimMissing, err)
 	}
 }
 
@@ -142,8 +147,8 @@ func TestMissingExpiresAt(t *testing.T) {
 		}),
 	)
 
-	if err != ErrInvalidTokenConfig {
-		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	if !errors.Is(err, ErrInvalidTokenConfig) || !errors.Is(err, ErrStandardClaimMissing) {
+		t.Errorf("Expected error: %v, got: %v", ErrStandardClaimMissing, err)
 	}
 }
 
@@ -192,12 +197,13 @@ func TestValidGenerateToken(t *testing.T) {
 }
 
 func TestValidRegenerateToken(t *testing.T) {
-	token, config, err := setupToken(t)
+	clock := NewFakeClock(time.Now())
+	token, config, err := setupToken(t, WithClock(clock))
 	if err != nil {
 		return
 	}
 
-	time.Sleep(1 * time.Second)
+	clock.Advance(1 * time.Second)
 
 	newToken, err := config.GenerateToken()
 	if err != nil {
@@ -214,8 +220,50 @@ func TestValidRegenerateToken(t *testing.T) {
 	}
 }
 
+func TestRegenerateExpiredToken(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	_, config, err := setupToken(t, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if config.State() != StateExpired {
+		t.Fatalf("Expected state %v, got %v", StateExpired, config.State())
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error regenerating an expired token: %v", err)
+	}
+
+	if config.State() != StateIssued {
+		t.Errorf("Expected state %v, got %v", StateIssued, config.State())
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if exp, _ := numericClaim(claims["exp"]); exp != clock.Now().Add(1*time.Hour).Unix() {
+		t.Errorf("Expected a fresh exp, got %v", time.Unix(exp, 0).Sub(clock.Now()))
+	}
+
+	forged := signTestClaims(t, jwt.MapClaims{"exp": clock.Now().Add(-1 * time.Hour).Unix()})
+	forged = forged[:len(forged)-2] + "xx"
+	tampered, err := NewToken(SecretKey(secretKey), WithClock(clock), WithToken(forged))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := tampered.GenerateToken(); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
 func TestValidRefreshToken(t *testing.T) {
-	access_config, refresh_config, err := setupTokens(t)
+	clock := NewFakeClock(time.Now())
+	access_config, refresh_config, err := setupTokens(t, WithClock(clock))
 	if err != nil {
 		return
 	}
@@ -226,7 +274,7 @@ func TestValidRefreshToken(t *testing.T) {
 		t.Errorf("Unexpected error generating token pair: %v", err)
 	}
 
-	time.Sleep(2 * time.Second)
+	clock.Advance(2 * time.Second)
 
 	newToken, err := access_config.RefreshToken(refresh_config)
 	if err != nil {
@@ -250,6 +298,22 @@ func TestInvalidRefreshToken(t *testing.T) {
 	}
 }
 
+func TestRefreshTokenUnissuedRefreshConfig(t *testing.T) {
+	_, accessConfig, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshConfig, err := NewToken(SecretKey(secretKey), WithExpiration(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrTokenNotGenerated {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenNotGenerated, err)
+	}
+}
+
 func TestValidExtractClaims(t *testing.T) {
 	_, config, err := setupToken(t)
 	if err != nil {
@@ -449,3 +513,123 @@ func TestCopyClaims(t *testing.T) {
 		t.Error("Custom name claim not copied")
 	}
 }
+
+func tokenExp(t *testing.T, config *TokenConfig) int64 {
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	exp, _ := numericClaim(claims["exp"])
+	return exp
+}
+
+func TestWithExpiration(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithClock(clock),
+		WithExpiration(1*time.Hour),
+		WithStandardClaims(jwt.StandardClaims{Issuer: "test"}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	if exp := tokenExp(t, config); exp != clock.Now().Add(1*time.Hour).Unix() {
+		t.Errorf("Expected a full lifetime from generation, got %v", time.Unix(exp, 0).Sub(clock.Now()))
+	}
+
+	clock.Advance(30 * time.Minute)
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error regenerating token: %v", err)
+	}
+
+	if exp := tokenExp(t, config); exp != clock.Now().Add(1*time.Hour).Unix() {
+		t.Errorf("Expected a full lifetime from regeneration, got %v", time.Unix(exp, 0).Sub(clock.Now()))
+	}
+}
+
+func TestWithExpirationOverridesExp(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithClock(clock),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: clock.Now().Add(24 * time.Hour).Unix()}),
+		WithExpiration(1*time.Hour),
+	)
+	if err != ErrExpirationOverridden || config == nil {
+		t.Fatalf("Expected error: %v with a configuration, got: %v", ErrExpirationOverridden, err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	if exp := tokenExp(t, config); exp != clock.Now().Add(1*time.Hour).Unix() {
+		t.Errorf("Expected the duration to win, got %v", time.Unix(exp, 0).Sub(clock.Now()))
+	}
+
+	typed := newTestTypedClaims()
+	if config, err := NewToken(SecretKey(secretKey), WithTypedClaims(typed), WithExpiration(1*time.Hour)); err != ErrExpirationOverridden || config == nil {
+		t.Errorf("Expected error: %v with a configuration for typed claims, got: %v", ErrExpirationOverridden, err)
+	}
+}
+
+func TestInvalidExpiration(t *testing.T) {
+	for _, d := range []time.Duration{0, -1 * time.Hour} {
+		_, err := NewToken(SecretKey(secretKey), WithExpiration(d))
+		if !errors.Is(err, ErrInvalidTokenConfig) || errors.Is(err, ErrStandardClaimMissing) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+}
+
+func TestClaimConflict(t *testing.T) {
+	standardClaims := jwt.StandardClaims{Subject: "alice", ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}
+	customClaims := map[string]interface{}{"sub": "mallory", "role": "admin"}
+
+	strict, err := NewToken(SecretKey(secretKey), WithStandardClaims(standardClaims), WithCustomClaims(customClaims))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := strict.GenerateToken(); !errors.Is(err, ErrClaimConflict) || !strings.Contains(err.Error(), "sub") {
+		t.Errorf("Expected error: %v listing sub, got: %v", ErrClaimConflict, err)
+	}
+
+	token := signTestClaims(t, jwt.MapClaims{"sub": "alice"})
+	regenerating, err := NewToken(SecretKey(secretKey), WithStandardClaims(standardClaims), WithCustomClaims(customClaims), WithToken(token))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := regenerating.GenerateToken(); !errors.Is(err, ErrClaimConflict) {
+		t.Errorf("Expected error: %v when regenerating, got: %v", ErrClaimConflict, err)
+	}
+
+	override, err := NewToken(SecretKey(secretKey), WithStandardClaims(standardClaims), WithCustomClaims(customClaims), WithAllowClaimOverride())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, step := range []string{"generated", "regenerated"} {
+		if _, err := override.GenerateToken(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		claims, err := override.ExtractClaims()
+		if err != nil {
+			t.Fatalf("Unexpected error extracting claims: %v", err)
+		}
+
+		if claims["sub"] != "alice" || claims["role"] != "admin" {
+			t.Errorf("Expected the standard sub to win in the %s token, got %v", step, claims)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package hydrate
+
+import "sort"
+
+// EnvironmentInspector reports which of a set of labeled verifiers accepts a token.
+// It is meant for debugging tokens collected from several environments, e.g. staging and production.
+type EnvironmentInspector struct {
+	verifiers map[string]*TokenConfig // Verifiers by label
+	labels    []string                // Labels in the order verifiers are tried
+}
+
+// EnvironmentResult is the outcome of validating a token with one labeled verifier.
+type EnvironmentResult struct {
+	Label    string // Label of the verifier
+	Err      error  // Validation error, or nil if the verifier accepts the token
+	WrongKey bool   // Whether the token looks signed with a different key
+}
+
+// NewEnvironmentInspector instantiates an EnvironmentInspector from the verifiers indexed by label.
+func NewEnvironmentInspector(verifiers map[string]*TokenConfig) (*EnvironmentInspector, error) {
+	if len(verifiers) == 0 {
+		return nil, ErrInvalidTokenConfig
+	}
+
+	inspector := &EnvironmentInspector{verifiers: make(map[string]*TokenConfig, len(verifiers))}
+	for label, verifier := range verifiers {
+		if verifier == nil {
+			return nil, ErrTokenConfigNil
+		}
+
+		inspector.verifiers[label] = verifier
+		inspector.labels = append(inspector.labels, label)
+	}
+	sort.Strings(inspector.labels)
+
+	return inspector, nil
+}
+
+// Inspect validates the token with every verifier and returns the label of the first one accepting it.
+// The label is empty if no verifier accepts the token; the results explain why each verifier rejected it.
+func (i *EnvironmentInspector) Inspect(tokenString string) (string, []EnvironmentResult) {
+	var match string
+	results := make([]EnvironmentResult, 0, len(i.labels))
+
+	for _, label := range i.labels {
+		verifier := i.verifiers[label]
+
+		var err error
+		if verifier.closed {
+			err = ErrConfigClosed
+		} else {
+			err = verifier.validateToken(tokenString)
+		}
+
+		if err == nil && match == "" {
+			match = label
+		}
+
+		results = append(results, EnvironmentResult{Label: label, Err: err, WrongKey: LikelyWrongKey(err)})
+	}
+
+	return match, results
+}
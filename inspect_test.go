@@ -0,0 +1,140 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func signWithKey(t *testing.T, key []byte, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("Unexpected error signing token: %v", err)
+	}
+
+	return token
+}
+
+func setupEnvironmentInspector(t *testing.T) *EnvironmentInspector {
+	production, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	staging, err := NewVerifier(SecretKey(otherSecretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	inspector, err := NewEnvironmentInspector(map[string]*TokenConfig{"production": production, "staging": staging})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return inspector
+}
+
+func TestEnvironmentInspectorSameClaims(t *testing.T) {
+	inspector := setupEnvironmentInspector(t)
+	claims := jwt.MapClaims{"sub": "user", "exp": time.Now().Add(1 * time.Hour).Unix()}
+
+	cases := []struct {
+		key      []byte
+		expected string
+		wrongKey string
+	}{
+		{secretKey, "production", "staging"},
+		{otherSecretKey, "staging", "production"},
+	}
+
+	for _, c := range cases {
+		match, results := inspector.Inspect(signWithKey(t, c.key, claims))
+		if match != c.expected {
+			t.Errorf("Expected %s to validate the token, got %q", c.expected, match)
+		}
+
+		for _, result := range results {
+			if result.WrongKey != (result.Label == c.wrongKey) {
+				t.Errorf("%s: Expected WrongKey to be %v, got: %v", result.Label, result.Label == c.wrongKey, result.Err)
+			}
+		}
+	}
+}
+
+func TestEnvironmentInspectorCorruptedToken(t *testing.T) {
+	inspector := setupEnvironmentInspector(t)
+
+	match, results := inspector.Inspect("not.a.token")
+	if match != "" {
+		t.Errorf("Expected no environment to validate the token, got %q", match)
+	}
+
+	for _, result := range results {
+		if result.Err != ErrTokenInvalid || result.WrongKey {
+			t.Errorf("%s: Expected error: %v, got: %v", result.Label, ErrTokenInvalid, result.Err)
+		}
+	}
+}
+
+func TestLikelyWrongKey(t *testing.T) {
+	token := signWithKey(t, otherSecretKey, jwt.MapClaims{"exp": time.Now().Add(1 * time.Hour).Unix()})
+
+	_, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return secretKey, nil })
+	if !LikelyWrongKey(err) {
+		t.Errorf("Expected a jwt signature error to be a likely wrong key, got: %v", err)
+	}
+
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{ErrSignatureInvalid, true},
+		{ErrTokenInvalid, false},
+		{ErrTokenExpired, false},
+		{nil, false},
+	}
+
+	for _, c := range cases {
+		if LikelyWrongKey(c.err) != c.expected {
+			t.Errorf("Expected LikelyWrongKey(%v) to be %v", c.err, c.expected)
+		}
+	}
+}
+
+func TestLikelyWrongKeyFromConfigMethods(t *testing.T) {
+	accessConfig, refreshConfig, err := setupTokens(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+
+	forged := signWithKey(t, otherSecretKey, jwt.MapClaims{"exp": time.Now().Add(1 * time.Hour).Unix()})
+	refreshConfig.token = &forged
+
+	if _, err := refreshConfig.ExtractClaims(); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected ExtractClaims to report a likely wrong key, got: %v", err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected RefreshToken to report a likely wrong key, got: %v", err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected RotateTokenPair to report a likely wrong key, got: %v", err)
+	}
+}
+
+func TestInvalidEnvironmentInspector(t *testing.T) {
+	if _, err := NewEnvironmentInspector(nil); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	if _, err := NewEnvironmentInspector(map[string]*TokenConfig{"production": nil}); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
@@ -0,0 +1,136 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// IntrospectionResponse is the RFC 7662 section 2.2 response describing a token.
+// Inactive tokens only set Active; the other members are omitted.
+type IntrospectionResponse struct {
+	Active    bool        `json:"active"`               // Whether the token is valid
+	Scope     string      `json:"scope,omitempty"`      // Space-delimited scopes granted by the token
+	Subject   string      `json:"sub,omitempty"`        // Subject of the token
+	Audience  interface{} `json:"aud,omitempty"`        // Audience of the token, a string or an array
+	Issuer    string      `json:"iss,omitempty"`        // Issuer of the token
+	TokenID   string      `json:"jti,omitempty"`        // ID of the token
+	ExpiresAt int64       `json:"exp,omitempty"`        // Expiration of the token, in seconds since the epoch
+	IssuedAt  int64       `json:"iat,omitempty"`        // Issuance of the token, in seconds since the epoch
+	NotBefore int64       `json:"nbf,omitempty"`        // Start of the validity of the token, in seconds since the epoch
+	TokenType string      `json:"token_type,omitempty"` // Always "Bearer" for active tokens
+}
+
+// CallerAuthenticator authenticates the resource server calling the introspection endpoint.
+// A non-nil error rejects the call with 401 Unauthorized before the token is looked at.
+type CallerAuthenticator func(r *http.Request) error
+
+// IntrospectionHandler is an http.Handler implementing the RFC 7662 token introspection endpoint.
+// It accepts POST requests with the form field "token" and describes it as active or inactive.
+type IntrospectionHandler struct {
+	verifier      *TokenConfig        // Configuration verifying the tokens, including their revocation
+	authenticator CallerAuthenticator // Authentication of the callers, nil to accept any
+}
+
+// NewIntrospectionHandler instantiates an IntrospectionHandler verifying tokens with the configuration, typically a verifier.
+// Tokens revoked in the RevocationStore of the configuration are reported inactive.
+func NewIntrospectionHandler(verifier *TokenConfig, options ...func(*IntrospectionHandler) error) (*IntrospectionHandler, error) {
+	if verifier == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	handler := &IntrospectionHandler{verifier: verifier}
+	for _, option := range options {
+		if err := option(handler); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler, nil
+}
+
+// WithCallerAuthenticator optionally restricts the endpoint to the callers the authenticator accepts, e.g. trusted
+// resource servers presenting client credentials. RFC 7662 requires some form of caller authentication.
+func WithCallerAuthenticator(authenticator CallerAuthenticator) func(*IntrospectionHandler) error {
+	return func(h *IntrospectionHandler) error {
+		if authenticator == nil {
+			return ErrInvalidTokenConfig
+		}
+
+		h.authenticator = authenticator
+		return nil
+	}
+}
+
+// ServeHTTP describes the token of the request form.
+// Invalid, expired, and revoked tokens get {"active": false} with 200 OK rather than an error status, per RFC 7662.
+func (h *IntrospectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeOAuthError(w, r, http.StatusMethodNotAllowed, ErrMalformedCredentials)
+		return
+	}
+
+	if h.authenticator != nil {
+		if err := h.authenticator(r); err != nil {
+			writeOAuthErrorCode(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRefreshBodySize)
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, r, http.StatusBadRequest, ErrMalformedCredentials)
+		return
+	}
+
+	tokenString := r.PostForm.Get("token")
+	if tokenString == "" {
+		writeOAuthError(w, r, http.StatusBadRequest, ErrNoTokenFound)
+		return
+	}
+
+	response, err := h.introspect(tokenString)
+	if err != nil {
+		writeOAuthError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// introspect describes the token. Only failures that say nothing about the token, e.g. an unreachable
+// RevocationStore, are returned as errors; any other failure makes the token inactive.
+func (h *IntrospectionHandler) introspect(tokenString string) (IntrospectionResponse, error) {
+	if h.verifier.closed {
+		return IntrospectionResponse{}, ErrConfigClosed
+	}
+
+	claims, err := h.verifier.validateClaims(tokenString)
+	if err != nil {
+		if DescribeError(err).Status >= http.StatusInternalServerError {
+			return IntrospectionResponse{}, err
+		}
+
+		return IntrospectionResponse{Active: false}, nil
+	}
+
+	return introspectionResponse(claims), nil
+}
+
+// introspectionResponse describes the claims of an active token.
+func introspectionResponse(claims jwt.MapClaims) IntrospectionResponse {
+	response := IntrospectionResponse{Active: true, TokenType: "Bearer", Audience: claims["aud"]}
+	response.Scope, _ = claims["scope"].(string)
+	response.Subject, _ = claims["sub"].(string)
+	response.Issuer, _ = claims["iss"].(string)
+	response.TokenID, _ = claims["jti"].(string)
+	response.ExpiresAt, _ = numericClaim(claims["exp"])
+	response.IssuedAt, _ = numericClaim(claims["iat"])
+	response.NotBefore, _ = numericClaim(claims["nbf"])
+
+	return response
+}
@@ -0,0 +1,130 @@
+package hydrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func introspect(t *testing.T, handler http.Handler, token string, header http.Header) (int, map[string]interface{}) {
+	form := url.Values{"token": {token}}
+	request := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for name, values := range header {
+		request.Header[name] = values
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatalf("Unexpected error decoding response: %v", err)
+	}
+
+	return recorder.Code, body
+}
+
+func TestIntrospectionHandler(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	verifier, err := NewVerifier(SecretKey(secretKey), WithRevocationStore(store))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler, err := NewIntrospectionHandler(verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	exp := time.Now().Add(1 * time.Hour).Unix()
+	active := signTestClaims(t, jwt.MapClaims{"sub": "alice", "scope": "read write", "jti": "active", "exp": exp})
+	revoked := signTestClaims(t, jwt.MapClaims{"sub": "alice", "jti": "revoked"})
+	expired := signTestClaims(t, jwt.MapClaims{"sub": "alice", "jti": "expired", "exp": time.Now().Add(-1 * time.Hour).Unix()})
+
+	if err := store.Revoke(context.Background(), "revoked", time.Unix(exp, 0)); err != nil {
+		t.Fatalf("Unexpected error revoking token: %v", err)
+	}
+
+	status, body := introspect(t, handler, active, nil)
+	if status != http.StatusOK || body["active"] != true || body["sub"] != "alice" || body["scope"] != "read write" || body["exp"] != float64(exp) {
+		t.Errorf("Expected an active token, got %d %v", status, body)
+	}
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"expired token", expired},
+		{"revoked token", revoked},
+		{"malformed token", "not-a-token"},
+	}
+
+	for _, c := range cases {
+		status, body := introspect(t, handler, c.token, nil)
+		if status != http.StatusOK || len(body) != 1 || body["active"] != false {
+			t.Errorf("%s: Expected an inactive token, got %d %v", c.name, status, body)
+		}
+	}
+}
+
+func TestIntrospectionHandlerCallerAuthentication(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler, err := NewIntrospectionHandler(verifier, WithCallerAuthenticator(func(r *http.Request) error {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "gateway" || secret != "s3cret" {
+			return errors.New("unknown caller")
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token := signTestClaims(t, jwt.MapClaims{"sub": "alice"})
+
+	status, body := introspect(t, handler, token, nil)
+	if status != http.StatusUnauthorized || body["error"] != "invalid_client" {
+		t.Errorf("Expected an unauthenticated caller to be rejected, got %d %v", status, body)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.SetBasicAuth("gateway", "s3cret")
+
+	status, body = introspect(t, handler, token, request.Header)
+	if status != http.StatusOK || body["active"] != true {
+		t.Errorf("Expected an authenticated caller to introspect, got %d %v", status, body)
+	}
+
+	if _, err := NewIntrospectionHandler(verifier, WithCallerAuthenticator(nil)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestIntrospectionHandlerStoreFailure(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey), WithRevocationStore(failingRevocationStore{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler, err := NewIntrospectionHandler(verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token := signTestClaims(t, jwt.MapClaims{"sub": "alice", "jti": "id"})
+	if status, body := introspect(t, handler, token, nil); status != http.StatusInternalServerError || body["error"] != "server_error" {
+		t.Errorf("Expected a store outage not to report the token inactive, got %d %v", status, body)
+	}
+}
@@ -0,0 +1,174 @@
+package hydrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+)
+
+// JWK is a public key encoded as an RFC 7517 JSON Web Key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is an RFC 7517 JWK Set.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKSet builds a JWK Set from the public keys of the token configurations.
+// Closed configurations and those without a public key, such as shared secret configurations, are skipped.
+// Pass the previous configurations alongside the current one to keep publishing their keys during rotation.
+func NewJWKSet(configs ...*TokenConfig) JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, config := range configs {
+		if config == nil || config.closed {
+			continue
+		}
+
+		if key, ok := config.jwk(); ok {
+			set.Keys = append(set.Keys, key)
+		}
+	}
+
+	return set
+}
+
+// NewJWKSHandler returns an http.Handler serving the JWK Set of the token configurations.
+// The set is rebuilt on every request, so closed configurations stop being published.
+func NewJWKSHandler(configs ...*TokenConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := json.Marshal(NewJWKSet(configs...))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+// JWKSHandler returns an http.Handler serving the public key of the token configuration as a JWK Set.
+// Use NewJWKSHandler to publish several keys during rotation.
+func (t *TokenConfig) JWKSHandler() http.Handler {
+	return NewJWKSHandler(t)
+}
+
+// jwk encodes the public key of the token configuration, reporting false if there is none.
+func (t *TokenConfig) jwk() (JWK, bool) {
+	key := JWK{Kid: t.activeKeyID, Use: "sig"}
+	if t.signingMethod != nil {
+		key.Alg = t.signingMethod.Alg()
+	}
+
+	switch publicKey := t.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = encodeJWKInt(publicKey.N, 0)
+		key.E = encodeJWKInt(big.NewInt(int64(publicKey.E)), 0)
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = publicKey.Curve.Params().Name
+		key.X = encodeJWKInt(publicKey.X, size)
+		key.Y = encodeJWKInt(publicKey.Y, size)
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JWK{}, false
+	}
+
+	return key, true
+}
+
+// encodeJWKInt encodes the integer as unpadded base64url, left-padded with zeros to size bytes.
+func encodeJWKInt(n *big.Int, size int) string {
+	b := n.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// publicKey reconstructs the public key encoded by the JWK.
+// Returns ErrInvalidKey if the key type or curve is unsupported or a field is malformed.
+func (k JWK) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeJWKInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, ErrInvalidKey
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, ok := jwkCurves[k.Crv]
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrInvalidKey
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+// jwkCurves maps JWK curve names to the supported elliptic curves.
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// decodeJWKInt decodes an unpadded base64url encoded integer.
+func decodeJWKInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
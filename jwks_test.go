@@ -0,0 +1,136 @@
+package hydrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func fetchJWKSet(t *testing.T, handler http.Handler) JWKSet {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected application/json content type, got %s", contentType)
+	}
+
+	var set JWKSet
+	if err := json.Unmarshal(recorder.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Unexpected error decoding JWK Set: %v", err)
+	}
+
+	return set
+}
+
+func jwkInt(t *testing.T, value string) *big.Int {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding JWK field: %v", err)
+	}
+
+	return new(big.Int).SetBytes(b)
+}
+
+// publicKeyFromJWK reconstructs the public key from the JWK fields alone.
+func publicKeyFromJWK(t *testing.T, key JWK) interface{} {
+	switch key.Kty {
+	case "RSA":
+		return &rsa.PublicKey{N: jwkInt(t, key.N), E: int(jwkInt(t, key.E).Int64())}
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve := curves[key.Crv]
+		if size := (curve.Params().BitSize + 7) / 8; len(key.X) != base64.RawURLEncoding.EncodedLen(size) {
+			t.Errorf("Expected %s coordinates padded to %d bytes", key.Crv, size)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: jwkInt(t, key.X), Y: jwkInt(t, key.Y)}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil {
+			t.Fatalf("Unexpected error decoding JWK field: %v", err)
+		}
+		return ed25519.PublicKey(x)
+	}
+
+	t.Fatalf("Unexpected key type %s", key.Kty)
+	return nil
+}
+
+func TestJWKSRoundTrip(t *testing.T) {
+	for _, c := range conformanceCases {
+		config := newConformanceToken(t, c)
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Fatalf("Unexpected error generating %s token: %v", c.alg, err)
+		}
+
+		set := fetchJWKSet(t, config.JWKSHandler())
+		if config.publicKey == nil {
+			if len(set.Keys) != 0 {
+				t.Errorf("Expected %s secret not to be published, got %+v", c.alg, set.Keys)
+			}
+			continue
+		}
+
+		if len(set.Keys) != 1 || set.Keys[0].Alg != c.alg {
+			t.Fatalf("Expected a single %s key, got %+v", c.alg, set.Keys)
+		}
+
+		publicKey := publicKeyFromJWK(t, set.Keys[0])
+		if _, err := jwt.Parse(string(token), func(*jwt.Token) (interface{}, error) { return publicKey, nil }); err != nil {
+			t.Errorf("Expected %s token to verify with the published key, got %v", c.alg, err)
+		}
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	previous, err := NewToken(append(rsaKey(jwt.SigningMethodRS256)(t), WithActiveKeyID("v1"))...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	current, err := NewToken(append(ecdsaKey("ec256_private.pem")(t), WithActiveKeyID("v2"))...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	set := fetchJWKSet(t, NewJWKSHandler(current, previous))
+
+	keys := make(map[string]interface{})
+	for _, key := range set.Keys {
+		keys[key.Kid] = publicKeyFromJWK(t, key)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("Expected keys v1 and v2, got %+v", set.Keys)
+	}
+
+	for _, config := range []*TokenConfig{previous, current} {
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Fatalf("Unexpected error generating token: %v", err)
+		}
+
+		_, err = jwt.Parse(string(token), func(token *jwt.Token) (interface{}, error) {
+			return keys[token.Header["kid"].(string)], nil
+		})
+		if err != nil {
+			t.Errorf("Expected token to verify with the key matching its kid, got %v", err)
+		}
+	}
+
+	previous.Close()
+
+	if set := fetchJWKSet(t, NewJWKSHandler(current, previous)); len(set.Keys) != 1 || set.Keys[0].Kid != "v2" {
+		t.Errorf("Expected closed configuration to stop being published, got %+v", set.Keys)
+	}
+}
@@ -0,0 +1,28 @@
+package hydrate
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// derivedKeySize is the size in bytes of keys derived from the secret key.
+const derivedKeySize = 32
+
+// deriveKey derives a purpose-specific key from the secret key using HKDF-SHA256.
+// The info string separates keys derived for different purposes.
+func (t *TokenConfig) deriveKey(info string) ([]byte, error) {
+	if t.secretKey == nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	key := make([]byte, derivedKeySize)
+
+	reader := hkdf.New(sha256.New, t.secretKey.Expose(), nil, []byte(info))
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	return key, nil
+}
@@ -0,0 +1,242 @@
+package hydrate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	defaultKeyPollInterval = 10 * time.Second // Interval between checks of the key files
+	defaultKeyGracePeriod  = 1 * time.Hour    // Time replaced keys remain valid for verification
+	defaultKeyHistory      = 3                // Number of replaced keys kept for verification
+)
+
+// fileKey is a key loaded from the key files.
+type fileKey struct {
+	kid       string      // Fingerprint of the key files
+	signing   interface{} // Key used to sign tokens
+	verifying interface{} // Key used to verify tokens
+	retiredAt time.Time   // Time the key was replaced, zero for the current key
+}
+
+// keyReload holds the settings for reloading key files.
+type keyReload struct {
+	interval time.Duration // Interval between checks of the key files
+	grace    time.Duration // Time replaced keys remain valid for verification
+	history  int           // Number of replaced keys kept for verification
+}
+
+// keyFiles watches key files and swaps the keys used to sign and verify tokens when they change.
+type keyFiles struct {
+	paths     []string                             // Key files, a secret or a private and public key
+	parse     func(data [][]byte) (fileKey, error) // Parses the contents of the key files
+	check     func(key fileKey) error              // Rejects keys the configuration cannot use, nil to accept any
+	keyReload                                      // Reload settings
+
+	now      func() time.Time // Wall clock recording when keys are replaced
+	mu       sync.RWMutex     // Guards the keys
+	current  fileKey          // Key used to sign new tokens
+	previous []fileKey        // Replaced keys, most recent first
+	stop     chan struct{}    // Closed to stop watching the key files
+}
+
+// WithKeyFile sets the secret key from a file, reloading it whenever the file changes.
+// Tokens are signed with the latest key and carry its fingerprint as kid; replaced keys keep verifying during the grace period.
+func WithKeyFile(path string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		files := newKeyFiles([]string{path}, func(data [][]byte) (fileKey, error) {
+			return fileKey{signing: data[0], verifying: data[0]}, nil
+		})
+		// The key size depends on the signing method and WithInsecureAllowWeakKeys, which may be set after this option.
+		files.check = func(key fileKey) error {
+			return t.checkKeySize(key.signing.([]byte))
+		}
+
+		return t.setKeyFiles(files, jwt.SigningMethodHS256)
+	}
+}
+
+// WithKeyPairFiles sets the RSA key pair from PEM encoded files and selects RS256, reloading them whenever they change.
+// If the public key path is empty, the public key is derived from the private key.
+func WithKeyPairFiles(privatePath, publicPath string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		paths := []string{privatePath}
+		if publicPath != "" {
+			paths = append(paths, publicPath)
+		}
+
+		files := newKeyFiles(paths, func(data [][]byte) (fileKey, error) {
+			var publicPEM []byte
+			if len(data) > 1 {
+				publicPEM = data[1]
+			}
+
+			privateKey, publicKey, err := parseRSAKeyPair(data[0], publicPEM)
+			if err != nil {
+				return fileKey{}, err
+			}
+			return fileKey{signing: privateKey, verifying: publicKey}, nil
+		})
+
+		return t.setKeyFiles(files, jwt.SigningMethodRS256)
+	}
+}
+
+// WithKeyReload optionally sets how often key files are checked, how long replaced keys remain valid,
+// and how many replaced keys are kept. If you don't call this function, the files are checked every 10 seconds
+// and the last 3 keys remain valid for an hour.
+func WithKeyReload(interval, grace time.Duration, history int) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if interval <= 0 || grace < 0 || history < 0 {
+			return ErrInvalidTokenConfig
+		}
+
+		t.keyReload = &keyReload{interval: interval, grace: grace, history: history}
+		return nil
+	}
+}
+
+// reloadTicker returns the ticks driving the reloads of key files and a function stopping them.
+type reloadTicker func(interval time.Duration) (<-chan time.Time, func())
+
+// newReloadTicker is the reloadTicker of a time.Ticker, used outside tests.
+func newReloadTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// newKeyFiles instantiates a keyFiles with the default reload settings.
+func newKeyFiles(paths []string, parse func(data [][]byte) (fileKey, error)) *keyFiles {
+	return &keyFiles{
+		paths:     paths,
+		parse:     parse,
+		keyReload: keyReload{interval: defaultKeyPollInterval, grace: defaultKeyGracePeriod, history: defaultKeyHistory},
+	}
+}
+
+// setKeyFiles loads the key files and sets them as the keys of the token.
+func (t *TokenConfig) setKeyFiles(files *keyFiles, method jwt.SigningMethod) error {
+	key, err := files.load()
+	if err != nil {
+		return err
+	}
+
+	files.current = key
+	t.keyFiles = files
+	t.signingMethod = method
+	return nil
+}
+
+// load reads and parses the key files, fingerprinting their contents as the key ID.
+func (f *keyFiles) load() (fileKey, error) {
+	data := make([][]byte, len(f.paths))
+	for i, path := range f.paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return fileKey{}, ErrInvalidKey
+		}
+		data[i] = bytes.TrimSpace(contents)
+	}
+
+	key, err := f.parse(data)
+	if err != nil {
+		return fileKey{}, err
+	}
+
+	fingerprint := sha256.Sum256(bytes.Join(data, []byte{0}))
+	key.kid = hex.EncodeToString(fingerprint[:8])
+
+	return key, nil
+}
+
+// start launches the watch of the key files on the ticks of the ticker, applying the reload settings if any.
+// Replaced keys are aged by the wall clock now.
+func (f *keyFiles) start(reload *keyReload, ticker reloadTicker, now func() time.Time) {
+	if reload != nil {
+		f.keyReload = *reload
+	}
+	f.now = now
+	f.stop = make(chan struct{})
+
+	go func() {
+		ticks, stop := ticker(f.interval)
+		defer stop()
+
+		for {
+			select {
+			case <-ticks:
+				f.reload()
+			case <-f.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops watching the key files.
+func (f *keyFiles) close() {
+	if f.stop != nil {
+		close(f.stop)
+	}
+}
+
+// reload swaps in the key files if their contents changed, retiring the current key.
+// Files that cannot be read, parsed, or checked, such as a partially written file, leave the current key in place.
+func (f *keyFiles) reload() {
+	key, err := f.load()
+	if err != nil {
+		return
+	}
+
+	if f.check != nil && f.check(key) != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key.kid == f.current.kid {
+		return
+	}
+
+	retired := f.current
+	retired.retiredAt = f.now()
+
+	f.previous = append([]fileKey{retired}, f.previous...)
+	if len(f.previous) > f.history {
+		f.previous = f.previous[:f.history]
+	}
+	f.current = key
+}
+
+// signing returns the key ID and the key used to sign new tokens.
+func (f *keyFiles) signing() (string, interface{}) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.current.kid, f.current.signing
+}
+
+// key returns the key with the key ID, if it is current or was replaced within the grace period.
+func (f *keyFiles) key(kid string) (interface{}, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if kid == f.current.kid {
+		return f.current.verifying, nil
+	}
+
+	for _, key := range f.previous {
+		if key.kid == kid && f.now().Sub(key.retiredAt) < f.grace {
+			return key.verifying, nil
+		}
+	}
+
+	return nil, ErrUnknownKeyID
+}
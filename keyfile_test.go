@@ -0,0 +1,181 @@
+package hydrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func writeKeyFile(t *testing.T, path string, data []byte) {
+	// Write then rename so the watcher never observes a partially written file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		t.Fatalf("Unexpected error writing key file: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Unexpected error writing key file: %v", err)
+	}
+}
+
+func newKeyFileToken(t *testing.T, option func(*TokenConfig) error, grace time.Duration, options ...func(*TokenConfig) error) *TokenConfig {
+	config, err := NewToken(append(options,
+		option,
+		WithKeyReload(10*time.Millisecond, grace, 2),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { config.Close() })
+
+	return config
+}
+
+// manualReloads returns an option driving the reloads of the key files by hand, and a function that reloads them.
+func manualReloads() (func(*TokenConfig) error, func()) {
+	ticks := make(chan time.Time)
+	option := func(t *TokenConfig) error {
+		t.reloadTicker = func(time.Duration) (<-chan time.Time, func()) {
+			return ticks, func() {}
+		}
+		return nil
+	}
+
+	return option, func() {
+		// The second tick is only received once the reload of the first one finished.
+		ticks <- time.Now()
+		ticks <- time.Now()
+	}
+}
+
+func generateWithKeyID(t *testing.T, config *TokenConfig) (string, string) {
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	parsed, err := config.ParseToken()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing token: %v", err)
+	}
+
+	kid, _ := parsed.Header["kid"].(string)
+	return string(token), kid
+}
+
+func TestKeyFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	writeKeyFile(t, path, keyV1)
+
+	manual, reload := manualReloads()
+	config := newKeyFileToken(t, WithKeyFile(path), time.Hour, manual)
+	before, beforeKid := generateWithKeyID(t, config)
+
+	writeKeyFile(t, path, keyV2)
+	reload()
+
+	after, afterKid := generateWithKeyID(t, config)
+	if afterKid == beforeKid {
+		t.Errorf("Expected new tokens to be signed with the new key")
+	}
+
+	if _, err := jwt.Parse(after, func(*jwt.Token) (interface{}, error) { return keyV2, nil }); err != nil {
+		t.Errorf("Expected new token to verify with the new key, got: %v", err)
+	}
+
+	if _, err := config.ParseTokenString(before); err != nil {
+		t.Errorf("Expected old token to verify within the grace period, got: %v", err)
+	}
+}
+
+func TestKeyFileGracePeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	writeKeyFile(t, path, keyV1)
+
+	wall := NewFakeClock(time.Now())
+	manual, reload := manualReloads()
+	config := newKeyFileToken(t, WithKeyFile(path), time.Minute, manual, withWallClock(wall))
+	before, _ := generateWithKeyID(t, config)
+
+	writeKeyFile(t, path, keyV2)
+	reload()
+
+	wall.Advance(30 * time.Second)
+	if _, err := config.ParseTokenString(before); err != nil {
+		t.Errorf("Expected old token to verify within the grace period, got: %v", err)
+	}
+
+	wall.Advance(1 * time.Minute)
+	if _, err := config.ParseTokenString(before); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+}
+
+func TestKeyFileKeepsCurrentKeyOnInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	writeKeyFile(t, path, keyV1)
+
+	manual, reload := manualReloads()
+	config := newKeyFileToken(t, WithKeyFile(path), time.Hour, manual)
+	_, beforeKid := generateWithKeyID(t, config)
+
+	writeKeyFile(t, path, []byte("short"))
+	reload()
+
+	if _, kid := generateWithKeyID(t, config); kid != beforeKid {
+		t.Errorf("Expected a weak key file to be ignored")
+	}
+}
+
+func TestKeyPairFilesReload(t *testing.T) {
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	writeKeyFile(t, privatePath, readFixture(t, "rsa_private.pem"))
+
+	manual, reload := manualReloads()
+	config := newKeyFileToken(t, WithKeyPairFiles(privatePath, ""), time.Hour, manual)
+	before, beforeKid := generateWithKeyID(t, config)
+
+	privatePEM, _ := encodeRSAKeys(t, setupRSAKey(t), false)
+	writeKeyFile(t, privatePath, privatePEM)
+	reload()
+
+	after, afterKid := generateWithKeyID(t, config)
+	if afterKid == beforeKid {
+		t.Errorf("Expected new tokens to be signed with the new key")
+	}
+
+	if _, err := jwt.Parse(after, func(*jwt.Token) (interface{}, error) { return &setupRSAKey(t).PublicKey, nil }); err != nil {
+		t.Errorf("Expected new token to verify with the new public key, got: %v", err)
+	}
+
+	if _, err := config.ParseTokenString(before); err != nil {
+		t.Errorf("Expected old token to verify within the grace period, got: %v", err)
+	}
+}
+
+func TestInvalidKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	weakPath := filepath.Join(dir, "weak")
+	writeKeyFile(t, weakPath, []byte("short"))
+
+	options := []func(*TokenConfig) error{
+		WithKeyFile(filepath.Join(dir, "missing")),
+		WithKeyPairFiles(weakPath, ""),
+		WithKeyReload(0, time.Hour, 1),
+	}
+
+	for _, option := range options {
+		if err := option(&TokenConfig{}); err == nil {
+			t.Errorf("Expected an error for an invalid key file option")
+		}
+	}
+	if _, err := NewToken(WithKeyFile(weakPath)); err != ErrWeakSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrWeakSecretKey, err)
+	}
+}
@@ -0,0 +1,339 @@
+package hydrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// WithRSAKeys sets the RSA key pair for the token from PEM encoded keys and selects RS256.
+// The private key may be PKCS1 or PKCS8 encoded. If the public key is nil, it is derived from the private key.
+func WithRSAKeys(privatePEM, publicPEM []byte) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		privateKey, publicKey, err := parseRSAKeyPair(privatePEM, publicPEM)
+		if err != nil {
+			return err
+		}
+
+		t.setKeyPair(privateKey, publicKey, jwt.SigningMethodRS256)
+		return nil
+	}
+}
+
+// parseRSAKeyPair parses a PEM encoded RSA key pair, deriving the public key if it is nil.
+func parseRSAKeyPair(privatePEM, publicPEM []byte) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+	if err != nil {
+		return nil, nil, ErrInvalidKeyPEM
+	}
+
+	publicKey := &privateKey.PublicKey
+	if publicPEM != nil {
+		publicKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return nil, nil, ErrInvalidKeyPEM
+		}
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// WithRSAPublicKey sets the RSA public key used to verify tokens from a PEM encoded key, without any signing capability.
+// RS256 is selected; use WithSigningMethod or WithAllowedMethods for other RSA methods.
+func WithRSAPublicKey(publicPEM []byte) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return ErrInvalidKeyPEM
+		}
+
+		t.setKeyPair(nil, publicKey, jwt.SigningMethodRS256)
+		return nil
+	}
+}
+
+// WithECDSAKey sets the ECDSA private key for the token and selects the signing method matching its curve.
+// P-256, P-384, and P-521 keys select ES256, ES384, and ES512 respectively.
+func WithECDSAKey(privateKey *ecdsa.PrivateKey) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if privateKey == nil {
+			return ErrInvalidKey
+		}
+
+		method, err := ecdsaSigningMethod(privateKey.Curve)
+		if err != nil {
+			return err
+		}
+
+		t.setKeyPair(privateKey, &privateKey.PublicKey, method)
+		return nil
+	}
+}
+
+// WithECDSAPublicKey sets the ECDSA public key used to verify tokens, without any signing capability.
+// The signing method matching the curve of the key is selected.
+func WithECDSAPublicKey(publicKey *ecdsa.PublicKey) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if publicKey == nil {
+			return ErrInvalidKey
+		}
+
+		method, err := ecdsaSigningMethod(publicKey.Curve)
+		if err != nil {
+			return err
+		}
+
+		t.setKeyPair(nil, publicKey, method)
+		return nil
+	}
+}
+
+// WithEd25519Key sets the Ed25519 private key for the token and selects EdDSA.
+func WithEd25519Key(privateKey ed25519.PrivateKey) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(privateKey) != ed25519.PrivateKeySize {
+			return ErrInvalidKey
+		}
+
+		t.setKeyPair(privateKey, privateKey.Public(), jwt.SigningMethodEdDSA)
+		return nil
+	}
+}
+
+// WithEd25519PublicKey sets the Ed25519 public key used to verify tokens, without any signing capability.
+func WithEd25519PublicKey(publicKey ed25519.PublicKey) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(publicKey) != ed25519.PublicKeySize {
+			return ErrInvalidKey
+		}
+
+		t.setKeyPair(nil, publicKey, jwt.SigningMethodEdDSA)
+		return nil
+	}
+}
+
+// ecdsaSigningMethod returns the signing method matching the elliptic curve.
+func ecdsaSigningMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+// WithAllowedMethods optionally sets the signing methods accepted when verifying the token.
+// If you don't call this function, only the configured signing method is accepted.
+func WithAllowedMethods(methods ...jwt.SigningMethod) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(methods) == 0 {
+			return ErrSigningMethodNil
+		}
+
+		for _, method := range methods {
+			if method == nil || method == jwt.SigningMethodNone {
+				return ErrSigningMethodNil
+			}
+		}
+
+		t.allowedMethods = methods
+		return nil
+	}
+}
+
+// keyFunc checks the token's signing method is allowed and returns the key used to verify it.
+// Tokens using "none" or any method outside the allowlist are rejected with ErrUnexpectedSigningMethod.
+func (t *TokenConfig) keyFunc(token *jwt.Token) (interface{}, error) {
+	if !t.isAllowedMethod(token.Method) {
+		return nil, ErrUnexpectedSigningMethod
+	}
+
+	if t.faults != nil {
+		if err := t.faults.keyRotationRace(token.Claims.(jwt.MapClaims)); err != nil {
+			return nil, err
+		}
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	return t.verificationKey(kid)
+}
+
+// isAllowedMethod reports whether tokens signed with the method may be verified.
+func (t *TokenConfig) isAllowedMethod(method jwt.SigningMethod) bool {
+	if method == nil || method.Alg() == jwt.SigningMethodNone.Alg() {
+		return false
+	}
+
+	if len(t.allowedMethods) == 0 {
+		return method.Alg() == t.signingMethod.Alg()
+	}
+
+	for _, allowed := range t.allowedMethods {
+		if method.Alg() == allowed.Alg() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithKeySet sets the secret keys used to verify tokens, indexed by key ID.
+// Tokens are verified with the key matching their kid header; unknown key IDs fail with ErrUnknownKeyID.
+func WithKeySet(keys map[string][]byte) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(keys) == 0 {
+			return ErrInvalidSecretKey
+		}
+
+		keySet := make(map[string]secretStore, len(keys))
+		for kid, key := range keys {
+			if kid == "" {
+				return fmt.Errorf("%w: key IDs cannot be empty", ErrInvalidTokenConfig)
+			}
+
+			secret, err := newSecureSecret(key)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrSecureMemoryFailed, err)
+			}
+			keySet[kid] = secret
+		}
+
+		t.keySet = keySet
+		return nil
+	}
+}
+
+// WithActiveKeyID sets the key ID used to sign new tokens, either from the key set or naming the asymmetric key pair.
+// The key ID is written to the kid header of every generated token.
+func WithActiveKeyID(kid string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if kid == "" {
+			return fmt.Errorf("%w: active key ID cannot be empty", ErrInvalidTokenConfig)
+		}
+
+		t.activeKeyID = kid
+		return nil
+	}
+}
+
+// setKeyPair sets the asymmetric key pair and the signing method for the token.
+// It is a utility function shared by the asymmetric key options.
+func (t *TokenConfig) setKeyPair(privateKey, publicKey interface{}, method jwt.SigningMethod) {
+	t.privateKey = privateKey
+	t.publicKey = publicKey
+	t.signingMethod = method
+}
+
+// signClaims signs the payload with the configured signing method and key. The claims are the payload as a map,
+// used to check the claim limits; they are usually the payload itself.
+// The active key ID, if any, is written to the kid header, and the claim limits are checked before signing.
+// When key files or a secret provider are configured, their current key is fetched at signing time.
+// Returns the signed token, or an error if one occurs.
+func (t *TokenConfig) signClaims(payload jwt.Claims, claims jwt.MapClaims) (string, error) {
+	if t.faults != nil {
+		if err := t.faults.signerLatency(claims); err != nil {
+			return "", err
+		}
+	}
+
+	kid, key, err := t.currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(t.signingMethod, payload)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	if err := t.checkClaimLimits(token, claims); err != nil {
+		return "", err
+	}
+
+	signedToken, err := token.SignedString(key)
+	if err != nil {
+		return "", ErrSigningToken
+	}
+
+	return signedToken, nil
+}
+
+// currentSigningKey returns the key ID and key to sign with now.
+// When key files or a secret provider are configured, their current key is used instead of the static one.
+func (t *TokenConfig) currentSigningKey() (string, interface{}, error) {
+	if t.privateKey == nil && t.keyFiles != nil {
+		kid, key := t.keyFiles.signing()
+		return kid, key, nil
+	}
+
+	if t.privateKey == nil && t.secretProvider != nil {
+		return t.currentProviderKey()
+	}
+
+	return t.activeKeyID, t.signingKey(), nil
+}
+
+// signingKey returns the key used to sign tokens with the configured signing method.
+func (t *TokenConfig) signingKey() interface{} {
+	if t.privateKey != nil {
+		return t.privateKey
+	}
+
+	if key, ok := t.keySet[t.activeKeyID]; ok && t.activeKeyID != "" {
+		return key.Expose()
+	}
+
+	if t.secretKey != nil {
+		return t.secretKey.Expose()
+	}
+
+	return nil
+}
+
+// canSign reports whether the configuration holds signing material and is allowed to use it.
+func (t *TokenConfig) canSign() bool {
+	return !t.verifyOnly && (t.signingKey() != nil || t.secretProvider != nil || t.keyFiles != nil)
+}
+
+// verificationKey returns the key used to verify tokens with the configured signing method.
+// When a key set, a JWKS URL, key files, or a secret provider is configured, the key is looked up by the token's key ID.
+func (t *TokenConfig) verificationKey(kid string) (interface{}, error) {
+	if t.publicKey != nil {
+		return t.publicKey, nil
+	}
+
+	if t.remoteKeys != nil {
+		return t.remoteKeys.key(kid)
+	}
+
+	if t.keyFiles != nil {
+		return t.keyFiles.key(kid)
+	}
+
+	if t.secretProvider != nil {
+		return t.providerKey(kid)
+	}
+
+	if t.keySet != nil {
+		key, ok := t.keySet[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		return key.Expose(), nil
+	}
+
+	if t.secretKey != nil {
+		return t.secretKey.Expose(), nil
+	}
+
+	return nil, ErrInvalidSecretKey
+}
@@ -0,0 +1,411 @@
+package hydrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var rsaTestKey *rsa.PrivateKey
+
+func setupRSAKey(t *testing.T) *rsa.PrivateKey {
+	if rsaTestKey == nil {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Unexpected error generating RSA key: %v", err)
+		}
+		rsaTestKey = key
+	}
+
+	return rsaTestKey
+}
+
+func encodeRSAKeys(t *testing.T, key *rsa.PrivateKey, pkcs8 bool) ([]byte, []byte) {
+	privateDER := x509.MarshalPKCS1PrivateKey(key)
+	privateType := "RSA PRIVATE KEY"
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Unexpected error encoding private key: %v", err)
+		}
+		privateDER, privateType = der, "PRIVATE KEY"
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding public key: %v", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: privateType, Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	return privatePEM, publicPEM
+}
+
+func TestValidRSAKeys(t *testing.T) {
+	key := setupRSAKey(t)
+
+	for _, pkcs8 := range []bool{false, true} {
+		privatePEM, publicPEM := encodeRSAKeys(t, key, pkcs8)
+
+		config, err := NewToken(
+			WithRSAKeys(privatePEM, publicPEM),
+			WithStandardClaims(jwt.StandardClaims{
+				ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+				Issuer:    "test",
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Errorf("Unexpected error generating token: %v", err)
+		}
+
+		parsed, err := jwt.Parse(string(token), func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil || parsed.Method != jwt.SigningMethodRS256 {
+			t.Errorf("Expected RS256 token verifiable with the public key, got %v", err)
+		}
+
+		if !config.IsValid() {
+			t.Errorf("Expected token to be valid")
+		}
+
+		claims, err := config.ExtractClaims()
+		if err != nil {
+			t.Errorf("Unexpected error extracting claims: %v", err)
+		}
+
+		if claims["iss"] != "test" {
+			t.Errorf("Expected iss to be test, got %v", claims["iss"])
+		}
+	}
+}
+
+func TestRSAKeysWithoutPublicKey(t *testing.T) {
+	privatePEM, _ := encodeRSAKeys(t, setupRSAKey(t), false)
+
+	config, err := NewToken(
+		WithRSAKeys(privatePEM, nil),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	if !config.IsValid() {
+		t.Errorf("Expected token to be valid")
+	}
+}
+
+func TestInvalidRSAKeysPEM(t *testing.T) {
+	privatePEM, _ := encodeRSAKeys(t, setupRSAKey(t), false)
+
+	options := map[string]func(*TokenConfig) error{
+		"private": WithRSAKeys([]byte("not a key"), nil),
+		"public":  WithRSAKeys(privatePEM, []byte("not a key")),
+	}
+
+	for name, option := range options {
+		if err := option(&TokenConfig{}); err != ErrInvalidKeyPEM {
+			t.Errorf("Expected error for invalid %s key: %v, got: %v", name, ErrInvalidKeyPEM, err)
+		}
+	}
+}
+
+func TestRSATokenWrongPublicKey(t *testing.T) {
+	privatePEM, _ := encodeRSAKeys(t, setupRSAKey(t), false)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unexpected error generating RSA key: %v", err)
+	}
+	_, otherPublicPEM := encodeRSAKeys(t, other, false)
+
+	config, err := NewToken(
+		WithRSAKeys(privatePEM, otherPublicPEM),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	if _, err := config.ExtractClaims(); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func setupECDSAToken(t *testing.T, curve elliptic.Curve) (*ecdsa.PrivateKey, *TokenConfig) {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error generating ECDSA key: %v", err)
+	}
+
+	config, err := NewToken(
+		WithECDSAKey(key),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "test",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return key, config
+}
+
+func TestValidECDSAKeys(t *testing.T) {
+	curves := map[string]elliptic.Curve{
+		"ES256": elliptic.P256(),
+		"ES384": elliptic.P384(),
+		"ES512": elliptic.P521(),
+	}
+
+	for alg, curve := range curves {
+		key, config := setupECDSAToken(t, curve)
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Errorf("Unexpected error generating %s token: %v", alg, err)
+		}
+
+		parsed, err := jwt.Parse(string(token), func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil || parsed.Method.Alg() != alg {
+			t.Errorf("Expected %s token verifiable with the public key, got %v", alg, err)
+		}
+
+		claims, err := config.ExtractClaims()
+		if err != nil {
+			t.Errorf("Unexpected error extracting %s claims: %v", alg, err)
+		}
+
+		if claims["iss"] != "test" {
+			t.Errorf("Expected iss to be test, got %v", claims["iss"])
+		}
+
+		verifier, err := NewToken(WithECDSAPublicKey(&key.PublicKey))
+		if err != nil {
+			t.Fatalf("Unexpected error creating %s verifier: %v", alg, err)
+		}
+
+		verifier.token = config.token
+		if !verifier.IsValid() {
+			t.Errorf("Expected %s token to be valid with the public key only", alg)
+		}
+	}
+}
+
+func TestECDSACurveMismatch(t *testing.T) {
+	_, es384Config := setupECDSAToken(t, elliptic.P384())
+	es256Key, _ := setupECDSAToken(t, elliptic.P256())
+
+	if _, err := es384Config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	verifier, err := NewToken(WithECDSAPublicKey(&es256Key.PublicKey))
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	verifier.token = es384Config.token
+	if _, err := verifier.ExtractClaims(); err != ErrUnexpectedSigningMethod {
+		t.Errorf("Expected error: %v, got: %v", ErrUnexpectedSigningMethod, err)
+	}
+}
+
+func TestVerifyOnlyECDSACannotSign(t *testing.T) {
+	key, _ := setupECDSAToken(t, elliptic.P256())
+
+	verifier, err := NewToken(WithECDSAPublicKey(&key.PublicKey))
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	if _, err := verifier.GenerateToken(); err != ErrSigningNotConfigured {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningNotConfigured, err)
+	}
+}
+
+func TestInvalidECDSAKey(t *testing.T) {
+	_, err := NewToken(WithECDSAKey(nil))
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func setupEd25519Token(t *testing.T) (ed25519.PublicKey, *TokenConfig) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error generating Ed25519 key: %v", err)
+	}
+
+	config, err := NewToken(
+		WithEd25519Key(privateKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "test",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return publicKey, config
+}
+
+func TestValidEd25519Key(t *testing.T) {
+	publicKey, config := setupEd25519Token(t)
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	parsed, err := jwt.Parse(string(token), func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	})
+	if err != nil || parsed.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("Expected EdDSA token verifiable with the public key, got %v", err)
+	}
+
+	if !config.IsValid() {
+		t.Errorf("Expected token to be valid")
+	}
+}
+
+func TestEd25519DifferentKey(t *testing.T) {
+	_, config := setupEd25519Token(t)
+	_, other := setupEd25519Token(t)
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	other.token = config.token
+	if _, err := other.ExtractClaims(); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestInvalidEd25519Key(t *testing.T) {
+	_, err := NewToken(WithEd25519Key(ed25519.PrivateKey("short")))
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func craftToken(t *testing.T, method jwt.SigningMethod, key interface{}) *string {
+	token, err := jwt.NewWithClaims(method, jwt.MapClaims{
+		"exp": time.Now().Add(1 * time.Hour).Unix(),
+		"iss": "attacker",
+	}).SignedString(key)
+	if err != nil {
+		t.Fatalf("Unexpected error crafting %s token: %v", method.Alg(), err)
+	}
+
+	return &token
+}
+
+func TestRejectUnexpectedSigningMethods(t *testing.T) {
+	tokens := map[string]*string{
+		"none":  craftToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType),
+		"HS512": craftToken(t, jwt.SigningMethodHS512, secretKey),
+	}
+
+	for name, token := range tokens {
+		_, config, err := setupToken(t)
+		if err != nil {
+			return
+		}
+
+		config.token = token
+
+		if config.IsValid() {
+			t.Errorf("Expected %s token to be invalid", name)
+		}
+
+		if _, err := config.ExtractClaims(); err != ErrUnexpectedSigningMethod {
+			t.Errorf("Expected error for %s token: %v, got: %v", name, ErrUnexpectedSigningMethod, err)
+		}
+
+		accessConfig, _, err := setupTokens(t)
+		if err != nil {
+			return
+		}
+
+		if _, err := accessConfig.GenerateToken(); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if _, err := accessConfig.RefreshToken(config); err != ErrTokenInvalid {
+			t.Errorf("Expected error refreshing with %s token: %v, got: %v", name, ErrTokenInvalid, err)
+		}
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithAllowedMethods(jwt.SigningMethodHS256, jwt.SigningMethodHS512),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	config.token = craftToken(t, jwt.SigningMethodHS512, secretKey)
+	if _, err := config.ExtractClaims(); err != nil {
+		t.Errorf("Unexpected error extracting allowed HS512 token: %v", err)
+	}
+
+	config.token = craftToken(t, jwt.SigningMethodHS384, secretKey)
+	if _, err := config.ExtractClaims(); err != ErrUnexpectedSigningMethod {
+		t.Errorf("Expected error: %v, got: %v", ErrUnexpectedSigningMethod, err)
+	}
+}
+
+func TestInvalidAllowedMethods(t *testing.T) {
+	for _, methods := range [][]jwt.SigningMethod{nil, {jwt.SigningMethodNone}} {
+		_, err := NewToken(
+			SecretKey(secretKey),
+			WithAllowedMethods(methods...),
+		)
+
+		if !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+}
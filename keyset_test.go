@@ -0,0 +1,118 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	keyV1 = []byte("rotation-secret-version-one-0123456789")
+	keyV2 = []byte("rotation-secret-version-two-0123456789")
+)
+
+func newKeySetToken(t *testing.T, keys map[string][]byte, active string) *TokenConfig {
+	options := []func(*TokenConfig) error{
+		WithKeySet(keys),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	}
+	if active != "" {
+		options = append(options, WithActiveKeyID(active))
+	}
+
+	config, err := NewToken(options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestKeySetWritesKeyID(t *testing.T) {
+	config := newKeySetToken(t, map[string][]byte{"v1": keyV1}, "v1")
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	token, err := config.ParseToken()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing token: %v", err)
+	}
+
+	if token.Header["kid"] != "v1" {
+		t.Errorf("Expected kid to be v1, got %v", token.Header["kid"])
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	before := newKeySetToken(t, map[string][]byte{"v1": keyV1}, "v1")
+	if _, err := before.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	after := newKeySetToken(t, map[string][]byte{"v1": keyV1, "v2": keyV2}, "v2")
+	if _, err := after.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	if _, err := after.ParseTokenString(*before.token); err != nil {
+		t.Errorf("Expected v1 token to validate after rotation, got: %v", err)
+	}
+
+	if _, err := after.ParseTokenString(*after.token); err != nil {
+		t.Errorf("Expected v2 token to validate, got: %v", err)
+	}
+
+	retired := newKeySetToken(t, map[string][]byte{"v2": keyV2}, "v2")
+	if _, err := retired.ParseTokenString(*before.token); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+}
+
+func TestKeySetWrongKeyForKeyID(t *testing.T) {
+	config := newKeySetToken(t, map[string][]byte{"v1": keyV1}, "v1")
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	other := newKeySetToken(t, map[string][]byte{"v1": keyV2}, "v1")
+	if _, err := other.ParseTokenString(*config.token); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestKeySetMissingKeyID(t *testing.T) {
+	token, _, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	config := newKeySetToken(t, map[string][]byte{"v1": secretKey}, "v1")
+	if _, err := config.ParseTokenString(string(token)); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+}
+
+func TestUnknownActiveKeyID(t *testing.T) {
+	_, err := NewToken(
+		WithKeySet(map[string][]byte{"v1": keyV1}),
+		WithActiveKeyID("v2"),
+	)
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestKeySetWithoutActiveKeyCannotSign(t *testing.T) {
+	config := newKeySetToken(t, map[string][]byte{"v1": keyV1}, "")
+
+	if _, err := config.GenerateToken(); err != ErrSigningNotConfigured {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningNotConfigured, err)
+	}
+}
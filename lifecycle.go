@@ -0,0 +1,105 @@
+package hydrate
+
+import "github.com/golang-jwt/jwt"
+
+// TokenState describes where a TokenConfig is in its lifecycle.
+type TokenState int
+
+const (
+	// StateConfigured is the state of a configuration that has not generated a token yet.
+	// Only GenerateToken, IsValid, and Close are meaningful; methods needing a token return ErrTokenNotGenerated.
+	StateConfigured TokenState = iota
+	// StateIssued is the state of a configuration holding an unexpired token.
+	// Every method is legal, and GenerateToken regenerates the token with a fresh expiration.
+	StateIssued
+	// StateExpired is the state of a configuration whose token has passed its expiration.
+	// ParseToken and ExtractClaims return ErrTokenInvalid, IsValid reports false, and GenerateToken re-issues the token.
+	StateExpired
+	// StateClosed is the terminal state of a configuration after Close.
+	// Every method returns ErrConfigClosed, and IsValid reports false.
+	StateClosed
+)
+
+// String returns the name of the state.
+func (s TokenState) String() string {
+	switch s {
+	case StateConfigured:
+		return "configured"
+	case StateIssued:
+		return "issued"
+	case StateExpired:
+		return "expired"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// State returns the current lifecycle state of the configuration.
+func (t *TokenConfig) State() TokenState {
+	switch {
+	case t.closed:
+		return StateClosed
+	case t.token == nil:
+		return StateConfigured
+	case t.tokenExpiry != 0 && t.tokenExpiry < t.now().Unix():
+		return StateExpired
+	default:
+		return StateIssued
+	}
+}
+
+// Close releases the secret key and the generated token and stops any JWKS refresh or key file watch, moving the configuration to StateClosed.
+// A secret kept in plain memory by WithInsecureSecretFallback is zeroed.
+// Returns ErrConfigClosed if the configuration is already closed.
+func (t *TokenConfig) Close() error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	t.closed = true
+	if secret, ok := t.secretKey.(*plainSecret); ok {
+		secret.zero()
+	}
+	t.secretKey = nil
+	t.privateKey = nil
+	t.keySet = nil
+	t.secretProvider = nil
+	if t.keyFiles != nil {
+		t.keyFiles.close()
+		t.keyFiles = nil
+	}
+	if t.remoteKeys != nil {
+		t.remoteKeys.close()
+		t.remoteKeys = nil
+	}
+	t.token = nil
+	t.tokenExpiry = 0
+
+	return nil
+}
+
+// tokenState is the token held by a configuration, saved so a failed rotation can put it back.
+type tokenState struct {
+	token  *string
+	expiry int64
+}
+
+// saveToken returns the token currently held by the configuration.
+func (t *TokenConfig) saveToken() tokenState {
+	return tokenState{token: t.token, expiry: t.tokenExpiry}
+}
+
+// restoreToken puts back a token saved by saveToken.
+func (t *TokenConfig) restoreToken(state tokenState) {
+	t.token = state.token
+	t.tokenExpiry = state.expiry
+}
+
+// setToken stores the signed token and its expiration time.
+// It is a utility function used to track the lifecycle state after signing.
+func (t *TokenConfig) setToken(signedToken string, claims jwt.MapClaims) {
+	t.token = &signedToken
+	t.tokenExpiry, _ = numericClaim(claims["exp"])
+}
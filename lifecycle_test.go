@@ -0,0 +1,191 @@
+package hydrate
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func newLifecycleToken(t *testing.T, expiresIn time.Duration) *TokenConfig {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(expiresIn).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestStateTransitions(t *testing.T) {
+	config := newLifecycleToken(t, time.Hour)
+
+	if state := config.State(); state != StateConfigured {
+		t.Errorf("Expected state %v, got %v", StateConfigured, state)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if state := config.State(); state != StateIssued {
+		t.Errorf("Expected state %v, got %v", StateIssued, state)
+	}
+
+	if err := config.Close(); err != nil {
+		t.Errorf("Unexpected error closing config: %v", err)
+	}
+
+	if state := config.State(); state != StateClosed {
+		t.Errorf("Expected state %v, got %v", StateClosed, state)
+	}
+
+	if err := config.Close(); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+}
+
+func TestExpiredState(t *testing.T) {
+	config := newLifecycleToken(t, -time.Hour)
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if state := config.State(); state != StateExpired {
+		t.Errorf("Expected state %v, got %v", StateExpired, state)
+	}
+}
+
+func TestClosedConfigMethods(t *testing.T) {
+	config := newLifecycleToken(t, time.Hour)
+	if err := config.Close(); err != nil {
+		t.Errorf("Unexpected error closing config: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+
+	if _, err := config.ParseToken(); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+
+	if _, err := config.ExtractClaims(); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+
+	if _, err := config.RefreshToken(newLifecycleToken(t, time.Hour)); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+
+	if config.IsValid() {
+		t.Errorf("Expected closed config to be invalid")
+	}
+}
+
+// lifecycleOperation is a method call driven by the random sequence test.
+type lifecycleOperation struct {
+	name string
+	call func(config, refreshConfig *TokenConfig) error
+}
+
+var lifecycleOperations = []lifecycleOperation{
+	{"GenerateToken", func(config, _ *TokenConfig) error {
+		_, err := config.GenerateToken()
+		return err
+	}},
+	{"ParseToken", func(config, _ *TokenConfig) error {
+		_, err := config.ParseToken()
+		return err
+	}},
+	{"ExtractClaims", func(config, _ *TokenConfig) error {
+		_, err := config.ExtractClaims()
+		return err
+	}},
+	{"RefreshToken", func(config, refreshConfig *TokenConfig) error {
+		_, err := config.RefreshToken(refreshConfig)
+		return err
+	}},
+	{"IsValid", func(config, _ *TokenConfig) error {
+		config.IsValid()
+		return nil
+	}},
+	{"Close", func(config, _ *TokenConfig) error {
+		return config.Close()
+	}},
+}
+
+// checkStateError asserts the error returned by an operation is appropriate for the state it was called in.
+func checkStateError(state TokenState, operation string, err error) error {
+	switch {
+	case operation == "IsValid":
+		return nil
+	case state == StateClosed:
+		if err != ErrConfigClosed {
+			return fmt.Errorf("expected %v, got %v", ErrConfigClosed, err)
+		}
+	case operation == "Close" || operation == "GenerateToken" && state != StateExpired:
+		if err != nil {
+			return fmt.Errorf("unexpected error %v", err)
+		}
+	case state == StateConfigured:
+		if err != ErrTokenNotGenerated {
+			return fmt.Errorf("expected %v, got %v", ErrTokenNotGenerated, err)
+		}
+	case state == StateExpired && operation != "GenerateToken" && operation != "RefreshToken":
+		if err != ErrTokenInvalid {
+			return fmt.Errorf("expected %v, got %v", ErrTokenInvalid, err)
+		}
+	case state == StateIssued:
+		if err != nil {
+			return fmt.Errorf("unexpected error %v", err)
+		}
+	}
+
+	return nil
+}
+
+func TestRandomLifecycleSequences(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	refreshConfig := newLifecycleToken(t, 24*time.Hour)
+	if _, err := refreshConfig.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for run := 0; run < 50; run++ {
+		expiresIn := time.Hour
+		if random.Intn(2) == 0 {
+			expiresIn = -time.Hour
+		}
+		config := newLifecycleToken(t, expiresIn)
+
+		for step := 0; step < 20; step++ {
+			operation := lifecycleOperations[random.Intn(len(lifecycleOperations))]
+			state := config.State()
+
+			var panicked interface{}
+			err := func() error {
+				defer func() {
+					panicked = recover()
+				}()
+				return operation.call(config, refreshConfig)
+			}()
+
+			if panicked != nil {
+				t.Fatalf("Run %d step %d: %s in state %v panicked: %v", run, step, operation.name, state, panicked)
+			}
+
+			if checkErr := checkStateError(state, operation.name, err); checkErr != nil {
+				t.Fatalf("Run %d step %d: %s in state %v: %v", run, step, operation.name, state, checkErr)
+			}
+		}
+	}
+}
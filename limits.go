@@ -0,0 +1,131 @@
+package hydrate
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ClaimLimitError reports a claim or token exceeding a configured issuance limit.
+// It wraps ErrClaimLimitExceeded so callers can match it with errors.Is.
+type ClaimLimitError struct {
+	Limit string // Name of the exceeded limit
+	Claim string // Offending claim, if the limit applies to a single claim
+	Size  int    // Size or count that exceeded the limit
+	Max   int    // Configured maximum
+}
+
+// Error returns a description naming the exceeded limit and the offending claim.
+func (e *ClaimLimitError) Error() string {
+	if e.Claim != "" {
+		return fmt.Sprintf("%v: %s of claim %q is %d, maximum is %d", ErrClaimLimitExceeded, e.Limit, e.Claim, e.Size, e.Max)
+	}
+	return fmt.Sprintf("%v: %s is %d, maximum is %d", ErrClaimLimitExceeded, e.Limit, e.Size, e.Max)
+}
+
+// Unwrap returns ErrClaimLimitExceeded.
+func (e *ClaimLimitError) Unwrap() error {
+	return ErrClaimLimitExceeded
+}
+
+// WithMaxClaimValueSize optionally limits the JSON encoded size in bytes of every claim value.
+func WithMaxClaimValueSize(bytes int) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if bytes <= 0 {
+			return ErrInvalidClaimLimit
+		}
+
+		t.maxClaimValueSize = bytes
+		return nil
+	}
+}
+
+// WithMaxClaimCount optionally limits the number of claims in the token.
+func WithMaxClaimCount(n int) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if n <= 0 {
+			return ErrInvalidClaimLimit
+		}
+
+		t.maxClaimCount = n
+		return nil
+	}
+}
+
+// WithMaxEncodedTokenSize optionally limits the size in bytes of the encoded token.
+// The size is estimated before signing so oversized tokens never reach the signer.
+func WithMaxEncodedTokenSize(bytes int) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if bytes <= 0 {
+			return ErrInvalidClaimLimit
+		}
+
+		t.maxEncodedTokenSize = bytes
+		return nil
+	}
+}
+
+// checkClaimLimits checks the claims and the encoded token against the configured limits.
+// Returns a *ClaimLimitError for the first exceeded limit, or nil.
+func (t *TokenConfig) checkClaimLimits(token *jwt.Token, claims jwt.MapClaims) error {
+	if t.maxClaimCount > 0 && len(claims) > t.maxClaimCount {
+		return &ClaimLimitError{Limit: "claim count", Size: len(claims), Max: t.maxClaimCount}
+	}
+
+	if t.maxClaimValueSize > 0 {
+		for key, value := range claims {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return ErrClaimsInvalid
+			}
+
+			if len(encoded) > t.maxClaimValueSize {
+				return &ClaimLimitError{Limit: "value size", Claim: key, Size: len(encoded), Max: t.maxClaimValueSize}
+			}
+		}
+	}
+
+	if t.maxEncodedTokenSize > 0 {
+		signingString, err := token.SigningString()
+		if err != nil {
+			return ErrClaimsInvalid
+		}
+
+		size := len(signingString) + 1 + encodedLen(t.signatureSize())
+		if size > t.maxEncodedTokenSize {
+			return &ClaimLimitError{Limit: "encoded token size", Size: size, Max: t.maxEncodedTokenSize}
+		}
+	}
+
+	return nil
+}
+
+// signatureSize returns the size in bytes of signatures produced by the configured signing method and key.
+func (t *TokenConfig) signatureSize() int {
+	base := t.signingMethod
+	if signer, ok := base.(*signerMethod); ok {
+		base = signer.base
+	}
+
+	switch method := base.(type) {
+	case *jwt.SigningMethodHMAC:
+		return method.Hash.Size()
+	case *jwt.SigningMethodECDSA:
+		return 2 * ((method.CurveBits + 7) / 8)
+	case *jwt.SigningMethodEd25519:
+		return 64
+	}
+
+	if publicKey, ok := t.publicKey.(*rsa.PublicKey); ok {
+		return publicKey.Size()
+	}
+
+	return 0
+}
+
+// encodedLen returns the length of n bytes encoded as unpadded base64url.
+func encodedLen(n int) int {
+	return (n*8 + 5) / 6
+}
@@ -0,0 +1,119 @@
+package hydrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func newLimitedToken(t *testing.T, custom map[string]interface{}, options ...func(*TokenConfig) error) *TokenConfig {
+	options = append(options,
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "test",
+		}),
+		WithCustomClaims(custom),
+	)
+
+	config, err := NewToken(options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestMaxClaimValueSize(t *testing.T) {
+	config := newLimitedToken(t, map[string]interface{}{
+		"permissions": strings.Repeat("x", 100),
+	}, WithMaxClaimValueSize(64))
+
+	_, err := config.GenerateToken()
+
+	var limitErr *ClaimLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected ClaimLimitError, got: %v", err)
+	}
+
+	if limitErr.Claim != "permissions" || limitErr.Size != 102 || limitErr.Max != 64 {
+		t.Errorf("Expected permissions claim of size 102 over 64, got %+v", limitErr)
+	}
+
+	if !errors.Is(err, ErrClaimLimitExceeded) {
+		t.Errorf("Expected error to match %v", ErrClaimLimitExceeded)
+	}
+
+	if config.token != nil {
+		t.Errorf("Expected no token to be stored")
+	}
+}
+
+func TestMaxClaimCount(t *testing.T) {
+	config := newLimitedToken(t, map[string]interface{}{
+		"a": 1,
+		"b": 2,
+	}, WithMaxClaimCount(3))
+
+	_, err := config.GenerateToken()
+
+	var limitErr *ClaimLimitError
+	if !errors.As(err, &limitErr) || limitErr.Size != 4 {
+		t.Errorf("Expected claim count error of 4 claims, got: %v", err)
+	}
+}
+
+func TestMaxEncodedTokenSize(t *testing.T) {
+	custom := map[string]interface{}{"role": "admin"}
+
+	token, err := newLimitedToken(t, custom).GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := newLimitedToken(t, custom, WithMaxEncodedTokenSize(len(token))).GenerateToken(); err != nil {
+		t.Errorf("Unexpected error at the exact size limit: %v", err)
+	}
+
+	_, err = newLimitedToken(t, custom, WithMaxEncodedTokenSize(len(token)-1)).GenerateToken()
+
+	var limitErr *ClaimLimitError
+	if !errors.As(err, &limitErr) || limitErr.Size != len(token) {
+		t.Errorf("Expected encoded size error of %d bytes, got: %v", len(token), err)
+	}
+}
+
+func TestMaxEncodedTokenSizeAsymmetric(t *testing.T) {
+	for _, c := range conformanceCases {
+		config := newConformanceToken(t, c)
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Fatalf("Unexpected error generating %s token: %v", c.alg, err)
+		}
+
+		limited := newConformanceToken(t, c)
+		limited.maxEncodedTokenSize = len(token)
+
+		if _, err := limited.GenerateToken(); err != nil {
+			t.Errorf("Expected %s size estimate to match the token length, got: %v", c.alg, err)
+		}
+	}
+}
+
+func TestInvalidClaimLimits(t *testing.T) {
+	options := []func(*TokenConfig) error{
+		WithMaxClaimValueSize(0),
+		WithMaxClaimCount(-1),
+		WithMaxEncodedTokenSize(0),
+	}
+
+	for _, option := range options {
+		if err := option(&TokenConfig{}); err != ErrInvalidClaimLimit {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidClaimLimit, err)
+		}
+	}
+}
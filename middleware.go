@@ -0,0 +1,129 @@
+package hydrate
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorWriter writes the response of a request rejected by the middleware, after any WWW-Authenticate header is set.
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+// AuthMiddleware is net/http middleware that verifies the bearer token of every request.
+// Requests with a valid token reach the next handler with the claims in their context, read by ClaimsFromContext.
+type AuthMiddleware struct {
+	verifier    *TokenConfig   // Configuration verifying the tokens
+	extractor   Extractor      // Source of the token of requests
+	session     *sessionCookie // Encrypted cookie holding the claims of requests instead of a token, if any
+	errorWriter ErrorWriter    // Writer of the rejected responses
+}
+
+// NewAuthMiddleware instantiates an AuthMiddleware verifying tokens with the configuration, typically a verifier.
+func NewAuthMiddleware(verifier *TokenConfig, options ...func(*AuthMiddleware) error) (*AuthMiddleware, error) {
+	if verifier == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	middleware := &AuthMiddleware{
+		verifier:    verifier,
+		extractor:   FromAuthHeader("Bearer"),
+		errorWriter: writeAuthError,
+	}
+	for _, option := range options {
+		if err := option(middleware); err != nil {
+			return nil, err
+		}
+	}
+
+	return middleware, nil
+}
+
+// WithErrorWriter optionally replaces the plain text body written for rejected requests.
+// The writer must write the status; the WWW-Authenticate header, if any, is already set.
+func WithErrorWriter(writer ErrorWriter) func(*AuthMiddleware) error {
+	return func(m *AuthMiddleware) error {
+		if writer == nil {
+			return ErrInvalidTokenConfig
+		}
+
+		m.errorWriter = writer
+		return nil
+	}
+}
+
+// WithExtractors optionally reads the token of requests with the extractors, tried in order, instead of the
+// "Authorization: Bearer" header.
+func WithExtractors(extractors ...Extractor) func(*AuthMiddleware) error {
+	return func(m *AuthMiddleware) error {
+		if len(extractors) == 0 {
+			return ErrInvalidTokenConfig
+		}
+
+		for _, extractor := range extractors {
+			if extractor == nil {
+				return ErrInvalidTokenConfig
+			}
+		}
+
+		m.extractor = ChainExtractors(extractors...)
+		return nil
+	}
+}
+
+// Middleware wraps the handler with an AuthMiddleware using the configuration and the default options.
+func (t *TokenConfig) Middleware(next http.Handler) http.Handler {
+	middleware, _ := NewAuthMiddleware(t)
+	return middleware.Handler(next)
+}
+
+// Handler wraps the handler, rejecting requests without a valid token with the status DescribeError gives.
+// Requests without a token get a bare Bearer challenge, and invalid tokens add error="invalid_token".
+// Requests whose token cannot be extracted are rejected with 400 Bad Request and error="invalid_request".
+// Failures of the backends, e.g. an unreachable RevocationStore, get a 5xx status and no challenge.
+func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.session != nil {
+			m.serveSession(w, r, next)
+			return
+		}
+
+		tokenString, err := m.extractor.Extract(r)
+		if err != nil {
+			m.reject(w, r, err)
+			return
+		}
+
+		if m.verifier.closed {
+			m.reject(w, r, ErrConfigClosed)
+			return
+		}
+
+		claims, err := m.verifier.validateClaims(tokenString)
+		if err != nil {
+			m.reject(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// reject sets the Bearer challenge for the error and writes the rejected response with the status of DescribeError.
+// Server-side failures get no challenge, so clients do not drop a token that may still be valid.
+func (m *AuthMiddleware) reject(w http.ResponseWriter, r *http.Request, err error) {
+	status := DescribeError(err).Status
+	switch {
+	case errors.Is(err, ErrNoTokenFound):
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	case errors.Is(err, ErrMalformedCredentials):
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+	case status < http.StatusInternalServerError:
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+
+	m.errorWriter(w, r, status, err)
+}
+
+// writeAuthError writes the status text as a plain text body.
+func writeAuthError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,186 @@
+package hydrate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func serveWithMiddleware(t *testing.T, middleware *AuthMiddleware, authorization string) (*httptest.ResponseRecorder, jwt.MapClaims) {
+	var claims jwt.MapClaims
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = ClaimsFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder, claims
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware, err := NewAuthMiddleware(verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	valid := signTestClaims(t, jwt.MapClaims{"sub": "user"})
+	expired := signTestClaims(t, jwt.MapClaims{"exp": time.Now().Add(-1 * time.Hour).Unix()})
+
+	cases := []struct {
+		name          string
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{"valid token", "Bearer " + valid, http.StatusNoContent, ""},
+		{"lowercase scheme", "bearer " + valid, http.StatusNoContent, ""},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"missing header", "", http.StatusUnauthorized, "Bearer"},
+		{"other scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "Bearer"},
+		{"malformed header", "Bearer ", http.StatusBadRequest, `Bearer error="invalid_request"`},
+		{"malformed token", "Bearer not-a-token", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+	}
+
+	for _, c := range cases {
+		recorder, claims := serveWithMiddleware(t, middleware, c.authorization)
+
+		if recorder.Code != c.status {
+			t.Errorf("%s: Expected status %d, got %d", c.name, c.status, recorder.Code)
+		}
+
+		if challenge := recorder.Header().Get("WWW-Authenticate"); challenge != c.challenge {
+			t.Errorf("%s: Expected challenge %q, got %q", c.name, c.challenge, challenge)
+		}
+
+		if (claims != nil) != (c.status == http.StatusNoContent) {
+			t.Errorf("%s: Expected claims in the context only for valid tokens, got %v", c.name, claims)
+		}
+	}
+}
+
+func TestMiddlewareBackendFailure(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey), WithRevocationStore(failingRevocationStore{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware, err := NewAuthMiddleware(verifier)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token := signTestClaims(t, jwt.MapClaims{"sub": "user", "jti": "id"})
+	recorder, _ := serveWithMiddleware(t, middleware, "Bearer "+token)
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d for a store outage, got %d", http.StatusInternalServerError, recorder.Code)
+	}
+
+	if challenge := recorder.Header().Get("WWW-Authenticate"); challenge != "" {
+		t.Errorf("Expected no challenge for a store outage, got %q", challenge)
+	}
+}
+
+func TestMiddlewareWrappedErrors(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		err       error
+		status    int
+		challenge string
+	}{
+		{fmt.Errorf("%w: no session cookie", ErrNoTokenFound), http.StatusUnauthorized, "Bearer"},
+		{fmt.Errorf("%w: bad encoding", ErrMalformedCredentials), http.StatusBadRequest, `Bearer error="invalid_request"`},
+	}
+
+	for _, c := range cases {
+		extractor := ExtractorFunc(func(r *http.Request) (string, error) {
+			return "", c.err
+		})
+
+		middleware, err := NewAuthMiddleware(verifier, WithExtractors(extractor))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		recorder, _ := serveWithMiddleware(t, middleware, "")
+		if recorder.Code != c.status || recorder.Header().Get("WWW-Authenticate") != c.challenge {
+			t.Errorf("%v: Expected %d %q, got %d %q", c.err, c.status, c.challenge, recorder.Code, recorder.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestMiddlewareErrorWriter(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var rejected error
+	middleware, err := NewAuthMiddleware(verifier, WithErrorWriter(func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		rejected = err
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"code":"` + DescribeError(err).Code + `"}`))
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expired := signTestClaims(t, jwt.MapClaims{"exp": time.Now().Add(-1 * time.Hour).Unix()})
+	recorder, _ := serveWithMiddleware(t, middleware, "Bearer "+expired)
+
+	if rejected != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, rejected)
+	}
+
+	if body := recorder.Body.String(); recorder.Code != http.StatusUnauthorized || body != `{"code":"token_expired"}` {
+		t.Errorf("Expected the custom body, got %d %q", recorder.Code, body)
+	}
+
+	if _, err := NewAuthMiddleware(nil); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
+
+func TestTokenConfigMiddleware(t *testing.T) {
+	token, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	handler := config.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := ClaimsFromContext(r.Context()); !ok || claims["iss"] != "test" {
+			t.Errorf("Expected the claims in the context, got %v", claims)
+		}
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+string(token))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package hydrate
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// pairIDClaim is the claim linking an access token to the refresh token generated with it.
+const pairIDClaim = "pair_id"
+
+// TokenPair is an access and refresh token pair generated together, linked by a shared pair ID.
+type TokenPair struct {
+	AccessToken  []byte // Signed access token
+	RefreshToken []byte // Signed refresh token
+	PairID       string // Value of the "pair_id" claim of both tokens
+}
+
+// IssueTokenPair generates a new access and refresh token pair like GenerateTokenPair, returning the pair ID with them.
+// Applications can persist the pair ID to revoke or audit the pair later.
+func IssueTokenPair(accessConfig, refreshConfig *TokenConfig) (*TokenPair, error) {
+	accessToken, refreshToken, err := GenerateTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		PairID:       accessConfig.pairID,
+	}, nil
+}
+
+// PairID returns the pair ID stamped on the tokens of the configuration by GenerateTokenPair, if any.
+func (t *TokenConfig) PairID() string {
+	return t.pairID
+}
+
+// stampPairID links the access and refresh configurations with a shared pair ID, reusing the access one if set.
+func stampPairID(accessConfig, refreshConfig *TokenConfig) error {
+	if accessConfig.pairID == "" {
+		pairID, err := newPairID(accessConfig.rand)
+		if err != nil {
+			return err
+		}
+
+		accessConfig.pairID = pairID
+	}
+
+	refreshConfig.pairID = accessConfig.pairID
+	return nil
+}
+
+// updatePairID sets the "pair_id" claim of the token, if the configuration is part of a pair.
+func (t *TokenConfig) updatePairID(claims jwt.MapClaims) {
+	if t.pairID != "" {
+		claims[pairIDClaim] = t.pairID
+	}
+}
+
+// checkPairID rejects refresh claims whose pair ID does not match the configuration, if it is part of a pair.
+func (t *TokenConfig) checkPairID(claims jwt.MapClaims) error {
+	if t.pairID == "" {
+		return nil
+	}
+
+	if pairID, _ := claims[pairIDClaim].(string); pairID != t.pairID {
+		return ErrTokenPairMismatch
+	}
+
+	return nil
+}
+
+// newPairID returns a random version 4 UUID read from the source.
+func newPairID(r io.Reader) (string, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return "", ErrInsecureRandomSource
+	}
+
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
@@ -0,0 +1,74 @@
+package hydrate
+
+import (
+	"regexp"
+	"testing"
+)
+
+func setupTokenPair(t *testing.T) (*TokenConfig, *TokenConfig, *TokenPair) {
+	accessConfig, refreshConfig, err := setupTokens(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pair, err := IssueTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+
+	return accessConfig, refreshConfig, pair
+}
+
+func TestTokenPairSharesPairID(t *testing.T) {
+	accessConfig, refreshConfig, pair := setupTokenPair(t)
+
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(pair.PairID) {
+		t.Errorf("Expected a version 4 UUID pair ID, got %q", pair.PairID)
+	}
+
+	for _, config := range []*TokenConfig{accessConfig, refreshConfig} {
+		claims, err := config.ExtractClaims()
+		if err != nil {
+			t.Fatalf("Unexpected error extracting claims: %v", err)
+		}
+
+		if claims[pairIDClaim] != pair.PairID || config.PairID() != pair.PairID {
+			t.Errorf("Expected pair ID %q, got %v", pair.PairID, claims[pairIDClaim])
+		}
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != nil {
+		t.Errorf("Unexpected error refreshing token: %v", err)
+	}
+}
+
+func TestTokenPairMismatch(t *testing.T) {
+	accessA, refreshA, pairA := setupTokenPair(t)
+	accessB, refreshB, pairB := setupTokenPair(t)
+
+	if pairA.PairID == pairB.PairID {
+		t.Fatalf("Expected distinct pair IDs, got %q twice", pairA.PairID)
+	}
+
+	if _, err := accessA.RefreshToken(refreshB); err != ErrTokenPairMismatch {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenPairMismatch, err)
+	}
+
+	if _, _, err := accessB.RotateTokenPair(refreshA); err != ErrTokenPairMismatch {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenPairMismatch, err)
+	}
+
+	_, refreshToken, err := accessA.RotateTokenPair(refreshA)
+	if err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	claims, err := refreshA.ExtractClaimsFrom(string(refreshToken))
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if claims[pairIDClaim] != pairA.PairID {
+		t.Errorf("Expected the pair ID to survive rotation, got %v", claims[pairIDClaim])
+	}
+}
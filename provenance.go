@@ -0,0 +1,40 @@
+package hydrate
+
+import (
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// provenanceClaim is the meta claim listing the claim keys set by the issuer.
+const provenanceClaim = "_iss_claims"
+
+// WithClaimsProvenance optionally records which claims were set by the issuer.
+// The keys originating from WithStandardClaims are listed in the "_iss_claims" meta claim.
+func WithClaimsProvenance() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.provenance = true
+		return nil
+	}
+}
+
+// IssuerSetClaims reports which claim keys were set by the issuer.
+// Returns nil if the token was generated without provenance tagging.
+func IssuerSetClaims(claims jwt.MapClaims) []string {
+	return stringSlice(claims[provenanceClaim])
+}
+
+// issuerClaimKeys returns the sorted keys of the standard claims that will be copied to the token.
+// It is a utility function used to build the provenance meta claim.
+func issuerClaimKeys(standardClaims jwt.StandardClaims) []string {
+	claims := make(jwt.MapClaims)
+	copyStandardClaims(&claims, standardClaims)
+
+	keys := make([]string, 0, len(claims))
+	for key := range claims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,94 @@
+package hydrate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestValidClaimsProvenance(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "test",
+			Subject:   "user",
+		}),
+		WithCustomClaims(map[string]interface{}{
+			"role": "admin",
+		}),
+		WithClaimsProvenance(),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	expected := []string{"exp", "iss", "sub"}
+	if keys := IssuerSetClaims(claims); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected issuer claims to be %v, got %v", expected, keys)
+	}
+}
+
+func TestMissingClaimsProvenance(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	if _, ok := claims[provenanceClaim]; ok {
+		t.Errorf("Expected %s claim to be absent", provenanceClaim)
+	}
+
+	if keys := IssuerSetClaims(claims); keys != nil {
+		t.Errorf("Expected no issuer claims, got %v", keys)
+	}
+}
+
+func TestCustomClaimCannotSpoofProvenance(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithCustomClaims(map[string]interface{}{
+			provenanceClaim: []string{"role"},
+			"role":          "admin",
+		}),
+		WithClaimsProvenance(),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = config.GenerateToken()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	expected := []string{"exp"}
+	if keys := IssuerSetClaims(claims); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected issuer claims to be %v, got %v", expected, keys)
+	}
+}
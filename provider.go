@@ -0,0 +1,105 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SecretProvider supplies the secret keys used to sign and verify tokens, such as keys held in Vault or a KMS.
+// Keys are requested every time a token is signed or verified, so rotating the current key takes effect immediately.
+type SecretProvider interface {
+	// CurrentKey returns the key ID and the key used to sign new tokens.
+	CurrentKey(ctx context.Context) (kid string, key []byte, err error)
+	// KeyByID returns the key with the key ID, or ErrUnknownKeyID if there is none.
+	KeyByID(ctx context.Context, kid string) ([]byte, error)
+}
+
+// WithSecretProvider sets the provider of the secret keys used to sign and verify tokens.
+// The current key ID is written to the kid header of generated tokens and used to resolve the key when parsing.
+func WithSecretProvider(provider SecretProvider) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if provider == nil {
+			return ErrInvalidSecretKey
+		}
+
+		t.secretProvider = provider
+		return nil
+	}
+}
+
+// staticSecretProvider provides a single secret key stored in secure memory.
+type staticSecretProvider struct {
+	kid    string      // Key ID of the secret key
+	secret secretStore // Secret key
+}
+
+// NewStaticSecretProvider instantiates a SecretProvider holding a single secret key in secure memory.
+// It behaves like SecretKey, with the key ID written to the kid header when it is not empty.
+func NewStaticSecretProvider(kid string, key []byte) (SecretProvider, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidSecretKey
+	}
+
+	secret, err := newSecureSecret(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecureMemoryFailed, err)
+	}
+
+	return &staticSecretProvider{kid: kid, secret: secret}, nil
+}
+
+// CurrentKey returns the secret key and its key ID.
+func (p *staticSecretProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	return p.kid, p.secret.Expose(), nil
+}
+
+// KeyByID returns the secret key if the key ID matches, or ErrUnknownKeyID otherwise.
+func (p *staticSecretProvider) KeyByID(ctx context.Context, kid string) ([]byte, error) {
+	if kid != p.kid {
+		return nil, ErrUnknownKeyID
+	}
+
+	return p.secret.Expose(), nil
+}
+
+// providerKey resolves the key with the key ID from the secret provider.
+// Provider failures other than an unknown key ID are wrapped in ErrSecretProvider.
+func (t *TokenConfig) providerKey(kid string) ([]byte, error) {
+	key, err := t.secretProvider.KeyByID(context.Background(), kid)
+	if errors.Is(err, ErrUnknownKeyID) {
+		return nil, ErrUnknownKeyID
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretProvider, err)
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrSecretProvider, ErrInvalidSecretKey)
+	}
+
+	if err := t.checkKeySize(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// currentProviderKey fetches the key ID and the key used to sign new tokens from the secret provider.
+func (t *TokenConfig) currentProviderKey() (string, []byte, error) {
+	kid, key, err := t.secretProvider.CurrentKey(context.Background())
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrSecretProvider, err)
+	}
+
+	if len(key) == 0 {
+		return "", nil, fmt.Errorf("%w: %w", ErrSecretProvider, ErrInvalidSecretKey)
+	}
+
+	if err := t.checkKeySize(key); err != nil {
+		return "", nil, err
+	}
+
+	return kid, key, nil
+}
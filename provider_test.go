@@ -0,0 +1,150 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var errVaultSealed = errors.New("vault is sealed")
+
+// rotatingProvider is a fake secret provider whose current key can be rotated.
+type rotatingProvider struct {
+	mu      sync.Mutex
+	keys    map[string][]byte
+	current string
+	err     error
+}
+
+func (p *rotatingProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current, p.keys[p.current], p.err
+}
+
+func (p *rotatingProvider) KeyByID(ctx context.Context, kid string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+func (p *rotatingProvider) rotate(kid string, key []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keys[kid] = key
+	p.current = kid
+}
+
+func newProviderToken(t *testing.T, provider SecretProvider) *TokenConfig {
+	config, err := NewToken(
+		WithSecretProvider(provider),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestSecretProviderRotation(t *testing.T) {
+	provider := &rotatingProvider{keys: map[string][]byte{"v1": keyV1}, current: "v1"}
+	config := newProviderToken(t, provider)
+
+	before, err := newProviderToken(t, provider).GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	provider.rotate("v2", keyV2)
+
+	after, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	token, err := config.ParseToken()
+	if err != nil || token.Header["kid"] != "v2" {
+		t.Errorf("Expected token signed with the rotated key v2, got %v", err)
+	}
+
+	for _, tokenString := range []string{string(before), string(after)} {
+		if _, err := config.ParseTokenString(tokenString); err != nil {
+			t.Errorf("Unexpected error parsing token: %v", err)
+		}
+	}
+
+	delete(provider.keys, "v1")
+
+	if _, err := config.ParseTokenString(string(before)); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+}
+
+func TestSecretProviderFailure(t *testing.T) {
+	provider := &rotatingProvider{keys: map[string][]byte{"v1": keyV1}, current: "v1"}
+	config := newProviderToken(t, provider)
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	provider.err = errVaultSealed
+
+	if _, err := config.GenerateToken(); !errors.Is(err, ErrSecretProvider) || !errors.Is(err, errVaultSealed) {
+		t.Errorf("Expected provider error, got: %v", err)
+	}
+
+	if _, err := config.ParseTokenString(string(token)); !errors.Is(err, ErrSecretProvider) {
+		t.Errorf("Expected provider error, got: %v", err)
+	}
+}
+
+func TestStaticSecretProvider(t *testing.T) {
+	provider, err := NewStaticSecretProvider("", secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := newProviderToken(t, provider).GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	_, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.ParseTokenString(string(token)); err != nil {
+		t.Errorf("Expected token to verify with the same secret key, got: %v", err)
+	}
+
+	if _, err := NewStaticSecretProvider("", nil); err != ErrInvalidSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidSecretKey, err)
+	}
+}
+
+func TestNilSecretProvider(t *testing.T) {
+	if _, err := NewToken(WithSecretProvider(nil)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
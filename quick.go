@@ -0,0 +1,111 @@
+package hydrate
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// TokenInfo describes a decoded token for printing.
+type TokenInfo struct {
+	Algorithm string                 // Signing algorithm from the header
+	KeyID     string                 // Key ID from the header, if any
+	Header    map[string]interface{} // Decoded header
+	Claims    jwt.MapClaims          // Decoded claims
+	IssuedAt  time.Time              // Time the token was issued, zero if absent
+	NotBefore time.Time              // Time the token becomes valid, zero if absent
+	ExpiresAt time.Time              // Time the token expires, zero if absent
+}
+
+// QuickSign mints an HS256 token with the claims that expires after ttl, without building a TokenConfig.
+// The iat and jti claims are set unless provided, and the secret must pass the minimum length check.
+// Options such as WithClock configure the TokenConfig built internally.
+func QuickSign(secret []byte, claims map[string]interface{}, ttl time.Duration, options ...func(*TokenConfig) error) (string, error) {
+	options = append(append([]func(*TokenConfig) error{SecretKey(secret)}, options...), withQuickClaims(claims, ttl))
+
+	config, err := NewToken(options...)
+	if err != nil {
+		return "", err
+	}
+	defer config.Close()
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
+
+// withQuickClaims sets the claims of QuickSign with WithStandardClaims and WithCustomClaims, expiring after ttl.
+// It runs after the caller's options, so the times and the jti come from their clock and random source.
+func withQuickClaims(claims map[string]interface{}, ttl time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		now := t.now()
+		standardClaims := jwt.StandardClaims{
+			ExpiresAt: now.Add(ttl).Unix(),
+			IssuedAt:  now.Unix(),
+		}
+
+		if _, ok := claims["iat"]; ok {
+			standardClaims.IssuedAt = 0
+		}
+
+		if _, ok := claims["jti"]; !ok {
+			jti, err := newTokenID(t.rand)
+			if err != nil {
+				return err
+			}
+			standardClaims.Id = jti
+		}
+
+		if err := WithStandardClaims(standardClaims)(t); err != nil {
+			return err
+		}
+
+		if len(claims) > 0 {
+			return WithCustomClaims(claims)(t)
+		}
+
+		return nil
+	}
+}
+
+// QuickInspect decodes the token and validates it with the secret, without building a TokenConfig.
+// The TokenInfo is filled whenever the token can be decoded; the error reports why it is invalid, as Validate does.
+// Options such as WithClock configure the verifier built internally.
+func QuickInspect(token string, secret []byte, options ...func(*TokenConfig) error) (TokenInfo, error) {
+	claims := make(jwt.MapClaims)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+	if err != nil {
+		return TokenInfo{}, ErrTokenInvalid
+	}
+
+	config, err := NewVerifier(append([]func(*TokenConfig) error{SecretKey(secret), WithToken(token)}, options...)...)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	defer config.Close()
+
+	info := TokenInfo{
+		Algorithm: parsed.Method.Alg(),
+		Header:    parsed.Header,
+		Claims:    claims,
+		IssuedAt:  claimTime(claims["iat"]),
+		NotBefore: claimTime(claims["nbf"]),
+		ExpiresAt: claimTime(claims["exp"]),
+	}
+	info.KeyID, _ = parsed.Header["kid"].(string)
+
+	return info, config.Validate()
+}
+
+// claimTime converts a numeric date claim to a time, returning the zero time if it is absent.
+func claimTime(value interface{}) time.Time {
+	seconds, ok := numericClaim(value)
+	if !ok {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
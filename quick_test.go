@@ -0,0 +1,132 @@
+package hydrate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuickSign(t *testing.T) {
+	token, err := QuickSign(secretKey, map[string]interface{}{"sub": "ops", "role": "debug"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	config, err := NewVerifier(SecretKey(secretKey), WithToken(token))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Expected token to verify with the full API, got: %v", err)
+	}
+
+	if claims["sub"] != "ops" || claims["role"] != "debug" {
+		t.Errorf("Expected custom claims to be kept, got %v", claims)
+	}
+
+	if jti, _ := claims["jti"].(string); len(jti) != 2*tokenIDSize {
+		t.Errorf("Expected a random jti, got %v", claims["jti"])
+	}
+
+	if _, ok := claims["iat"]; !ok {
+		t.Errorf("Expected iat to be set")
+	}
+
+	if _, err := QuickSign([]byte("secret"), nil, time.Hour); err != ErrWeakSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrWeakSecretKey, err)
+	}
+}
+
+func TestQuickInspect(t *testing.T) {
+	token, config, err := setupToken(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := QuickInspect(string(token), secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if info.Algorithm != "HS256" || info.ExpiresAt.Unix() != int64(claims["exp"].(float64)) {
+		t.Errorf("Expected HS256 token expiring at %v, got %+v", claims["exp"], info)
+	}
+
+	for key, value := range claims {
+		if info.Claims[key] != value {
+			t.Errorf("Expected claim %s to be %v, got %v", key, value, info.Claims[key])
+		}
+	}
+}
+
+func TestQuickInspectInvalidToken(t *testing.T) {
+	expired, err := QuickSign(secretKey, map[string]interface{}{"sub": "ops"}, -time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := QuickInspect(expired, secretKey)
+	if err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+
+	if info.Claims["sub"] != "ops" || info.ExpiresAt.IsZero() {
+		t.Errorf("Expected an expired token to still be decoded, got %+v", info)
+	}
+
+	if _, err := QuickInspect(expired, otherSecretKey); err != ErrSignatureInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrSignatureInvalid, err)
+	}
+
+	if _, err := QuickInspect("not a token", secretKey); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestQuickSignUsesClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	token, err := QuickSign(secretKey, map[string]interface{}{"sub": "ops"}, time.Hour, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	info, err := QuickInspect(token, secretKey, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !info.IssuedAt.Equal(clock.Now()) || !info.ExpiresAt.Equal(clock.Now().Add(1*time.Hour)) {
+		t.Errorf("Expected the times of the clock, got iat %v and exp %v", info.IssuedAt, info.ExpiresAt)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, err := QuickInspect(token, secretKey, WithClock(clock)); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestQuickSignUsesRandSource(t *testing.T) {
+	var jtis []interface{}
+	for i := 0; i < 2; i++ {
+		token, err := QuickSign(secretKey, nil, time.Hour, WithRandSource(&sequenceReader{}))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		info, err := QuickInspect(token, secretKey)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		jtis = append(jtis, info.Claims["jti"])
+	}
+
+	if jtis[0] != jtis[1] {
+		t.Errorf("Expected the jti to be drawn from the configured source, got %v and %v", jtis[0], jtis[1])
+	}
+}
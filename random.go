@@ -0,0 +1,38 @@
+package hydrate
+
+import (
+	"io"
+)
+
+// randSampleSize is the number of bytes read from a random source during the startup self-check.
+const randSampleSize = 32
+
+// WithRandSource sets the source of randomness used by the token configuration.
+// If you don't call this function, the default source is crypto/rand.
+func WithRandSource(source io.Reader) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if source == nil {
+			return ErrInsecureRandomSource
+		}
+
+		t.rand = source
+		return nil
+	}
+}
+
+// checkRandSource reads a sample from the random source and rejects obviously broken sources.
+// Short reads, read errors, and samples made of a single repeated byte are treated as insecure.
+func checkRandSource(source io.Reader) error {
+	sample := make([]byte, randSampleSize)
+	if _, err := io.ReadFull(source, sample); err != nil {
+		return ErrInsecureRandomSource
+	}
+
+	for _, b := range sample[1:] {
+		if b != sample[0] {
+			return nil
+		}
+	}
+
+	return ErrInsecureRandomSource
+}
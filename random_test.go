@@ -0,0 +1,79 @@
+package hydrate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// sequenceReader is a deterministic random source that yields an incrementing byte sequence.
+type sequenceReader struct {
+	next byte
+}
+
+func (r *sequenceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.next
+		r.next++
+	}
+	return len(p), nil
+}
+
+// zeroReader is a broken random source that only yields zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func newTokenWithRand(source io.Reader) (*TokenConfig, error) {
+	return NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithRandSource(source),
+	)
+}
+
+func TestValidRandSource(t *testing.T) {
+	config, err := newTokenWithRand(&sequenceReader{})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if config == nil {
+		t.Errorf("Expected token config to be created")
+	}
+}
+
+func TestZeroRandSource(t *testing.T) {
+	_, err := newTokenWithRand(zeroReader{})
+
+	if err != ErrInsecureRandomSource {
+		t.Errorf("Expected error: %v, got: %v", ErrInsecureRandomSource, err)
+	}
+}
+
+func TestShortRandSource(t *testing.T) {
+	_, err := newTokenWithRand(bytes.NewReader([]byte{1, 2, 3}))
+
+	if err != ErrInsecureRandomSource {
+		t.Errorf("Expected error: %v, got: %v", ErrInsecureRandomSource, err)
+	}
+}
+
+func TestNilRandSource(t *testing.T) {
+	_, err := newTokenWithRand(nil)
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
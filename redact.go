@@ -0,0 +1,45 @@
+package hydrate
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// RedactedClaimValue replaces the value of sensitive claims in redacted claims.
+const RedactedClaimValue = "[REDACTED]"
+
+// WithSensitiveClaims optionally marks claims whose values must never leave the verification path.
+// RedactClaims replaces their values; the claims returned by ExtractClaims are left untouched.
+func WithSensitiveClaims(names ...string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(names) == 0 {
+			return fmt.Errorf("%w: sensitive claim names must be non-empty", ErrInvalidTokenConfig)
+		}
+
+		sensitive := make(map[string]bool, len(names))
+		for _, name := range names {
+			if name == "" {
+				return fmt.Errorf("%w: sensitive claim names must be non-empty", ErrInvalidTokenConfig)
+			}
+			sensitive[name] = true
+		}
+
+		t.sensitiveClaims = sensitive
+		return nil
+	}
+}
+
+// RedactClaims returns a copy of the claims with the values of sensitive claims replaced by RedactedClaimValue.
+// Use it before passing claims to logs, metrics, or any other observer.
+func (t *TokenConfig) RedactClaims(claims jwt.MapClaims) jwt.MapClaims {
+	redacted := make(jwt.MapClaims, len(claims))
+	for name, value := range claims {
+		if t.sensitiveClaims[name] {
+			value = RedactedClaimValue
+		}
+		redacted[name] = value
+	}
+
+	return redacted
+}
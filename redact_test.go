@@ -0,0 +1,62 @@
+package hydrate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestRedactClaims(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithSensitiveClaims("email", "nid"),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Subject:   "user-1",
+		}),
+		WithCustomClaims(map[string]interface{}{
+			"email": "user@example.com",
+			"nid":   "123-45-6789",
+			"role":  "admin",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	redacted := fmt.Sprint(config.RedactClaims(claims))
+	for _, value := range []string{"user@example.com", "123-45-6789"} {
+		if strings.Contains(redacted, value) {
+			t.Errorf("Expected sensitive value %s to be redacted", value)
+		}
+	}
+
+	if !strings.Contains(redacted, "admin") || !strings.Contains(redacted, RedactedClaimValue) {
+		t.Errorf("Expected only sensitive claims to be redacted, got %s", redacted)
+	}
+
+	if claims["email"] != "user@example.com" {
+		t.Errorf("Expected the verified claims to be left untouched")
+	}
+}
+
+func TestInvalidSensitiveClaims(t *testing.T) {
+	for _, option := range []func(*TokenConfig) error{WithSensitiveClaims(), WithSensitiveClaims("email", "")} {
+		if err := option(&TokenConfig{}); !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxRefreshBodySize bounds the JSON body read by RefreshHandler.
+const maxRefreshBodySize = 64 << 10
+
+// TokenResponse is the RFC 6749 section 5.1 response of a successful refresh.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`  // Rotated access token
+	RefreshToken string `json:"refresh_token"` // Rotated refresh token
+	TokenType    string `json:"token_type"`    // Always "Bearer"
+	ExpiresIn    int64  `json:"expires_in"`    // Lifetime of the access token, in seconds
+}
+
+// TokenResponder writes the response of a successful refresh.
+type TokenResponder func(w http.ResponseWriter, r *http.Request, response TokenResponse)
+
+// RefreshHandler is an http.Handler rotating the token pair presented to a /refresh endpoint.
+// It accepts POST requests with a JSON body {"refresh_token": "..."} or the refresh token cookie.
+type RefreshHandler struct {
+	accessConfig  *TokenConfig   // Configuration issuing the access tokens
+	refreshConfig *TokenConfig   // Configuration verifying and rotating the refresh tokens
+	cookies       CookieOptions  // Cookies read, and rewritten when the refresh token came from a cookie
+	responder     TokenResponder // Writer of successful responses
+	errorWriter   ErrorWriter    // Writer of rejected responses
+	lock          sync.Mutex     // Serializes rotations, which update the configurations
+}
+
+// NewRefreshHandler instantiates a RefreshHandler rotating pairs generated by GenerateTokenPair with the configurations.
+func NewRefreshHandler(accessConfig, refreshConfig *TokenConfig, options ...func(*RefreshHandler) error) (*RefreshHandler, error) {
+	if accessConfig == nil || refreshConfig == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	handler := &RefreshHandler{
+		accessConfig:  accessConfig,
+		refreshConfig: refreshConfig,
+		responder:     writeTokenResponse,
+		errorWriter:   writeOAuthError,
+	}
+	for _, option := range options {
+		if err := option(handler); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler, nil
+}
+
+// WithRefreshCookies optionally sets the cookies read and rewritten by the handler, instead of the CookieOptions defaults.
+func WithRefreshCookies(opts CookieOptions) func(*RefreshHandler) error {
+	return func(h *RefreshHandler) error {
+		h.cookies = opts
+		return nil
+	}
+}
+
+// WithTokenResponder optionally replaces the JSON body written for successful refreshes, e.g. to negotiate another format.
+func WithTokenResponder(responder TokenResponder) func(*RefreshHandler) error {
+	return func(h *RefreshHandler) error {
+		if responder == nil {
+			return ErrInvalidTokenConfig
+		}
+
+		h.responder = responder
+		return nil
+	}
+}
+
+// WithRefreshErrorWriter optionally replaces the RFC 6749 JSON error body written for rejected refreshes.
+func WithRefreshErrorWriter(writer ErrorWriter) func(*RefreshHandler) error {
+	return func(h *RefreshHandler) error {
+		if writer == nil {
+			return ErrInvalidTokenConfig
+		}
+
+		h.errorWriter = writer
+		return nil
+	}
+}
+
+// ServeHTTP rotates the presented refresh token and writes the new pair.
+// Malformed requests get 400 Bad Request, and invalid, expired, or reused refresh tokens 401 Unauthorized.
+func (h *RefreshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.errorWriter(w, r, http.StatusMethodNotAllowed, ErrMalformedCredentials)
+		return
+	}
+
+	refreshToken, fromCookie, err := h.presentedToken(r)
+	if err != nil {
+		h.errorWriter(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	h.lock.Lock()
+	pair, err := h.accessConfig.rotatePresented(h.refreshConfig, refreshToken)
+	expiresIn := h.accessConfig.tokenExpiry - h.accessConfig.now().Unix()
+	h.lock.Unlock()
+
+	if err != nil {
+		status := http.StatusUnauthorized
+		if DescribeError(err).Status >= http.StatusInternalServerError {
+			status = http.StatusInternalServerError
+		}
+
+		h.errorWriter(w, r, status, err)
+		return
+	}
+
+	if fromCookie {
+		if err := h.accessConfig.SetAuthCookies(w, pair, h.cookies); err != nil {
+			h.errorWriter(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	h.responder(w, r, TokenResponse{
+		AccessToken:  string(pair.AccessToken),
+		RefreshToken: string(pair.RefreshToken),
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+	})
+}
+
+// presentedToken returns the refresh token of the JSON body, or else of the refresh cookie, and whether it came from the cookie.
+// Returns ErrMalformedCredentials for unreadable bodies, or ErrNoTokenFound if neither holds a token.
+func (h *RefreshHandler) presentedToken(r *http.Request) (string, bool, error) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := json.NewDecoder(io.LimitReader(r.Body, maxRefreshBodySize)).Decode(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", false, ErrMalformedCredentials
+	}
+
+	if body.RefreshToken != "" {
+		return body.RefreshToken, false, nil
+	}
+
+	_, refreshName := h.cookies.names()
+	if cookie, err := r.Cookie(refreshName); err == nil && cookie.Value != "" {
+		return cookie.Value, true, nil
+	}
+
+	return "", false, ErrNoTokenFound
+}
+
+// writeTokenResponse writes the response as uncached JSON, per RFC 6749 section 5.1.
+func writeTokenResponse(w http.ResponseWriter, r *http.Request, response TokenResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeOAuthError writes an RFC 6749 section 5.2 error body for the status.
+func writeOAuthError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	code := "invalid_request"
+	switch status {
+	case http.StatusUnauthorized:
+		code = "invalid_grant"
+	case http.StatusInternalServerError:
+		code = "server_error"
+	}
+
+	writeOAuthErrorCode(w, status, code)
+}
+
+// writeOAuthErrorCode writes an RFC 6749 section 5.2 error body with the code.
+func writeOAuthErrorCode(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
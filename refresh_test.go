@@ -0,0 +1,119 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupRefreshHandler(t *testing.T, clock *FakeClock) (*RefreshHandler, *TokenConfig, *TokenPair) {
+	accessConfig, refreshConfig, pair := setupCookiePair(t, clock)
+
+	handler, err := NewRefreshHandler(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return handler, accessConfig, pair
+}
+
+func serveRefresh(handler http.Handler, method, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/refresh", strings.NewReader(body))
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRefreshHandler(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	handler, accessConfig, pair := setupRefreshHandler(t, clock)
+	clock.Advance(1 * time.Second)
+
+	recorder := serveRefresh(handler, http.MethodPost, `{"refresh_token":"`+string(pair.RefreshToken)+`"}`, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body)
+	}
+
+	var response TokenResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatalf("Unexpected error decoding response: %v", err)
+	}
+
+	if response.TokenType != "Bearer" || response.ExpiresIn != int64(time.Hour.Seconds()) || response.RefreshToken == string(pair.RefreshToken) {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	if err := accessConfig.ValidateString(response.AccessToken); err != nil {
+		t.Errorf("Unexpected error validating the access token: %v", err)
+	}
+
+	if len(recorder.Result().Cookies()) != 0 {
+		t.Errorf("Expected no cookies when the token came from the body")
+	}
+
+	recorder = serveRefresh(handler, http.MethodPost, "", &http.Cookie{Name: "refresh_token", Value: response.RefreshToken})
+	if recorder.Code != http.StatusOK || len(recorder.Result().Cookies()) != 2 {
+		t.Errorf("Expected the cookie refresh to rewrite both cookies, got %d with %d cookies", recorder.Code, len(recorder.Result().Cookies()))
+	}
+}
+
+func TestRefreshHandlerErrors(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	handler, _, pair := setupRefreshHandler(t, clock)
+
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed, "invalid_request"},
+		{"malformed JSON", http.MethodPost, `{"refresh_token":`, http.StatusBadRequest, "invalid_request"},
+		{"missing token", http.MethodPost, `{}`, http.StatusBadRequest, "invalid_request"},
+		{"invalid token", http.MethodPost, `{"refresh_token":"not-a-token"}`, http.StatusUnauthorized, "invalid_grant"},
+		{"expired token", http.MethodPost, `{"refresh_token":"` + string(pair.RefreshToken) + `"}`, http.StatusUnauthorized, "invalid_grant"},
+	}
+
+	clock.Advance(25 * time.Hour)
+
+	for _, c := range cases {
+		recorder := serveRefresh(handler, c.method, c.body, nil)
+
+		var body map[string]string
+		json.NewDecoder(recorder.Body).Decode(&body)
+
+		if recorder.Code != c.status || body["error"] != c.code {
+			t.Errorf("%s: Expected %d %s, got %d %v", c.name, c.status, c.code, recorder.Code, body)
+		}
+	}
+}
+
+func TestRefreshHandlerResponder(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	accessConfig, refreshConfig, pair := setupCookiePair(t, clock)
+
+	handler, err := NewRefreshHandler(accessConfig, refreshConfig, WithTokenResponder(func(w http.ResponseWriter, r *http.Request, response TokenResponse) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(response.AccessToken))
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	recorder := serveRefresh(handler, http.MethodPost, `{"refresh_token":"`+string(pair.RefreshToken)+`"}`, nil)
+	if recorder.Header().Get("Content-Type") != "text/plain" || accessConfig.ValidateString(recorder.Body.String()) != nil {
+		t.Errorf("Expected the custom response, got %q", recorder.Body)
+	}
+
+	if _, err := NewRefreshHandler(accessConfig, nil); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
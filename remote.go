@@ -0,0 +1,218 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// remoteKeySetTimeout bounds a single fetch of a remote JWK Set.
+	remoteKeySetTimeout = 10 * time.Second
+	// remoteKeySetMinRefetch is the minimum time between two fetches of a remote JWK Set, so tokens naming
+	// unknown key IDs cannot make the verifier flood the issuer with requests.
+	remoteKeySetMinRefetch = 30 * time.Second
+	// remoteKeySetMaxUnknown bounds the number of unknown key IDs remembered between fetches.
+	remoteKeySetMaxUnknown = 1024
+)
+
+// remoteKeySet caches the public keys published at a JWKS URL, indexed by key ID.
+type remoteKeySet struct {
+	url             string        // URL of the JWK Set
+	refreshInterval time.Duration // Maximum age of the cached keys
+	client          *http.Client  // Client used to fetch the JWK Set
+	clock           Clock         // Clock timing the cached keys and the fetches
+
+	mu        sync.RWMutex           // Guards keys, fetchedAt, and unknown
+	keys      map[string]interface{} // Last good keys by key ID
+	fetchedAt time.Time              // Time of the last successful fetch
+	unknown   map[string]time.Time   // Times unknown key IDs were last looked up
+
+	fetchMu     sync.Mutex    // Ensures a single fetch is in flight, and guards lastAttempt and lastErr
+	lastAttempt time.Time     // Time of the last fetch, successful or not
+	lastErr     error         // Error of the last fetch
+	stop        chan struct{} // Closed to stop the background refresh
+}
+
+// WithJWKSURL verifies tokens with the public keys published at the JWKS URL, selected by the token's kid header.
+// Keys are cached and refreshed in the background every refresh interval, and refetched when a token names an unknown key ID.
+// Refetches are at most every 30 seconds, or every refresh interval if shorter, and unknown key IDs are remembered meanwhile.
+// RS256 is selected; use WithAllowedMethods to accept the other methods published by the issuer.
+func WithJWKSURL(url string, refreshInterval time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if url == "" || refreshInterval <= 0 {
+			return ErrInvalidTokenConfig
+		}
+
+		t.remoteKeys = &remoteKeySet{
+			url:             url,
+			refreshInterval: refreshInterval,
+			client:          &http.Client{Timeout: remoteKeySetTimeout},
+			clock:           realClock{},
+		}
+		t.signingMethod = jwt.SigningMethodRS256
+		return nil
+	}
+}
+
+// start launches the background refresh of the keys.
+func (r *remoteKeySet) start() {
+	r.stop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(r.refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				_ = r.refresh(r.now())
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the background refresh.
+func (r *remoteKeySet) close() {
+	if r.stop != nil {
+		close(r.stop)
+	}
+}
+
+// key returns the public key with the key ID, refreshing the keys when they are stale or the key ID is unknown.
+// If the JWK Set cannot be fetched, the last good keys are used; ErrJWKSUnavailable is returned only when none exist.
+func (r *remoteKeySet) key(kid string) (interface{}, error) {
+	now := r.now()
+
+	key, ok, fresh := r.cached(kid, now)
+	if ok && fresh {
+		return key, nil
+	}
+
+	if fresh && r.knownUnknown(kid, now) {
+		return nil, ErrUnknownKeyID
+	}
+
+	err := r.refresh(now)
+
+	key, ok, _ = r.cached(kid, now)
+	if ok {
+		return key, nil
+	}
+
+	r.mu.Lock()
+	empty := r.keys == nil
+	if !empty {
+		if len(r.unknown) >= remoteKeySetMaxUnknown {
+			r.unknown = nil
+		}
+		if r.unknown == nil {
+			r.unknown = make(map[string]time.Time)
+		}
+		r.unknown[kid] = now
+	}
+	r.mu.Unlock()
+
+	if err != nil && empty {
+		return nil, ErrJWKSUnavailable
+	}
+
+	return nil, ErrUnknownKeyID
+}
+
+// cached looks up the key ID in the cached keys and reports whether the keys are younger than the refresh interval.
+func (r *remoteKeySet) cached(kid string, now time.Time) (interface{}, bool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	return key, ok, now.Sub(r.fetchedAt) < r.refreshInterval
+}
+
+// knownUnknown reports whether the key ID was looked up and missing less than the minimum refetch interval ago.
+func (r *remoteKeySet) knownUnknown(kid string, now time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen, ok := r.unknown[kid]
+	return ok && now.Sub(seen) < r.minRefetch()
+}
+
+// refresh fetches the JWK Set unless a fetch was attempted less than the minimum refetch interval ago,
+// in which case the error of that fetch is returned. Concurrent callers wait for the fetch in flight instead of
+// starting their own.
+func (r *remoteKeySet) refresh(now time.Time) error {
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+
+	if !r.lastAttempt.IsZero() && now.Sub(r.lastAttempt) < r.minRefetch() {
+		return r.lastErr
+	}
+	r.lastAttempt = now
+
+	keys, err := r.fetch()
+	r.lastErr = err
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.fetchedAt = now
+	r.unknown = nil
+	r.mu.Unlock()
+
+	return nil
+}
+
+// minRefetch returns the minimum time between two fetches.
+func (r *remoteKeySet) minRefetch() time.Duration {
+	if r.refreshInterval < remoteKeySetMinRefetch {
+		return r.refreshInterval
+	}
+
+	return remoteKeySetMinRefetch
+}
+
+// now returns the current wall clock time of the clock, stripped of its monotonic reading like wallNow.
+func (r *remoteKeySet) now() time.Time {
+	return r.clock.Now().Round(0)
+}
+
+// fetch downloads and decodes the JWK Set, skipping keys without a key ID or of an unsupported type.
+func (r *remoteKeySet) fetch() (map[string]interface{}, error) {
+	response, err := r.client.Get(r.url)
+	if err != nil {
+		return nil, ErrJWKSUnavailable
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, ErrJWKSUnavailable
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(response.Body).Decode(&set); err != nil {
+		return nil, ErrJWKSUnavailable
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,250 @@
+package hydrate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// jwksServer serves the JWK Set of the issuers and counts the requests it receives.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	issuers []*TokenConfig
+	down    bool
+	delay   time.Duration
+	fetches int32
+}
+
+func newJWKSServer(t *testing.T, issuers ...*TokenConfig) *jwksServer {
+	server := &jwksServer{issuers: issuers}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&server.fetches, 1)
+
+		server.mu.Lock()
+		issuers, down, delay := server.issuers, server.down, server.delay
+		server.mu.Unlock()
+
+		time.Sleep(delay)
+		if down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		NewJWKSHandler(issuers...).ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *jwksServer) set(issuers []*TokenConfig, down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issuers, s.down = issuers, down
+}
+
+func newRemoteIssuer(t *testing.T, kid string) (*TokenConfig, string) {
+	issuer, err := NewToken(append(rsaKey(jwt.SigningMethodRS256)(t),
+		WithActiveKeyID(kid),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "idp",
+		}),
+	)...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := issuer.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	return issuer, string(token)
+}
+
+func signWithRemoteKeyID(t *testing.T, issuer *TokenConfig, kid string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": time.Now().Add(1 * time.Hour).Unix()})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(issuer.signingKey())
+	if err != nil {
+		t.Fatalf("Unexpected error signing token: %v", err)
+	}
+
+	return signed
+}
+
+func newRemoteVerifier(t *testing.T, url string, refreshInterval time.Duration, clock Clock) *TokenConfig {
+	verifier, err := NewVerifier(WithJWKSURL(url, refreshInterval), WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { verifier.Close() })
+
+	return verifier
+}
+
+func TestJWKSURLCacheHit(t *testing.T) {
+	issuer, token := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, issuer)
+	verifier := newRemoteVerifier(t, server.URL, time.Hour, NewFakeClock(time.Now()))
+
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.ParseTokenString(token); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if fetches := atomic.LoadInt32(&server.fetches); fetches != 1 {
+		t.Errorf("Expected a single fetch, got %d", fetches)
+	}
+}
+
+func TestJWKSURLRefreshAfterExpiry(t *testing.T) {
+	issuer, token := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, issuer)
+	clock := NewFakeClock(time.Now())
+	verifier := newRemoteVerifier(t, server.URL, 10*time.Minute, clock)
+
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clock.Advance(15 * time.Minute)
+
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if fetches := atomic.LoadInt32(&server.fetches); fetches != 2 {
+		t.Errorf("Expected the keys to be refreshed, got %d fetches", fetches)
+	}
+}
+
+func TestJWKSURLKeyIDMissRefetch(t *testing.T) {
+	previous, previousToken := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, previous)
+	clock := NewFakeClock(time.Now())
+	verifier := newRemoteVerifier(t, server.URL, time.Hour, clock)
+
+	if _, err := verifier.ParseTokenString(previousToken); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	current, currentToken := newRemoteIssuer(t, "v2")
+	server.set([]*TokenConfig{previous, current}, false)
+
+	// Tokens signed with the new key are rejected until the minimum refetch interval passes.
+	if _, err := verifier.ParseTokenString(currentToken); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+
+	clock.Advance(remoteKeySetMinRefetch)
+	if _, err := verifier.ParseTokenString(currentToken); err != nil {
+		t.Errorf("Expected the rotated key to be fetched, got: %v", err)
+	}
+
+	_, unknownToken := newRemoteIssuer(t, "v3")
+	if _, err := verifier.ParseTokenString(unknownToken); err != ErrUnknownKeyID {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+	}
+}
+
+func TestJWKSURLSingleFlight(t *testing.T) {
+	issuer, token := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, issuer)
+	server.delay = 50 * time.Millisecond
+	verifier := newRemoteVerifier(t, server.URL, time.Hour, NewFakeClock(time.Now()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := verifier.ParseTokenString(token); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fetches := atomic.LoadInt32(&server.fetches); fetches != 1 {
+		t.Errorf("Expected concurrent misses to share a single fetch, got %d", fetches)
+	}
+}
+
+func TestJWKSURLUnavailable(t *testing.T) {
+	issuer, token := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, issuer)
+	server.set([]*TokenConfig{issuer}, true)
+	clock := NewFakeClock(time.Now())
+	verifier := newRemoteVerifier(t, server.URL, 10*time.Minute, clock)
+
+	if _, err := verifier.ParseTokenString(token); err != ErrJWKSUnavailable {
+		t.Errorf("Expected error: %v, got: %v", ErrJWKSUnavailable, err)
+	}
+
+	server.set([]*TokenConfig{issuer}, false)
+	clock.Advance(remoteKeySetMinRefetch)
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	server.set([]*TokenConfig{issuer}, true)
+	clock.Advance(15 * time.Minute)
+
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Errorf("Expected the last good keys to be used, got: %v", err)
+	}
+}
+
+func TestJWKSURLUnknownKeyIDRateLimited(t *testing.T) {
+	issuer, token := newRemoteIssuer(t, "v1")
+	server := newJWKSServer(t, issuer)
+	clock := NewFakeClock(time.Now())
+	verifier := newRemoteVerifier(t, server.URL, time.Hour, clock)
+
+	if _, err := verifier.ParseTokenString(token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		forged := signWithRemoteKeyID(t, issuer, fmt.Sprintf("bogus-%d", i))
+		if _, err := verifier.ParseTokenString(forged); err != ErrUnknownKeyID {
+			t.Errorf("Expected error: %v, got: %v", ErrUnknownKeyID, err)
+		}
+	}
+
+	if fetches := atomic.LoadInt32(&server.fetches); fetches != 1 {
+		t.Errorf("Expected unknown key IDs not to trigger fetches within the minimum interval, got %d fetches", fetches)
+	}
+
+	clock.Advance(remoteKeySetMinRefetch)
+	for i := 0; i < 3; i++ {
+		verifier.ParseTokenString(signWithRemoteKeyID(t, issuer, fmt.Sprintf("later-%d", i)))
+	}
+
+	if fetches := atomic.LoadInt32(&server.fetches); fetches != 2 {
+		t.Errorf("Expected a single refetch per minimum interval, got %d fetches", fetches)
+	}
+}
+
+func TestInvalidJWKSURL(t *testing.T) {
+	if _, err := NewVerifier(WithJWKSURL("", time.Hour)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	if _, err := NewVerifier(WithJWKSURL("http://localhost", 0)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
@@ -0,0 +1,168 @@
+package hydrate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// requestMethodClaim is the claim holding the HTTP method a request token is bound to.
+	requestMethodClaim = "htm"
+	// requestPathClaim is the claim holding the path a request token is bound to.
+	requestPathClaim = "htu"
+	// requestBodyHashClaim is the claim holding the base64url SHA-256 of the body a request token is bound to.
+	requestBodyHashClaim = "bth"
+)
+
+// defaultRequestBodyLimit is the size of the request bodies VerifyRequestToken hashes unless WithRequestBodyLimit sets one.
+const defaultRequestBodyLimit = 1 << 20
+
+// WithRequestBodyLimit optionally sets the size of the request bodies VerifyRequestToken hashes, 1 MiB by default.
+// Larger bodies fail with ErrRequestBodyTooLarge without being read past the limit.
+func WithRequestBodyLimit(bytes int64) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if bytes <= 0 {
+			return fmt.Errorf("%w: request body limit must be positive", ErrInvalidTokenConfig)
+		}
+
+		t.requestBodyLimit = bytes
+		return nil
+	}
+}
+
+// GenerateRequestToken signs a single-use token bound to one request, e.g. a webhook delivery: its method, its path,
+// and the SHA-256 of its body, e.g. the result of sha256.Sum256. The token gets a random jti for replay detection
+// and expires after the ttl. The token held by the configuration is left untouched.
+// Returns the token, or an error if one occurs.
+func (t *TokenConfig) GenerateRequestToken(method, path string, bodyHash []byte, ttl time.Duration) ([]byte, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	if !t.canSign() {
+		return nil, ErrSigningNotConfigured
+	}
+
+	if method == "" || path == "" || len(bodyHash) != sha256.Size || ttl <= 0 {
+		return nil, fmt.Errorf("%w: request tokens need a method, a path, a SHA-256 body hash, and a positive lifetime", ErrInvalidTokenConfig)
+	}
+
+	jti, err := newTokenID(t.rand)
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.now()
+	claims := jwt.MapClaims{
+		requestMethodClaim:   strings.ToUpper(method),
+		requestPathClaim:     path,
+		requestBodyHashClaim: base64.RawURLEncoding.EncodeToString(bodyHash),
+		"jti":                jti,
+		"iat":                now.Unix(),
+		"exp":                now.Add(ttl).Unix(),
+	}
+
+	signedToken, err := t.signClaims(claims, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(signedToken), nil
+}
+
+// VerifyRequestToken verifies the token and that it is bound to the request, then records its jti in the TokenStore.
+// The body is hashed as it is read, up to the WithRequestBodyLimit, and r.Body is restored for the handler.
+// Returns ErrTokenExpired for expired tokens, ErrRequestMismatch if the method or path differ, ErrBodyHashMismatch
+// if the body differs, ErrRequestBodyTooLarge for bodies over the limit, and ErrRequestTokenReplayed for reused
+// tokens. A TokenStore is required, otherwise ErrInvalidTokenConfig is returned.
+func (t *TokenConfig) VerifyRequestToken(r *http.Request, token string) error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	if t.tokenStore == nil {
+		return fmt.Errorf("%w: request tokens need a token store to detect replays", ErrInvalidTokenConfig)
+	}
+
+	claims, err := t.validateClaims(token)
+	if err != nil {
+		return err
+	}
+
+	method, _ := claims[requestMethodClaim].(string)
+	path, _ := claims[requestPathClaim].(string)
+	bodyHash, _ := claims[requestBodyHashClaim].(string)
+	jti, _ := claims["jti"].(string)
+	exp, ok := numericClaim(claims["exp"])
+	if method == "" || path == "" || bodyHash == "" || jti == "" || !ok {
+		return ErrClaimsInvalid
+	}
+
+	requestHash, err := t.hashRequestBody(r)
+	if err != nil {
+		return err
+	}
+
+	methodMatch := subtle.ConstantTimeCompare([]byte(method), []byte(r.Method))
+	pathMatch := subtle.ConstantTimeCompare([]byte(path), []byte(r.URL.Path))
+	if methodMatch&pathMatch != 1 {
+		return ErrRequestMismatch
+	}
+
+	if subtle.ConstantTimeCompare([]byte(bodyHash), []byte(base64.RawURLEncoding.EncodeToString(requestHash))) != 1 {
+		return ErrBodyHashMismatch
+	}
+
+	used, err := t.tokenStore.Consume(context.Background(), jti, time.Unix(exp, 0))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if used {
+		return ErrRequestTokenReplayed
+	}
+
+	return nil
+}
+
+// hashRequestBody returns the SHA-256 of the request body, teeing it into a buffer that replaces r.Body.
+// Bodies over the limit fail with ErrRequestBodyTooLarge, up front when their length is declared.
+func (t *TokenConfig) hashRequestBody(r *http.Request) ([]byte, error) {
+	limit := t.requestBodyLimit
+	if limit == 0 {
+		limit = defaultRequestBodyLimit
+	}
+
+	if r.ContentLength > limit {
+		return nil, ErrRequestBodyTooLarge
+	}
+
+	hash := sha256.New()
+	if r.Body == nil || r.Body == http.NoBody {
+		return hash.Sum(nil), nil
+	}
+
+	var body bytes.Buffer
+	_, err := io.Copy(&body, io.TeeReader(io.LimitReader(r.Body, limit+1), hash))
+	r.Body.Close()
+	r.Body = io.NopCloser(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(body.Len()) > limit {
+		return nil, ErrRequestBodyTooLarge
+	}
+
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,123 @@
+package hydrate
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupRequestTokens(t *testing.T, options ...func(*TokenConfig) error) *TokenConfig {
+	config, err := NewToken(append(options,
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}),
+	)...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func signRequest(t *testing.T, config *TokenConfig, method, path, body string) string {
+	hash := sha256.Sum256([]byte(body))
+	token, err := config.GenerateRequestToken(method, path, hash[:], 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error generating request token: %v", err)
+	}
+
+	return string(token)
+}
+
+func TestRequestToken(t *testing.T) {
+	config := setupRequestTokens(t, WithTokenStore(NewMemoryTokenStore()))
+	body := `{"event":"delivered"}`
+	token := signRequest(t, config, http.MethodPost, "/webhooks/orders", body)
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/orders", strings.NewReader(body))
+	if err := config.VerifyRequestToken(request, token); err != nil {
+		t.Fatalf("Unexpected error verifying request token: %v", err)
+	}
+
+	if restored, _ := io.ReadAll(request.Body); string(restored) != body {
+		t.Errorf("Expected the body to be restored for the handler, got %q", restored)
+	}
+
+	replayed := httptest.NewRequest(http.MethodPost, "/webhooks/orders", strings.NewReader(body))
+	if err := config.VerifyRequestToken(replayed, token); err != ErrRequestTokenReplayed {
+		t.Errorf("Expected error: %v, got: %v", ErrRequestTokenReplayed, err)
+	}
+}
+
+func TestRequestTokenMismatch(t *testing.T) {
+	config := setupRequestTokens(t, WithTokenStore(NewMemoryTokenStore()), WithRequestBodyLimit(64))
+	body := `{"event":"delivered"}`
+
+	expiredHash := sha256.Sum256([]byte(body))
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		requestMethodClaim:   http.MethodPost,
+		requestPathClaim:     "/webhooks/orders",
+		requestBodyHashClaim: base64.RawURLEncoding.EncodeToString(expiredHash[:]),
+		"jti":                "expired",
+		"exp":                time.Now().Add(-1 * time.Minute).Unix(),
+	}).SignedString(secretKey)
+
+	cases := []struct {
+		name   string
+		token  string
+		method string
+		target string
+		body   io.Reader
+		err    error
+	}{
+		{"other body", signRequest(t, config, http.MethodPost, "/webhooks/orders", body), http.MethodPost, "/webhooks/orders", strings.NewReader(`{"event":"refunded"}`), ErrBodyHashMismatch},
+		{"other method", signRequest(t, config, http.MethodPost, "/webhooks/orders", body), http.MethodPut, "/webhooks/orders", strings.NewReader(body), ErrRequestMismatch},
+		{"other path", signRequest(t, config, http.MethodPost, "/webhooks/orders", body), http.MethodPost, "/webhooks/refunds", strings.NewReader(body), ErrRequestMismatch},
+		{"expired token", expired, http.MethodPost, "/webhooks/orders", strings.NewReader(body), ErrTokenExpired},
+		{"declared large body", signRequest(t, config, http.MethodPost, "/webhooks/orders", body), http.MethodPost, "/webhooks/orders", strings.NewReader(strings.Repeat("a", 65)), ErrRequestBodyTooLarge},
+		{"streamed large body", signRequest(t, config, http.MethodPost, "/webhooks/orders", body), http.MethodPost, "/webhooks/orders", io.MultiReader(strings.NewReader(strings.Repeat("a", 65))), ErrRequestBodyTooLarge},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest(c.method, c.target, c.body)
+		if err := config.VerifyRequestToken(request, c.token); err != c.err {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.err, err)
+		}
+	}
+
+	token := signRequest(t, config, http.MethodPost, "/webhooks/orders", body)
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/orders", strings.NewReader(body))
+	if err := config.VerifyRequestToken(request, token); err != nil {
+		t.Errorf("Expected a rejected request not to burn other tokens, got: %v", err)
+	}
+}
+
+func TestInvalidRequestToken(t *testing.T) {
+	config := setupRequestTokens(t)
+	hash := sha256.Sum256(nil)
+
+	if _, err := config.GenerateRequestToken(http.MethodPost, "/webhooks", hash[:16], time.Minute); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	token, err := config.GenerateRequestToken(http.MethodPost, "/webhooks", hash[:], time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error generating request token: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", nil)
+	if err := config.VerifyRequestToken(request, string(token)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v without a token store, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	if _, err := NewToken(SecretKey(secretKey), WithRequestBodyLimit(0)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
@@ -0,0 +1,242 @@
+package hydrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// RevocationStore records the IDs of tokens revoked before their expiration.
+// Implementations must be safe for concurrent use; entries only need to be kept until the token expires.
+type RevocationStore interface {
+	// Revoke denies the token ID until expiresAt.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked reports whether the token ID is denied.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// SubjectRevocationStore is a RevocationStore that can also revoke every token of a subject at once.
+// It records, per subject, a time before which issued tokens are invalid.
+type SubjectRevocationStore interface {
+	RevocationStore
+	// RevokeSubject invalidates the tokens of the subject issued at or before the time.
+	RevokeSubject(ctx context.Context, subject string, at time.Time) error
+	// SubjectRevokedAt returns the last revocation time of the subject, or the zero time if it was never revoked.
+	SubjectRevokedAt(ctx context.Context, subject string) (time.Time, error)
+}
+
+// WithRevocationStore optionally checks parsed tokens against the denylist in the store, failing revoked tokens with ErrTokenRevoked.
+// Tokens without a jti claim fail with ErrTokenRevoked too, unless WithOptionalTokenID is set.
+// Tokens generated by the configuration always carry jti and iat claims once a store is set.
+func WithRevocationStore(store RevocationStore) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if store == nil {
+			return fmt.Errorf("%w: revocation store cannot be nil", ErrInvalidTokenConfig)
+		}
+
+		t.revocationStore = store
+		return nil
+	}
+}
+
+// WithOptionalTokenID optionally accepts tokens without a jti claim when a revocation store is set.
+// Such tokens cannot be revoked and stay valid until they expire.
+func WithOptionalTokenID() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.optionalTokenID = true
+		return nil
+	}
+}
+
+// Revoke denies the token ID in the revocation store until the expiration time, typically the exp claim of the token.
+// Returns ErrInvalidTokenConfig if no revocation store is set, or ErrStoringToken if the store fails.
+func (t *TokenConfig) Revoke(jti string, exp time.Time) error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	if t.revocationStore == nil {
+		return ErrInvalidTokenConfig
+	}
+
+	if jti == "" {
+		return ErrClaimsInvalid
+	}
+
+	if err := t.revocationStore.Revoke(context.Background(), jti, exp); err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the token ID is denied by the revocation store.
+// Returns ErrInvalidTokenConfig if no revocation store is set, or ErrStoringToken if the store fails.
+func (t *TokenConfig) IsRevoked(jti string) (bool, error) {
+	if t.closed {
+		return false, ErrConfigClosed
+	}
+
+	if t.revocationStore == nil {
+		return false, ErrInvalidTokenConfig
+	}
+
+	revoked, err := t.revocationStore.IsRevoked(context.Background(), jti)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeAllForSubject invalidates every token of the subject issued so far, e.g. after a password change.
+// Tokens whose iat claim is at or before the revocation, in seconds, or missing, fail with ErrTokenRevoked.
+// Returns ErrInvalidTokenConfig unless the revocation store is a SubjectRevocationStore.
+func (t *TokenConfig) RevokeAllForSubject(subject string) error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	store, ok := t.revocationStore.(SubjectRevocationStore)
+	if !ok {
+		return ErrInvalidTokenConfig
+	}
+
+	if subject == "" {
+		return ErrClaimsInvalid
+	}
+
+	if err := store.RevokeSubject(context.Background(), subject, t.now()); err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	return nil
+}
+
+// checkRevocation rejects claims whose jti is revoked or missing, or whose subject was revoked since they were issued,
+// if a revocation store is set.
+func (t *TokenConfig) checkRevocation(claims jwt.MapClaims) error {
+	if t.revocationStore == nil {
+		return nil
+	}
+
+	if err := t.checkSubjectRevocation(claims); err != nil {
+		return err
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		if t.optionalTokenID {
+			return nil
+		}
+		return ErrTokenRevoked
+	}
+
+	revoked, err := t.IsRevoked(jti)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// checkSubjectRevocation rejects claims issued at or before the last revocation of their subject, if any.
+func (t *TokenConfig) checkSubjectRevocation(claims jwt.MapClaims) error {
+	store, ok := t.revocationStore.(SubjectRevocationStore)
+	if !ok {
+		return nil
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil
+	}
+
+	revokedAt, err := store.SubjectRevokedAt(context.Background(), subject)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStoringToken, err)
+	}
+
+	if revokedAt.IsZero() {
+		return nil
+	}
+
+	if issuedAt, ok := numericClaim(claims["iat"]); !ok || issuedAt <= revokedAt.Unix() {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// MemoryRevocationStore is a RevocationStore kept in process memory.
+// It suits a single instance; deployments with several instances need a shared store.
+type MemoryRevocationStore struct {
+	revoked  map[string]time.Time // Expiration times by revoked token ID
+	subjects map[string]time.Time // Revocation times by subject
+	sweeper  expirySweeper        // Forgets the expired token IDs
+	now      func() time.Time     // Wall clock expiring the token IDs
+	lock     sync.Mutex           // Synchronize access to the revoked token IDs and subjects
+}
+
+// NewMemoryRevocationStore instantiates an empty MemoryRevocationStore.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked:  make(map[string]time.Time),
+		subjects: make(map[string]time.Time),
+		now:      wallNow,
+	}
+}
+
+// Revoke denies the token ID until expiresAt.
+// Token IDs past their expiration are forgotten at most once per sweepInterval.
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sweeper.sweep(s.now(), s.revoked)
+	s.revoked[jti] = expiresAt.Round(0)
+	return nil
+}
+
+// IsRevoked reports whether the token ID is denied and not yet expired.
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	expiresAt, ok := s.revoked[jti]
+	if !ok {
+		return false, nil
+	}
+
+	if expiresAt.Before(s.now()) {
+		delete(s.revoked, jti)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// RevokeSubject invalidates the tokens of the subject issued at or before the time.
+// Subject revocations are kept for the lifetime of the store.
+func (s *MemoryRevocationStore) RevokeSubject(ctx context.Context, subject string, at time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.subjects[subject] = at.Round(0)
+	return nil
+}
+
+// SubjectRevokedAt returns the last revocation time of the subject, or the zero time if it was never revoked.
+func (s *MemoryRevocationStore) SubjectRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.subjects[subject], nil
+}
@@ -0,0 +1,201 @@
+package hydrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestRevokeToken(t *testing.T) {
+	_, config, err := setupToken(t, WithRevocationStore(NewMemoryRevocationStore()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		t.Fatalf("Expected a jti claim, got %v", claims["jti"])
+	}
+
+	exp, _ := numericClaim(claims["exp"])
+	if err := config.Revoke(jti, time.Unix(exp, 0)); err != nil {
+		t.Fatalf("Unexpected error revoking token: %v", err)
+	}
+
+	if revoked, err := config.IsRevoked(jti); !revoked || err != nil {
+		t.Errorf("Expected the token to be revoked, got %v, %v", revoked, err)
+	}
+
+	if err := config.Validate(); err != ErrTokenRevoked {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenRevoked, err)
+	}
+
+	if config.IsValid() {
+		t.Errorf("Expected IsValid to be false")
+	}
+
+	if _, err := config.ExtractClaims(); err != ErrTokenRevoked {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenRevoked, err)
+	}
+
+	snapshot, err := config.Snapshot(claims, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error taking snapshot: %v", err)
+	}
+
+	if _, err := config.VerifySnapshot(snapshot); err != ErrTokenRevoked {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenRevoked, err)
+	}
+}
+
+func TestUnrevokedToken(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	_, config, err := setupToken(t, WithRevocationStore(store))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := config.Revoke("another-token", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error revoking token: %v", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRevocationRequiresTokenID(t *testing.T) {
+	token := signTestClaims(t, jwt.MapClaims{"sub": "user"})
+
+	config := newExpectingVerifier(t, token, WithRevocationStore(NewMemoryRevocationStore()))
+	if err := config.Validate(); err != ErrTokenRevoked {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenRevoked, err)
+	}
+
+	config = newExpectingVerifier(t, token, WithRevocationStore(NewMemoryRevocationStore()), WithOptionalTokenID())
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRevocationExpires(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	if err := store.Revoke(ctx, "expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := store.Revoke(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, "expired"); revoked {
+		t.Errorf("Expected the expired entry to leave the denylist")
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, "live"); !revoked {
+		t.Errorf("Expected the live entry to stay in the denylist")
+	}
+
+	if _, ok := store.revoked["expired"]; ok {
+		t.Errorf("Expected the expired entry to be forgotten")
+	}
+}
+
+type failingRevocationStore struct{}
+
+func (failingRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	return errStoreDown
+}
+
+func (failingRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, errStoreDown
+}
+
+func TestRevocationStoreFailure(t *testing.T) {
+	_, config, err := setupToken(t, WithRevocationStore(failingRevocationStore{}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := config.Validate(); !errors.Is(err, ErrStoringToken) || !errors.Is(err, errStoreDown) {
+		t.Errorf("Expected error: %v, got: %v", ErrStoringToken, err)
+	}
+
+	if err := config.Revoke("jti", time.Now()); !errors.Is(err, ErrStoringToken) {
+		t.Errorf("Expected error: %v, got: %v", ErrStoringToken, err)
+	}
+
+	if _, config, _ = setupToken(t); !errors.Is(config.Revoke("jti", time.Now()), ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v without a revocation store", ErrInvalidTokenConfig)
+	}
+}
+
+func TestRevokeAllForSubject(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	store := NewMemoryRevocationStore()
+
+	issue := func(subject string) (*TokenConfig, string) {
+		config, err := NewToken(
+			SecretKey(secretKey),
+			WithClock(clock),
+			WithRevocationStore(store),
+			WithStandardClaims(jwt.StandardClaims{
+				Subject:   subject,
+				ExpiresAt: clock.Now().Add(1 * time.Hour).Unix(),
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Fatalf("Unexpected error generating token: %v", err)
+		}
+
+		return config, string(token)
+	}
+
+	config, _ := issue("alice")
+	var aliceTokens []string
+	for i := 0; i < 3; i++ {
+		_, token := issue("alice")
+		aliceTokens = append(aliceTokens, token)
+		clock.Advance(1 * time.Second)
+	}
+	_, bobToken := issue("bob")
+
+	if err := config.RevokeAllForSubject("alice"); err != nil {
+		t.Fatalf("Unexpected error revoking subject: %v", err)
+	}
+
+	for _, token := range aliceTokens {
+		if err := config.ValidateString(token); err != ErrTokenRevoked {
+			t.Errorf("Expected error: %v, got: %v", ErrTokenRevoked, err)
+		}
+	}
+
+	if err := config.ValidateString(bobToken); err != nil {
+		t.Errorf("Unexpected error for another subject: %v", err)
+	}
+
+	clock.Advance(1 * time.Second)
+
+	if _, token := issue("alice"); config.ValidateString(token) != nil {
+		t.Errorf("Expected tokens issued after the revocation to be valid")
+	}
+
+	if _, withoutSubjects, _ := setupToken(t, WithRevocationStore(failingRevocationStore{})); !errors.Is(withoutSubjects.RevokeAllForSubject("alice"), ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v for a store without subject revocation", ErrInvalidTokenConfig)
+	}
+}
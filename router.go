@@ -0,0 +1,95 @@
+package hydrate
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// IssuerRouter verifies tokens with the verifier registered for their issuer.
+// The iss claim is only read to pick a verifier; the token is always fully verified by it.
+type IssuerRouter struct {
+	verifiers map[string]*TokenConfig // Verifiers by issuer
+	issuers   []string                // Issuers in the order tried when a token has no issuer
+
+	routed   atomic.Uint64 // Tokens routed by their issuer
+	fallback atomic.Uint64 // Tokens without an issuer tried against every verifier
+	unknown  atomic.Uint64 // Tokens rejected for an unregistered issuer
+}
+
+// RouteStats counts the routing decisions made by an IssuerRouter.
+type RouteStats struct {
+	Routed   uint64 // Tokens routed by their issuer
+	Fallback uint64 // Tokens without an issuer tried against every verifier
+	Unknown  uint64 // Tokens rejected for an unregistered issuer
+}
+
+// NewIssuerRouter instantiates an IssuerRouter from the verifiers indexed by the issuer they accept.
+// Local and remote verifiers can be mixed, e.g. a SecretKey configuration and a WithJWKSURL verifier.
+func NewIssuerRouter(verifiers map[string]*TokenConfig) (*IssuerRouter, error) {
+	if len(verifiers) == 0 {
+		return nil, ErrInvalidTokenConfig
+	}
+
+	router := &IssuerRouter{verifiers: make(map[string]*TokenConfig, len(verifiers))}
+	for issuer, verifier := range verifiers {
+		if issuer == "" {
+			return nil, ErrInvalidTokenConfig
+		}
+
+		if verifier == nil {
+			return nil, ErrTokenConfigNil
+		}
+
+		router.verifiers[issuer] = verifier
+		router.issuers = append(router.issuers, issuer)
+	}
+	sort.Strings(router.issuers)
+
+	return router, nil
+}
+
+// ParseTokenString verifies the token with the verifier registered for its issuer.
+// Tokens without an issuer are tried against every verifier; unregistered issuers fail with ErrUnknownIssuer.
+func (r *IssuerRouter) ParseTokenString(tokenString string) (*jwt.Token, error) {
+	claims := make(jwt.MapClaims)
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if issuer == "" {
+		r.fallback.Add(1)
+		return r.parseAny(tokenString)
+	}
+
+	verifier, ok := r.verifiers[issuer]
+	if !ok {
+		r.unknown.Add(1)
+		return nil, ErrUnknownIssuer
+	}
+
+	r.routed.Add(1)
+	return verifier.ParseTokenString(tokenString)
+}
+
+// Stats returns the routing decisions made so far.
+func (r *IssuerRouter) Stats() RouteStats {
+	return RouteStats{
+		Routed:   r.routed.Load(),
+		Fallback: r.fallback.Load(),
+		Unknown:  r.unknown.Load(),
+	}
+}
+
+// parseAny verifies the token with each verifier in turn, returning the first success.
+func (r *IssuerRouter) parseAny(tokenString string) (*jwt.Token, error) {
+	for _, issuer := range r.issuers {
+		if token, err := r.verifiers[issuer].ParseTokenString(tokenString); err == nil {
+			return token, nil
+		}
+	}
+
+	return nil, ErrTokenInvalid
+}
@@ -0,0 +1,118 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func issueToken(t *testing.T, issuer string, options ...func(*TokenConfig) error) string {
+	config, err := NewToken(append(options, WithStandardClaims(jwt.StandardClaims{
+		ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		Issuer:    issuer,
+	}))...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	return string(token)
+}
+
+func setupIssuerRouter(t *testing.T) *IssuerRouter {
+	local, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	partner, _ := newRemoteIssuer(t, "p1")
+	server := newJWKSServer(t, partner)
+
+	router, err := NewIssuerRouter(map[string]*TokenConfig{
+		"local": local,
+		"idp":   newRemoteVerifier(t, server.URL, time.Hour, realClock{}),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return router
+}
+
+func TestIssuerRouterRoutesByIssuer(t *testing.T) {
+	router := setupIssuerRouter(t)
+	_, partnerToken := newRemoteIssuer(t, "p1")
+
+	for _, token := range []string{issueToken(t, "local", SecretKey(secretKey)), partnerToken} {
+		if _, err := router.ParseTokenString(token); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if stats := router.Stats(); stats.Routed != 2 || stats.Fallback != 0 {
+		t.Errorf("Expected 2 routed tokens, got %+v", stats)
+	}
+}
+
+func TestIssuerRouterRejectsSpoofedIssuer(t *testing.T) {
+	router := setupIssuerRouter(t)
+
+	partnerSigned := issueToken(t, "local", WithRSAKeys(readFixture(t, "rsa_private.pem"), nil), WithActiveKeyID("p1"))
+	if _, err := router.ParseTokenString(partnerSigned); err != ErrUnexpectedSigningMethod {
+		t.Errorf("Expected error: %v, got: %v", ErrUnexpectedSigningMethod, err)
+	}
+
+	otherSecret := issueToken(t, "local", SecretKey(otherSecretKey))
+	if _, err := router.ParseTokenString(otherSecret); !errors.Is(err, ErrTokenInvalid) || !LikelyWrongKey(err) {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+
+	localSigned := issueToken(t, "idp", SecretKey(secretKey))
+	if _, err := router.ParseTokenString(localSigned); err != ErrUnexpectedSigningMethod {
+		t.Errorf("Expected error: %v, got: %v", ErrUnexpectedSigningMethod, err)
+	}
+}
+
+func TestIssuerRouterFallback(t *testing.T) {
+	router := setupIssuerRouter(t)
+
+	if _, err := router.ParseTokenString(issueToken(t, "", SecretKey(secretKey))); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := router.ParseTokenString(issueToken(t, "", SecretKey(otherSecretKey))); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+
+	if stats := router.Stats(); stats.Fallback != 2 || stats.Routed != 0 {
+		t.Errorf("Expected 2 fallback tokens, got %+v", stats)
+	}
+}
+
+func TestIssuerRouterUnknownIssuer(t *testing.T) {
+	router := setupIssuerRouter(t)
+
+	if _, err := router.ParseTokenString(issueToken(t, "stranger", SecretKey(secretKey))); err != ErrUnknownIssuer {
+		t.Errorf("Expected error: %v, got: %v", ErrUnknownIssuer, err)
+	}
+
+	if stats := router.Stats(); stats.Unknown != 1 {
+		t.Errorf("Expected 1 unknown issuer, got %+v", stats)
+	}
+}
+
+func TestInvalidIssuerRouter(t *testing.T) {
+	if _, err := NewIssuerRouter(nil); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+
+	if _, err := NewIssuerRouter(map[string]*TokenConfig{"local": nil}); err != ErrTokenConfigNil {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenConfigNil, err)
+	}
+}
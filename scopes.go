@@ -0,0 +1,57 @@
+package hydrate
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// HasScope reports whether the claims grant the scope.
+// Scopes are read from the space-delimited "scope" claim and from the "scp" array claim.
+// Missing or oddly typed claims grant no scope.
+func HasScope(claims jwt.MapClaims, scope string) bool {
+	if scope == "" {
+		return false
+	}
+
+	if scopes, ok := claims["scope"].(string); ok {
+		for _, granted := range strings.Fields(scopes) {
+			if granted == scope {
+				return true
+			}
+		}
+	}
+
+	return containsString(stringSlice(claims["scp"]), scope)
+}
+
+// HasRole reports whether the "roles" array claim contains the role.
+// A missing or oddly typed claim grants no role.
+func HasRole(claims jwt.MapClaims, role string) bool {
+	return role != "" && containsString(stringSlice(claims["roles"]), role)
+}
+
+// HasScope reports whether the token held by the configuration grants the scope.
+// Returns false if the token cannot be verified.
+func (t *TokenConfig) HasScope(scope string) bool {
+	claims, err := t.ExtractClaims()
+	return err == nil && HasScope(claims, scope)
+}
+
+// HasRole reports whether the token held by the configuration has the role.
+// Returns false if the token cannot be verified.
+func (t *TokenConfig) HasRole(role string) bool {
+	claims, err := t.ExtractClaims()
+	return err == nil && HasRole(claims, role)
+}
+
+// containsString reports whether the values contain the value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
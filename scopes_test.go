@@ -0,0 +1,82 @@
+package hydrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestHasScope(t *testing.T) {
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		scope    string
+		expected bool
+	}{
+		{"scope string", jwt.MapClaims{"scope": "read:users write:users"}, "write:users", true},
+		{"scope string prefix", jwt.MapClaims{"scope": "read:users"}, "read", false},
+		{"scp interface array", jwt.MapClaims{"scp": []interface{}{"read:users", 42}}, "read:users", true},
+		{"scp string array", jwt.MapClaims{"scp": []string{"read:users"}}, "write:users", false},
+		{"scope absent", jwt.MapClaims{}, "read:users", false},
+		{"scope oddly typed", jwt.MapClaims{"scope": 42, "scp": "read:users"}, "read:users", false},
+		{"empty scope", jwt.MapClaims{"scope": "read:users"}, "", false},
+	}
+
+	for _, c := range cases {
+		if HasScope(c.claims, c.scope) != c.expected {
+			t.Errorf("%s: Expected HasScope to be %v", c.name, c.expected)
+		}
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected bool
+	}{
+		{"roles array", jwt.MapClaims{"roles": []interface{}{"admin", "user"}}, true},
+		{"role missing", jwt.MapClaims{"roles": []interface{}{"user"}}, false},
+		{"roles absent", jwt.MapClaims{}, false},
+		{"roles oddly typed", jwt.MapClaims{"roles": "admin"}, false},
+	}
+
+	for _, c := range cases {
+		if HasRole(c.claims, "admin") != c.expected {
+			t.Errorf("%s: Expected HasRole to be %v", c.name, c.expected)
+		}
+	}
+}
+
+func TestTokenConfigScopesAndRoles(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithCustomClaims(map[string]interface{}{
+			"scope": "read:users",
+			"roles": []string{"admin"},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.HasScope("read:users") || config.HasRole("admin") {
+		t.Errorf("Expected no scope or role before a token is generated")
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	if !config.HasScope("read:users") || config.HasScope("write:users") {
+		t.Errorf("Expected only the read:users scope")
+	}
+
+	if !config.HasRole("admin") || config.HasRole("user") {
+		t.Errorf("Expected only the admin role")
+	}
+}
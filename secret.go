@@ -0,0 +1,132 @@
+package hydrate
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	m "github.com/garrettladley/mattress"
+	"github.com/golang-jwt/jwt"
+)
+
+// minSecretKeySize is the minimum size in bytes of a secret key, matching the output of SHA-256.
+const minSecretKeySize = 32
+
+// secretStore holds the secret key in memory.
+type secretStore interface {
+	Expose() []byte
+}
+
+// newSecureSecret stores the secret key in secure memory.
+// It is a variable so tests can simulate environments without secure memory.
+var newSecureSecret = func(key []byte) (secretStore, error) {
+	return m.NewSecret(key)
+}
+
+// plainSecret holds the secret key in ordinary memory when secure memory is unavailable.
+type plainSecret struct {
+	key  []byte       // Secret key
+	lock sync.RWMutex // Synchronize access to the key
+}
+
+// Expose returns a copy of the secret key.
+func (s *plainSecret) Expose() []byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]byte(nil), s.key...)
+}
+
+// zero overwrites the secret key so it no longer resides in memory.
+func (s *plainSecret) zero() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := range s.key {
+		s.key[i] = 0
+	}
+	s.key = nil
+}
+
+// WithInsecureSecretFallback optionally keeps the secret key in plain memory when secure memory is unavailable.
+// A warning is logged when the fallback is used, and the key is zeroed on Close.
+func WithInsecureSecretFallback() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.insecureSecret = true
+		return nil
+	}
+}
+
+// fallbackSecret handles a secret key that could not be stored in secure memory.
+// Returns the underlying error unless the insecure fallback is enabled.
+func (t *TokenConfig) fallbackSecret() error {
+	secretErr, key := t.secretErr, t.pendingSecret
+	t.secretErr, t.pendingSecret = nil, nil
+
+	if !t.insecureSecret {
+		return fmt.Errorf("%w: %w", ErrSecureMemoryFailed, secretErr)
+	}
+
+	log.Printf("hydrate: WARNING: secure memory unavailable (%v), keeping the secret key in plain memory", secretErr)
+	t.secretKey = &plainSecret{key: key}
+
+	return nil
+}
+
+// WithInsecureAllowWeakKeys optionally disables the minimum secret key length.
+// It is intended for tests only; short HMAC secrets can be brute-forced.
+func WithInsecureAllowWeakKeys() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.allowWeakKeys = true
+		return nil
+	}
+}
+
+// checkSecretStrength rejects secret keys too short for the signing method, as checkKeySize does.
+// It runs once all options are applied since the signing method can be set after the key.
+func (t *TokenConfig) checkSecretStrength() error {
+	if t.secretKey != nil {
+		if err := t.checkKeySize(t.secretKey.Expose()); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range t.keySet {
+		if err := t.checkKeySize(key.Expose()); err != nil {
+			return err
+		}
+	}
+
+	if t.keyFiles != nil && t.keyFiles.check != nil {
+		t.keyFiles.mu.RLock()
+		defer t.keyFiles.mu.RUnlock()
+
+		return t.keyFiles.check(t.keyFiles.current)
+	}
+
+	return nil
+}
+
+// checkKeySize applies checkKeySize with the signing method of the configuration and WithInsecureAllowWeakKeys.
+func (t *TokenConfig) checkKeySize(key []byte) error {
+	return checkKeySize(key, t.signingMethod, t.allowWeakKeys)
+}
+
+// checkKeySize returns ErrWeakSecretKey if the secret key is shorter than the output of the HMAC hash of the method,
+// and never shorter than 32 bytes, unless weak keys are allowed.
+func checkKeySize(key []byte, method jwt.SigningMethod, allowWeakKeys bool) error {
+	if allowWeakKeys {
+		return nil
+	}
+
+	minSize := minSecretKeySize
+	if method, ok := method.(*jwt.SigningMethodHMAC); ok && method.Hash.Size() > minSize {
+		minSize = method.Hash.Size()
+	}
+
+	if len(key) < minSize {
+		return ErrWeakSecretKey
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package hydrate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var errNoSecureMemory = errors.New("mlock: operation not permitted")
+
+// withoutSecureMemory simulates an environment where secure memory cannot be allocated.
+func withoutSecureMemory(t *testing.T) {
+	original := newSecureSecret
+	newSecureSecret = func(key []byte) (secretStore, error) {
+		return nil, errNoSecureMemory
+	}
+	t.Cleanup(func() {
+		newSecureSecret = original
+	})
+}
+
+func TestSecureMemoryFailure(t *testing.T) {
+	withoutSecureMemory(t)
+
+	_, err := NewToken(SecretKey(secretKey))
+
+	if !errors.Is(err, ErrSecureMemoryFailed) {
+		t.Errorf("Expected error: %v, got: %v", ErrSecureMemoryFailed, err)
+	}
+
+	if !errors.Is(err, errNoSecureMemory) {
+		t.Errorf("Expected underlying error: %v, got: %v", errNoSecureMemory, err)
+	}
+}
+
+func TestInsecureSecretFallback(t *testing.T) {
+	withoutSecureMemory(t)
+
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+		WithInsecureSecretFallback(),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+
+	if !config.IsValid() {
+		t.Errorf("Expected token to be valid")
+	}
+
+	secret, ok := config.secretKey.(*plainSecret)
+	if !ok {
+		t.Fatalf("Expected plain secret, got %T", config.secretKey)
+	}
+
+	if err := config.Close(); err != nil {
+		t.Errorf("Unexpected error closing config: %v", err)
+	}
+
+	if secret.key != nil {
+		t.Errorf("Expected secret key to be zeroed on close")
+	}
+}
+
+func TestPlainSecretZero(t *testing.T) {
+	key := []byte("secret")
+	secret := &plainSecret{key: key}
+
+	secret.zero()
+
+	for _, b := range key {
+		if b != 0 {
+			t.Errorf("Expected key bytes to be zeroed, got %v", key)
+			break
+		}
+	}
+}
+
+func TestWeakSecretKey(t *testing.T) {
+	hs512Key := []byte("hs512-secret-0123456789abcdefghijklmnopqrstuvwxyz-0123456789abcd")
+
+	cases := map[string][]func(*TokenConfig) error{
+		"short secret":           {SecretKey([]byte("secret"))},
+		"HS384 after the key":    {SecretKey(secretKey), WithSigningMethod(jwt.SigningMethodHS384)},
+		"HS512 before the key":   {WithSigningMethod(jwt.SigningMethodHS512), SecretKey(secretKey)},
+		"short key set entry":    {WithKeySet(map[string][]byte{"v1": keyV1, "v2": []byte("short")}), WithActiveKeyID("v1")},
+		"HS512 with 64 bytes ok": {SecretKey(hs512Key), WithSigningMethod(jwt.SigningMethodHS512)},
+	}
+
+	for name, options := range cases {
+		_, err := NewToken(options...)
+
+		expected := ErrWeakSecretKey
+		if name == "HS512 with 64 bytes ok" {
+			expected = nil
+		}
+
+		if err != expected {
+			t.Errorf("%s: Expected error: %v, got: %v", name, expected, err)
+		}
+	}
+}
+
+func TestInsecureAllowWeakKeys(t *testing.T) {
+	config, err := NewToken(
+		SecretKey([]byte("secret")),
+		WithInsecureAllowWeakKeys(),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+}
+
+func TestWeakKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret")
+	writeKeyFile(t, path, secretKey)
+
+	if _, err := NewToken(WithKeyFile(path), WithSigningMethod(jwt.SigningMethodHS512)); err != ErrWeakSecretKey {
+		t.Errorf("Expected error: %v for HS512, got: %v", ErrWeakSecretKey, err)
+	}
+
+	weakPath := filepath.Join(dir, "weak")
+	writeKeyFile(t, weakPath, []byte("secret"))
+
+	config, err := NewToken(WithKeyFile(weakPath), WithInsecureAllowWeakKeys())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config.Close()
+}
+
+func TestWeakProviderKey(t *testing.T) {
+	provider := &rotatingProvider{keys: map[string][]byte{"v1": []byte("secret")}, current: "v1"}
+
+	if _, err := newProviderToken(t, provider).GenerateToken(); err != ErrWeakSecretKey {
+		t.Errorf("Expected error: %v, got: %v", ErrWeakSecretKey, err)
+	}
+
+	allowed, err := NewToken(
+		WithSecretProvider(provider),
+		WithInsecureAllowWeakKeys(),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := allowed.GenerateToken(); err != nil {
+		t.Errorf("Unexpected error generating token: %v", err)
+	}
+}
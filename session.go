@@ -0,0 +1,74 @@
+package hydrate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// authTimeClaim is the claim holding the time the session started, carried unchanged across refreshes.
+const authTimeClaim = "auth_time"
+
+// WithMaxSessionLifetime optionally caps how long refreshes can extend a session, counted from its "auth_time" claim.
+// RefreshToken and RotateTokenPair fail with ErrSessionExpired once the cap is reached or if the claim is missing,
+// and re-issued tokens never expire after the cap.
+func WithMaxSessionLifetime(lifetime time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if lifetime <= 0 {
+			return fmt.Errorf("%w: maximum session lifetime must be positive", ErrInvalidTokenConfig)
+		}
+
+		t.maxSessionLifetime = lifetime
+		return nil
+	}
+}
+
+// stampAuthTime makes the configuration stamp its tokens with the session start, unless it already does.
+func (t *TokenConfig) stampAuthTime(authTime int64) {
+	if t.authTime == 0 {
+		t.authTime = authTime
+	}
+}
+
+// updateAuthTime sets the "auth_time" claim of the token, unless it is already set.
+func (t *TokenConfig) updateAuthTime(claims jwt.MapClaims) {
+	if _, ok := claims[authTimeClaim]; !ok && t.authTime != 0 {
+		claims[authTimeClaim] = t.authTime
+	}
+}
+
+// sessionEnd returns the time the session of the claims ends, if a maximum session lifetime is set.
+func (t *TokenConfig) sessionEnd(claims jwt.MapClaims) (time.Time, bool) {
+	if t.maxSessionLifetime == 0 {
+		return time.Time{}, false
+	}
+
+	authTime, ok := numericClaim(claims[authTimeClaim])
+	if !ok {
+		return time.Time{}, true
+	}
+
+	return time.Unix(authTime, 0).Add(t.maxSessionLifetime), true
+}
+
+// checkSessionLifetime rejects claims whose session reached the maximum lifetime, if any.
+func (t *TokenConfig) checkSessionLifetime(claims jwt.MapClaims) error {
+	if end, ok := t.sessionEnd(claims); ok && !t.now().Before(end) {
+		return ErrSessionExpired
+	}
+
+	return nil
+}
+
+// capExpiration lowers the exp claim to the end of the session, if a maximum session lifetime is set.
+func (t *TokenConfig) capExpiration(claims jwt.MapClaims) {
+	end, ok := t.sessionEnd(claims)
+	if !ok || end.IsZero() {
+		return
+	}
+
+	if exp, ok := numericClaim(claims["exp"]); ok && exp > end.Unix() {
+		claims["exp"] = end.Unix()
+	}
+}
@@ -0,0 +1,74 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxSessionLifetimeCapsRotations(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	start := clock.Now().Unix()
+
+	accessConfig, refreshConfig, err := setupTokens(t, WithClock(clock), WithMaxSessionLifetime(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		clock.Advance(1 * time.Hour)
+
+		_, refreshToken, err := accessConfig.RotateTokenPair(refreshConfig)
+		if err != nil {
+			t.Fatalf("Rotation %d: Unexpected error: %v", i, err)
+		}
+
+		claims, err := refreshConfig.ExtractClaimsFrom(string(refreshToken))
+		if err != nil {
+			t.Fatalf("Rotation %d: Unexpected error extracting claims: %v", i, err)
+		}
+
+		if authTime, _ := numericClaim(claims[authTimeClaim]); authTime != start {
+			t.Errorf("Rotation %d: Expected auth_time %d to be kept, got %v", i, start, claims[authTimeClaim])
+		}
+
+		if exp, _ := numericClaim(claims["exp"]); exp != start+int64((3*time.Hour).Seconds()) {
+			t.Errorf("Rotation %d: Expected exp to be capped at the session end, got %d", i, exp)
+		}
+	}
+
+	clock.Advance(1 * time.Hour)
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != ErrSessionExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrSessionExpired, err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrSessionExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrSessionExpired, err)
+	}
+}
+
+func TestMaxSessionLifetimeRequiresAuthTime(t *testing.T) {
+	accessConfig, refreshConfig, err := setupTokens(t, WithMaxSessionLifetime(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, config := range []*TokenConfig{accessConfig, refreshConfig} {
+		if _, err := config.GenerateToken(); err != nil {
+			t.Fatalf("Unexpected error generating token: %v", err)
+		}
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrSessionExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrSessionExpired, err)
+	}
+
+	if _, err := NewToken(SecretKey(secretKey), WithMaxSessionLifetime(0)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
@@ -0,0 +1,173 @@
+package hydrate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// signerMethod is a signing method that delegates signatures to a crypto.Signer, such as an HSM or KMS key.
+// Tokens it signs are standard JWTs verifiable with the base method and the signer's public key.
+type signerMethod struct {
+	base   jwt.SigningMethod // Signing method producing the signature format
+	signer crypto.Signer     // Signer holding the private key
+	rand   io.Reader         // Source of randomness passed to the signer, the configuration's
+}
+
+// prewarmSigningInput is signed and discarded to warm up a signer during eager initialization.
+const prewarmSigningInput = "hydrate.prewarm"
+
+// Prewarmer is optionally implemented by a crypto.Signer backed by a KMS or a remote service.
+// Prewarm establishes connections and checks credentials before the first token is signed.
+type Prewarmer interface {
+	Prewarm(ctx context.Context) error
+}
+
+// WithEagerInit optionally prewarms the signer set with WithSigner when the configuration is created.
+// NewToken calls Prewarm if the signer implements Prewarmer, then signs and discards a test string,
+// so cold starts and credential problems surface at startup instead of on the first token.
+func WithEagerInit() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.eagerInit = true
+		return nil
+	}
+}
+
+// WithSigner signs tokens with the crypto.Signer so the private key never leaves it, and verifies them with its public key.
+// The signing method must match the signer's key: RSA for RS and PS methods, the matching curve for ES methods, or Ed25519 for EdDSA.
+func WithSigner(signer crypto.Signer, method jwt.SigningMethod) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if signer == nil {
+			return ErrInvalidKey
+		}
+
+		if method == nil {
+			return ErrSigningMethodNil
+		}
+
+		if !signerMatchesMethod(signer.Public(), method) {
+			return ErrInvalidKey
+		}
+
+		t.setKeyPair(signer, signer.Public(), &signerMethod{base: method, signer: signer, rand: rand.Reader})
+		return nil
+	}
+}
+
+// signerMatchesMethod reports whether tokens signed with the method can be verified with the public key.
+func signerMatchesMethod(publicKey crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch method := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := publicKey.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		return ok && key.Curve.Params().BitSize == method.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := publicKey.(ed25519.PublicKey)
+		return ok
+	default:
+		return false
+	}
+}
+
+// Alg returns the algorithm of the base signing method.
+func (m *signerMethod) Alg() string {
+	return m.base.Alg()
+}
+
+// Verify verifies the signature with the base signing method.
+func (m *signerMethod) Verify(signingString, signature string, key interface{}) error {
+	return m.base.Verify(signingString, signature, key)
+}
+
+// Sign builds the digest of the signing string and delegates the signature to the signer.
+// The key argument is ignored; ECDSA signatures are converted from ASN.1 to the fixed-size JWS format.
+func (m *signerMethod) Sign(signingString string, key interface{}) (string, error) {
+	message := []byte(signingString)
+
+	var opts crypto.SignerOpts
+	switch method := m.base.(type) {
+	case *jwt.SigningMethodRSA:
+		opts = method.Hash
+	case *jwt.SigningMethodRSAPSS:
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: method.Hash}
+	case *jwt.SigningMethodECDSA:
+		opts = method.Hash
+	case *jwt.SigningMethodEd25519:
+		opts = crypto.Hash(0)
+	default:
+		return "", ErrInvalidKey
+	}
+
+	digest := message
+	if hash := opts.HashFunc(); hash != 0 {
+		hasher := hash.New()
+		hasher.Write(message)
+		digest = hasher.Sum(nil)
+	}
+
+	signature, err := m.signer.Sign(m.rand, digest, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if method, ok := m.base.(*jwt.SigningMethodECDSA); ok {
+		if signature, err = ecdsaSignatureToJWS(signature, method.CurveBits); err != nil {
+			return "", err
+		}
+	}
+
+	return jwt.EncodeSegment(signature), nil
+}
+
+// ecdsaSignatureToJWS converts an ASN.1 encoded ECDSA signature to the concatenated r and s used by JWS.
+func ecdsaSignatureToJWS(signature []byte, curveBits int) ([]byte, error) {
+	var parsed struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(signature, &parsed); err != nil || len(rest) != 0 {
+		return nil, ErrSigningToken
+	}
+
+	size := (curveBits + 7) / 8
+	if parsed.R.BitLen() > 8*size || parsed.S.BitLen() > 8*size {
+		return nil, ErrSigningToken
+	}
+
+	out := make([]byte, 2*size)
+	parsed.R.FillBytes(out[:size])
+	parsed.S.FillBytes(out[size:])
+
+	return out, nil
+}
+
+// prewarm warms up the signer and checks it can sign, wrapping any failure in ErrSignerUnavailable.
+// Configurations without a signer are left untouched.
+func (t *TokenConfig) prewarm(ctx context.Context) error {
+	method, ok := t.signingMethod.(*signerMethod)
+	if !ok {
+		return nil
+	}
+
+	if prewarmer, ok := method.signer.(Prewarmer); ok {
+		if err := prewarmer.Prewarm(ctx); err != nil {
+			return fmt.Errorf("%w: %w", ErrSignerUnavailable, err)
+		}
+	}
+
+	if _, err := method.Sign(prewarmSigningInput, nil); err != nil {
+		return fmt.Errorf("%w: %w", ErrSignerUnavailable, err)
+	}
+
+	return nil
+}
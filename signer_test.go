@@ -0,0 +1,204 @@
+package hydrate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// hsmSigner hides the private key behind the crypto.Signer interface, like an HSM or KMS client.
+type hsmSigner struct {
+	signer crypto.Signer
+	signs  int
+	rand   io.Reader
+}
+
+func (s *hsmSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *hsmSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.signs++
+	s.rand = rand
+	return s.signer.Sign(rand, digest, opts)
+}
+
+func fixtureSigner(t *testing.T, fixture string) crypto.Signer {
+	data := readFixture(t, fixture)
+
+	var (
+		key interface{}
+		err error
+	)
+	switch fixture {
+	case "rsa_private.pem":
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+	case "ed25519_private.pem":
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	default:
+		key, err = jwt.ParseECPrivateKeyFromPEM(data)
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %s: %v", fixture, err)
+	}
+
+	if edKey, ok := key.(ed25519.PrivateKey); ok {
+		return edKey
+	}
+	return key.(crypto.Signer)
+}
+
+func TestWithSigner(t *testing.T) {
+	cases := []struct {
+		fixture string
+		method  jwt.SigningMethod
+	}{
+		{"rsa_private.pem", jwt.SigningMethodRS256},
+		{"rsa_private.pem", jwt.SigningMethodPS384},
+		{"ec256_private.pem", jwt.SigningMethodES256},
+		{"ec384_private.pem", jwt.SigningMethodES384},
+		{"ec521_private.pem", jwt.SigningMethodES512},
+		{"ed25519_private.pem", jwt.SigningMethodEdDSA},
+	}
+
+	for _, c := range cases {
+		signer := &hsmSigner{signer: fixtureSigner(t, c.fixture)}
+
+		config, err := NewToken(
+			WithSigner(signer, c.method),
+			WithStandardClaims(jwt.StandardClaims{
+				ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error creating %s config: %v", c.method.Alg(), err)
+		}
+
+		token, err := config.GenerateToken()
+		if err != nil {
+			t.Fatalf("Unexpected error generating %s token: %v", c.method.Alg(), err)
+		}
+
+		if signer.signs != 1 {
+			t.Errorf("Expected the %s signature to be delegated to the signer", c.method.Alg())
+		}
+
+		parsed, err := jwt.Parse(string(token), func(*jwt.Token) (interface{}, error) {
+			return signer.Public(), nil
+		})
+		if err != nil || parsed.Method.Alg() != c.method.Alg() {
+			t.Errorf("Expected %s token to verify with jwt.Parse, got %v", c.method.Alg(), err)
+		}
+
+		if _, err := config.ParseToken(); err != nil {
+			t.Errorf("Unexpected error parsing %s token: %v", c.method.Alg(), err)
+		}
+	}
+}
+
+func TestWithSignerRandSource(t *testing.T) {
+	signer := &hsmSigner{signer: fixtureSigner(t, "rsa_private.pem")}
+	source := &sequenceReader{}
+	config, err := NewToken(
+		WithSigner(signer, jwt.SigningMethodRS256),
+		WithRandSource(source),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	if signer.rand != source {
+		t.Errorf("Expected the signer to get the configured random source")
+	}
+}
+
+func TestWithSignerMismatchedMethod(t *testing.T) {
+	signer := fixtureSigner(t, "rsa_private.pem")
+
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodES256, jwt.SigningMethodHS256, nil} {
+		if _, err := NewToken(WithSigner(signer, method)); !errors.Is(err, ErrInvalidTokenConfig) {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+		}
+	}
+
+	if _, err := NewToken(WithSigner(fixtureSigner(t, "ec256_private.pem"), jwt.SigningMethodES384)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+var errKMSCredentials = errors.New("kms: invalid credentials")
+
+// coldSigner is a fake KMS signer whose first signature pays a cold start.
+type coldSigner struct {
+	hsmSigner
+	coldStart  time.Duration
+	prewarmed  bool
+	prewarmErr error
+}
+
+func (s *coldSigner) Prewarm(ctx context.Context) error {
+	s.prewarmed = true
+	return s.prewarmErr
+}
+
+func (s *coldSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.signs == 0 {
+		time.Sleep(s.coldStart)
+	}
+	return s.hsmSigner.Sign(rand, digest, opts)
+}
+
+func TestEagerInitPrewarmsSigner(t *testing.T) {
+	signer := &coldSigner{hsmSigner: hsmSigner{signer: fixtureSigner(t, "ec256_private.pem")}, coldStart: 200 * time.Millisecond}
+
+	config, err := NewToken(
+		WithSigner(signer, jwt.SigningMethodES256),
+		WithEagerInit(),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !signer.prewarmed || signer.signs != 1 {
+		t.Errorf("Expected the signer to be prewarmed with a test signature")
+	}
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, err := config.GenerateToken(); err != nil {
+			t.Fatalf("Unexpected error generating token: %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed >= signer.coldStart {
+			t.Errorf("Expected issuance after prewarm to skip the cold start, took %v", elapsed)
+		}
+	}
+}
+
+func TestEagerInitSignerFailure(t *testing.T) {
+	signer := &coldSigner{hsmSigner: hsmSigner{signer: fixtureSigner(t, "ec256_private.pem")}, prewarmErr: errKMSCredentials}
+
+	_, err := NewToken(WithSigner(signer, jwt.SigningMethodES256), WithEagerInit())
+
+	if !errors.Is(err, ErrSignerUnavailable) || !errors.Is(err, errKMSCredentials) {
+		t.Errorf("Expected error: %v, got: %v", ErrSignerUnavailable, err)
+	}
+
+	if _, err := NewToken(WithSigner(signer, jwt.SigningMethodES256)); err != nil {
+		t.Errorf("Expected the signer not to be prewarmed without eager init, got: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package hydrate
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// SlidingWindow optionally extends the lifetime of tokens on activity.
+// ValidateAndSlide re-issues tokens with less than the window of their lifetime left.
+func SlidingWindow(window time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if window <= 0 {
+			return ErrInvalidTokenConfig
+		}
+
+		t.slidingWindow = window
+		return nil
+	}
+}
+
+// ValidateAndSlide validates the token string like ValidateString and returns it with its claims.
+// If a SlidingWindow is set and less than the window of the token's lifetime is left, the claims are re-issued
+// with a fresh exp and iat, and the new token is returned instead. The token held by the configuration is left untouched.
+func (t *TokenConfig) ValidateAndSlide(tokenString string) ([]byte, jwt.MapClaims, error) {
+	if t.closed {
+		return nil, nil, ErrConfigClosed
+	}
+
+	claims, err := t.validateClaims(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exp, ok := numericClaim(claims["exp"])
+	if t.slidingWindow == 0 || !ok || time.Unix(exp, 0).Sub(t.now()) >= t.slidingWindow {
+		return []byte(tokenString), claims, nil
+	}
+
+	if t.expiration <= 0 {
+		return nil, nil, ErrStandardClaimMissing
+	}
+
+	if !t.canSign() {
+		return nil, nil, ErrSigningNotConfigured
+	}
+
+	now := t.now()
+	claims["exp"] = now.Add(t.expiration).Unix()
+	claims["iat"] = now.Unix()
+	t.updateAudienceExpiry(claims)
+	t.capExpiration(claims)
+
+	signedToken, err := t.signClaims(claims, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []byte(signedToken), claims, nil
+}
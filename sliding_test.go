@@ -0,0 +1,108 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupSlidingToken(t *testing.T, clock *FakeClock) (*TokenConfig, string) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithClock(clock),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: clock.Now().Add(30 * time.Minute).Unix(),
+			Subject:   "user",
+		}),
+		SlidingWindow(10*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	return config, string(token)
+}
+
+func TestValidateAndSlidePassesThrough(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, token := setupSlidingToken(t, clock)
+
+	clock.Advance(15 * time.Minute)
+
+	slid, claims, err := config.ValidateAndSlide(token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(slid) != token {
+		t.Errorf("Expected the token to be returned unchanged")
+	}
+
+	if claims["sub"] != "user" {
+		t.Errorf("Expected the claims of the token, got %v", claims)
+	}
+}
+
+func TestValidateAndSlideReissues(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, token := setupSlidingToken(t, clock)
+
+	clock.Advance(25 * time.Minute)
+
+	slid, claims, err := config.ValidateAndSlide(token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(slid) == token {
+		t.Fatalf("Expected a re-issued token")
+	}
+
+	if exp, _ := numericClaim(claims["exp"]); exp != clock.Now().Add(30*time.Minute).Unix() {
+		t.Errorf("Expected exp to be extended by the token lifetime, got %d", exp)
+	}
+
+	if iat, _ := numericClaim(claims["iat"]); iat != clock.Now().Unix() {
+		t.Errorf("Expected iat to be now, got %d", iat)
+	}
+
+	if claims["sub"] != "user" {
+		t.Errorf("Expected the other claims to be kept, got %v", claims)
+	}
+
+	if *config.token != token {
+		t.Errorf("Expected the held token to be left untouched")
+	}
+
+	clock.Advance(20 * time.Minute)
+
+	if err := config.ValidateString(string(slid)); err != nil {
+		t.Errorf("Expected the re-issued token to outlive the original, got: %v", err)
+	}
+
+	if err := config.ValidateString(token); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestValidateAndSlideRejectsInvalidTokens(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, token := setupSlidingToken(t, clock)
+
+	clock.Advance(31 * time.Minute)
+
+	if _, _, err := config.ValidateAndSlide(token); err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+
+	if _, err := NewToken(SecretKey(secretKey), SlidingWindow(0)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
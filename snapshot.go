@@ -0,0 +1,113 @@
+package hydrate
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// snapshotInfo separates the snapshot key from other keys derived from the secret.
+const snapshotInfo = "hydrate claims snapshot v1"
+
+// claimsSnapshot is the payload of a claims snapshot.
+type claimsSnapshot struct {
+	Claims     jwt.MapClaims `json:"c"`           // Claims of the source token
+	JTI        string        `json:"j,omitempty"` // Identifier of the source token
+	Expiration int64         `json:"e,omitempty"` // Expiration time of the source token
+	Until      int64         `json:"u"`           // Expiration time of the snapshot
+}
+
+// Snapshot produces a MAC'd, compressed snapshot of the claims that expires after the provided duration.
+// The snapshot is bound to the jti and exp of the source token. Returns the snapshot, or an error if one occurs.
+func (t *TokenConfig) Snapshot(claims jwt.MapClaims, ttl time.Duration) ([]byte, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	key, err := t.deriveKey(snapshotInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := claimsSnapshot{
+		Claims: claims,
+		Until:  t.now().Add(ttl).Unix(),
+	}
+	snapshot.JTI, _ = claims["jti"].(string)
+	snapshot.Expiration, _ = numericClaim(claims["exp"])
+
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestSpeed)
+	if err != nil {
+		return nil, ErrClaimsInvalid
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return nil, ErrClaimsInvalid
+	}
+	if err := writer.Close(); err != nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(compressed.Bytes())
+
+	return append(mac.Sum(nil), compressed.Bytes()...), nil
+}
+
+// VerifySnapshot verifies a snapshot produced by Snapshot and returns the claims it holds.
+// Returns ErrTokenInvalid for tampered snapshots, ErrTokenExpired once the source token or the snapshot expired,
+// and ErrTokenRevoked once the source token is revoked, if a revocation store is set.
+func (t *TokenConfig) VerifySnapshot(blob []byte) (jwt.MapClaims, error) {
+	if t.closed {
+		return nil, ErrConfigClosed
+	}
+
+	key, err := t.deriveKey(snapshotInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blob) < sha256.Size {
+		return nil, ErrTokenInvalid
+	}
+
+	sum, compressed := blob[:sha256.Size], blob[sha256.Size:]
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(compressed)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil, ErrTokenInvalid
+	}
+
+	payload, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+
+	var snapshot claimsSnapshot
+	if err := json.Unmarshal(payload, &snapshot); err != nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	now := t.now().Unix()
+	if snapshot.Until < now || (snapshot.Expiration != 0 && snapshot.Expiration < now) {
+		return nil, ErrTokenExpired
+	}
+
+	if err := t.checkRevocation(snapshot.Claims); err != nil {
+		return nil, err
+	}
+
+	return snapshot.Claims, nil
+}
@@ -0,0 +1,111 @@
+package hydrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestValidSnapshot(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	blob, err := config.Snapshot(claims, time.Minute)
+	if err != nil {
+		t.Errorf("Unexpected error creating snapshot: %v", err)
+	}
+
+	snapshotClaims, err := config.VerifySnapshot(blob)
+	if err != nil {
+		t.Errorf("Unexpected error verifying snapshot: %v", err)
+	}
+
+	if !compareClaims(claims, snapshotClaims) {
+		t.Errorf("Expected snapshot claims %v, got %v", claims, snapshotClaims)
+	}
+}
+
+func TestTamperedSnapshot(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	blob, err := config.Snapshot(jwt.MapClaims{"sub": "user"}, time.Minute)
+	if err != nil {
+		t.Errorf("Unexpected error creating snapshot: %v", err)
+	}
+
+	blob[len(blob)-1] ^= 0xff
+
+	_, err = config.VerifySnapshot(blob)
+	if err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestSnapshotWrongSecret(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	other := setupDetached(t, otherSecretKey)
+
+	blob, err := config.Snapshot(jwt.MapClaims{"sub": "user"}, time.Minute)
+	if err != nil {
+		t.Errorf("Unexpected error creating snapshot: %v", err)
+	}
+
+	_, err = other.VerifySnapshot(blob)
+	if err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestExpiredSnapshot(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	blob, err := config.Snapshot(jwt.MapClaims{"sub": "user"}, -time.Minute)
+	if err != nil {
+		t.Errorf("Unexpected error creating snapshot: %v", err)
+	}
+
+	_, err = config.VerifySnapshot(blob)
+	if err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestSnapshotOfExpiredToken(t *testing.T) {
+	_, config, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"sub": "user",
+		"exp": float64(time.Now().Add(-time.Minute).Unix()),
+	}
+
+	blob, err := config.Snapshot(claims, time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error creating snapshot: %v", err)
+	}
+
+	_, err = config.VerifySnapshot(blob)
+	if err != ErrTokenExpired {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenExpired, err)
+	}
+}
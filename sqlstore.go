@@ -0,0 +1,251 @@
+package hydrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sqlTokenTable is the table holding the token IDs recorded by SQLTokenStore.
+const sqlTokenTable = "hydrate_tokens"
+
+// sqlSubjectTable is the table holding the subject revocations recorded by SQLTokenStore.
+const sqlSubjectTable = "hydrate_subject_revocations"
+
+// sqlPurgeBatchSize bounds the number of rows deleted by a single statement of PurgeExpired.
+const sqlPurgeBatchSize = 1000
+
+// SQLDialect selects the SQL flavor spoken by SQLTokenStore.
+type SQLDialect int
+
+const (
+	// DialectPostgres speaks PostgreSQL, with $n placeholders.
+	DialectPostgres SQLDialect = iota
+	// DialectMySQL speaks MySQL and MariaDB, with ? placeholders.
+	DialectMySQL
+	// DialectSQLite speaks SQLite 3.24 or later, with ? placeholders.
+	DialectSQLite
+)
+
+// SQLTokenStore is a TokenStore and SubjectRevocationStore persisted in a database/sql database, shared by every
+// instance using it. Token IDs live in the hydrate_tokens table, created by Migrate:
+//
+//	jti        VARCHAR(64) PRIMARY KEY  -- token ID
+//	subject    VARCHAR(255)             -- sub claim of the token, if known
+//	expires_at BIGINT NOT NULL          -- Unix time after which the row can be purged
+//	revoked_at BIGINT                   -- Unix time the token was revoked, if it was
+//
+// Subject revocations live in the hydrate_subject_revocations table, also created by Migrate:
+//
+//	subject    VARCHAR(255) PRIMARY KEY -- revoked subject
+//	revoked_at BIGINT NOT NULL          -- Unix time of the last revocation
+//
+// Every lookup is a single primary key access, and PurgeExpired relies on an index on expires_at.
+type SQLTokenStore struct {
+	db      *sql.DB    // Database holding the table
+	dialect SQLDialect // SQL flavor of the database
+}
+
+// NewSQLTokenStore instantiates a SQLTokenStore over the database, which the caller keeps ownership of.
+// Returns ErrStoringToken if the database is nil or the dialect unknown.
+func NewSQLTokenStore(db *sql.DB, dialect SQLDialect) (*SQLTokenStore, error) {
+	if db == nil || dialect < DialectPostgres || dialect > DialectSQLite {
+		return nil, ErrStoringToken
+	}
+
+	return &SQLTokenStore{db: db, dialect: dialect}, nil
+}
+
+// Migrate creates the tables and the expiration index if they do not exist yet.
+func (s *SQLTokenStore) Migrate(ctx context.Context) error {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + sqlTokenTable + " (" +
+			"jti VARCHAR(64) NOT NULL PRIMARY KEY, " +
+			"subject VARCHAR(255), " +
+			"expires_at BIGINT NOT NULL, " +
+			"revoked_at BIGINT" +
+			s.inlineIndex() + ")",
+		"CREATE TABLE IF NOT EXISTS " + sqlSubjectTable + " (" +
+			"subject VARCHAR(255) NOT NULL PRIMARY KEY, " +
+			"revoked_at BIGINT NOT NULL)",
+	}
+
+	if s.dialect != DialectMySQL {
+		statements = append(statements,
+			"CREATE INDEX IF NOT EXISTS "+sqlTokenTable+"_expires_at ON "+sqlTokenTable+" (expires_at)")
+	}
+
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w: %w", ErrStoringToken, err)
+		}
+	}
+
+	return nil
+}
+
+// Consume marks the token ID as used until expiresAt, reporting whether it was already used.
+// The insert is a single statement ignoring duplicates, so concurrent instances cannot both consume a token ID.
+func (s *SQLTokenStore) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	result, err := s.db.ExecContext(ctx, s.insertIgnore(), jti, expiresAt.Unix())
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted == 0, nil
+}
+
+// Revoke denies the token ID until expiresAt, recording the revocation time.
+func (s *SQLTokenStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.upsertRevoked(), jti, expiresAt.Unix(), wallNow().Unix())
+	return err
+}
+
+// IsRevoked reports whether the token ID is denied, with a single primary key lookup.
+func (s *SQLTokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revokedAt sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, s.selectRevoked(), jti).Scan(&revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return revokedAt.Valid, nil
+}
+
+// RevokeSubject invalidates the tokens of the subject issued at or before the time.
+func (s *SQLTokenStore) RevokeSubject(ctx context.Context, subject string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.upsertSubject(), subject, at.Unix())
+	return err
+}
+
+// SubjectRevokedAt returns the last revocation time of the subject, with a single primary key lookup.
+// Returns the zero time if the subject was never revoked.
+func (s *SQLTokenStore) SubjectRevokedAt(ctx context.Context, subject string) (time.Time, error) {
+	var revokedAt int64
+
+	err := s.db.QueryRowContext(ctx, s.selectSubject(), subject).Scan(&revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(revokedAt, 0), nil
+}
+
+// PurgeExpired deletes the rows that expired before now, in batches to keep locks short.
+// Returns the number of deleted rows.
+func (s *SQLTokenStore) PurgeExpired(ctx context.Context) (int64, error) {
+	now := wallNow().Unix()
+	var purged int64
+
+	for {
+		result, err := s.db.ExecContext(ctx, s.deleteExpired(), now, sqlPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+
+		purged += deleted
+		if deleted < sqlPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// inlineIndex returns the index declared inside CREATE TABLE, for dialects without CREATE INDEX IF NOT EXISTS.
+func (s *SQLTokenStore) inlineIndex() string {
+	if s.dialect == DialectMySQL {
+		return ", INDEX " + sqlTokenTable + "_expires_at (expires_at)"
+	}
+
+	return ""
+}
+
+// insertIgnore returns the statement inserting a token ID and expiration unless the token ID exists.
+func (s *SQLTokenStore) insertIgnore() string {
+	switch s.dialect {
+	case DialectMySQL:
+		return "INSERT IGNORE INTO " + sqlTokenTable + " (jti, expires_at) VALUES (?, ?)"
+	case DialectSQLite:
+		return "INSERT OR IGNORE INTO " + sqlTokenTable + " (jti, expires_at) VALUES (?, ?)"
+	default:
+		return "INSERT INTO " + sqlTokenTable + " (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING"
+	}
+}
+
+// upsertRevoked returns the statement recording a revoked token ID, expiration, and revocation time.
+func (s *SQLTokenStore) upsertRevoked() string {
+	switch s.dialect {
+	case DialectMySQL:
+		return "INSERT INTO " + sqlTokenTable + " (jti, expires_at, revoked_at) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE revoked_at = VALUES(revoked_at)"
+	case DialectSQLite:
+		return "INSERT INTO " + sqlTokenTable + " (jti, expires_at, revoked_at) VALUES (?, ?, ?) " +
+			"ON CONFLICT (jti) DO UPDATE SET revoked_at = excluded.revoked_at"
+	default:
+		return "INSERT INTO " + sqlTokenTable + " (jti, expires_at, revoked_at) VALUES ($1, $2, $3) " +
+			"ON CONFLICT (jti) DO UPDATE SET revoked_at = EXCLUDED.revoked_at"
+	}
+}
+
+// selectRevoked returns the statement reading the revocation time of a token ID.
+func (s *SQLTokenStore) selectRevoked() string {
+	if s.dialect == DialectPostgres {
+		return "SELECT revoked_at FROM " + sqlTokenTable + " WHERE jti = $1"
+	}
+
+	return "SELECT revoked_at FROM " + sqlTokenTable + " WHERE jti = ?"
+}
+
+// upsertSubject returns the statement recording the revocation time of a subject.
+func (s *SQLTokenStore) upsertSubject() string {
+	switch s.dialect {
+	case DialectMySQL:
+		return "INSERT INTO " + sqlSubjectTable + " (subject, revoked_at) VALUES (?, ?) " +
+			"ON DUPLICATE KEY UPDATE revoked_at = VALUES(revoked_at)"
+	case DialectSQLite:
+		return "INSERT INTO " + sqlSubjectTable + " (subject, revoked_at) VALUES (?, ?) " +
+			"ON CONFLICT (subject) DO UPDATE SET revoked_at = excluded.revoked_at"
+	default:
+		return "INSERT INTO " + sqlSubjectTable + " (subject, revoked_at) VALUES ($1, $2) " +
+			"ON CONFLICT (subject) DO UPDATE SET revoked_at = EXCLUDED.revoked_at"
+	}
+}
+
+// selectSubject returns the statement reading the revocation time of a subject.
+func (s *SQLTokenStore) selectSubject() string {
+	if s.dialect == DialectPostgres {
+		return "SELECT revoked_at FROM " + sqlSubjectTable + " WHERE subject = $1"
+	}
+
+	return "SELECT revoked_at FROM " + sqlSubjectTable + " WHERE subject = ?"
+}
+
+// deleteExpired returns the statement deleting a batch of rows expired before a time.
+func (s *SQLTokenStore) deleteExpired() string {
+	switch s.dialect {
+	case DialectMySQL:
+		return "DELETE FROM " + sqlTokenTable + " WHERE expires_at < ? LIMIT ?"
+	case DialectSQLite:
+		return "DELETE FROM " + sqlTokenTable + " WHERE jti IN (SELECT jti FROM " + sqlTokenTable + " WHERE expires_at < ? LIMIT ?)"
+	default:
+		return "DELETE FROM " + sqlTokenTable + " WHERE jti IN (SELECT jti FROM " + sqlTokenTable + " WHERE expires_at < $1 LIMIT $2)"
+	}
+}
@@ -0,0 +1,269 @@
+package hydrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// fakeTokenTable emulates the hydrate_tokens table for the statements issued by SQLTokenStore.
+type fakeTokenTable struct {
+	rows       map[string]int64 // Expiration times by token ID
+	revoked    map[string]int64 // Revocation times by token ID
+	subjects   map[string]int64 // Revocation times by subject
+	statements []string         // Statements executed, in order
+	lock       sync.Mutex
+}
+
+func (f *fakeTokenTable) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeTokenTable) Driver() driver.Driver                        { return nil }
+func (f *fakeTokenTable) Prepare(query string) (driver.Stmt, error)    { return fakeStmt{f, query}, nil }
+func (f *fakeTokenTable) Close() error                                 { return nil }
+func (f *fakeTokenTable) Begin() (driver.Tx, error)                    { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	table *fakeTokenTable
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	f := s.table
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.statements = append(f.statements, s.query)
+
+	if strings.Contains(s.query, sqlSubjectTable) {
+		if at, ok := f.subjects[args[0].(string)]; ok {
+			return &fakeRows{values: []driver.Value{at}}, nil
+		}
+		return &fakeRows{}, nil
+	}
+
+	jti := args[0].(string)
+	if _, ok := f.rows[jti]; !ok {
+		return &fakeRows{}, nil
+	}
+
+	var revokedAt driver.Value
+	if at, ok := f.revoked[jti]; ok {
+		revokedAt = at
+	}
+
+	return &fakeRows{values: []driver.Value{revokedAt}}, nil
+}
+
+// fakeRows holds at most one row of a single column.
+type fakeRows struct {
+	values []driver.Value
+	read   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"revoked_at"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read || r.values == nil {
+		return io.EOF
+	}
+
+	r.read = true
+	copy(dest, r.values)
+	return nil
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	f := s.table
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.statements = append(f.statements, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT INTO "+sqlSubjectTable):
+		f.subjects[args[0].(string)] = args[1].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT") && len(args) == 3:
+		jti := args[0].(string)
+		if _, ok := f.rows[jti]; !ok {
+			f.rows[jti] = args[1].(int64)
+		}
+		f.revoked[jti] = args[2].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		jti := args[0].(string)
+		if _, ok := f.rows[jti]; ok {
+			return driver.RowsAffected(0), nil
+		}
+		f.rows[jti] = args[1].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		var deleted int64
+		for jti, exp := range f.rows {
+			if exp < args[0].(int64) && deleted < args[1].(int64) {
+				delete(f.rows, jti)
+				delete(f.revoked, jti)
+				deleted++
+			}
+		}
+		return driver.RowsAffected(deleted), nil
+	}
+
+	return nil, errors.New("unexpected statement: " + s.query)
+}
+
+func setupSQLTokenStore(t *testing.T, dialect SQLDialect) (*SQLTokenStore, *fakeTokenTable) {
+	table := &fakeTokenTable{rows: make(map[string]int64), revoked: make(map[string]int64), subjects: make(map[string]int64)}
+	db := sql.OpenDB(table)
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewSQLTokenStore(db, dialect)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatalf("Unexpected error migrating: %v", err)
+	}
+
+	return store, table
+}
+
+func TestSQLTokenStoreConsume(t *testing.T) {
+	store, _ := setupSQLTokenStore(t, DialectPostgres)
+	ctx := context.Background()
+
+	if used, err := store.Consume(ctx, "jti", time.Now().Add(time.Hour)); used || err != nil {
+		t.Errorf("Expected the first use not to be reported as reused, got %v, %v", used, err)
+	}
+
+	if used, err := store.Consume(ctx, "jti", time.Now().Add(time.Hour)); !used || err != nil {
+		t.Errorf("Expected the second use to be reported as reused, got %v, %v", used, err)
+	}
+}
+
+func TestSQLTokenStoreRejectsRotatedToken(t *testing.T) {
+	store, _ := setupSQLTokenStore(t, DialectSQLite)
+	accessConfig, refreshConfig := setupRotatingPair(t, store)
+	oldRefreshToken := *refreshConfig.token
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	replayed, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithTokenStore(store),
+		WithToken(oldRefreshToken),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(replayed); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+}
+
+func TestSQLTokenStorePurgeExpired(t *testing.T) {
+	store, table := setupSQLTokenStore(t, DialectMySQL)
+	ctx := context.Background()
+
+	for i := 0; i < sqlPurgeBatchSize+5; i++ {
+		table.rows[fmt.Sprintf("expired-%d", i)] = time.Now().Add(-time.Minute).Unix()
+	}
+	table.rows["live"] = time.Now().Add(time.Hour).Unix()
+
+	purged, err := store.PurgeExpired(ctx)
+	if err != nil || purged != sqlPurgeBatchSize+5 {
+		t.Errorf("Expected %d purged rows, got %d, %v", sqlPurgeBatchSize+5, purged, err)
+	}
+
+	if _, ok := table.rows["live"]; !ok || len(table.rows) != 1 {
+		t.Errorf("Expected only the live row to be kept, got %d rows", len(table.rows))
+	}
+}
+
+func TestSQLTokenStoreDialects(t *testing.T) {
+	cases := []struct {
+		dialect SQLDialect
+		insert  string
+		index   bool
+	}{
+		{DialectPostgres, "ON CONFLICT (jti) DO NOTHING", true},
+		{DialectMySQL, "INSERT IGNORE", false},
+		{DialectSQLite, "INSERT OR IGNORE", true},
+	}
+
+	for _, c := range cases {
+		store, table := setupSQLTokenStore(t, c.dialect)
+
+		if _, err := store.Consume(context.Background(), "jti", time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if last := table.statements[len(table.statements)-1]; !strings.Contains(last, c.insert) {
+			t.Errorf("Expected %q in %q", c.insert, last)
+		}
+
+		if index := strings.HasPrefix(table.statements[2], "CREATE INDEX"); index != c.index {
+			t.Errorf("Expected separate index creation to be %v for dialect %d", c.index, c.dialect)
+		}
+	}
+
+	if _, err := NewSQLTokenStore(nil, DialectPostgres); err != ErrStoringToken {
+		t.Errorf("Expected error: %v, got: %v", ErrStoringToken, err)
+	}
+}
+
+func TestSQLTokenStoreRevocation(t *testing.T) {
+	store, _ := setupSQLTokenStore(t, DialectPostgres)
+	ctx := context.Background()
+
+	if _, err := store.Consume(ctx, "consumed", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := store.Revoke(ctx, "revoked", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error revoking: %v", err)
+	}
+
+	for jti, expected := range map[string]bool{"revoked": true, "consumed": false, "unknown": false} {
+		if revoked, err := store.IsRevoked(ctx, jti); revoked != expected || err != nil {
+			t.Errorf("%s: Expected revoked to be %v, got %v, %v", jti, expected, revoked, err)
+		}
+	}
+}
+
+func TestSQLTokenStoreSubjectRevocation(t *testing.T) {
+	store, _ := setupSQLTokenStore(t, DialectSQLite)
+	ctx := context.Background()
+	at := time.Unix(time.Now().Unix(), 0)
+
+	if revokedAt, err := store.SubjectRevokedAt(ctx, "alice"); !revokedAt.IsZero() || err != nil {
+		t.Errorf("Expected no revocation, got %v, %v", revokedAt, err)
+	}
+
+	if err := store.RevokeSubject(ctx, "alice", at); err != nil {
+		t.Fatalf("Unexpected error revoking subject: %v", err)
+	}
+
+	if revokedAt, err := store.SubjectRevokedAt(ctx, "alice"); !revokedAt.Equal(at) || err != nil {
+		t.Errorf("Expected revocation at %v, got %v, %v", at, revokedAt, err)
+	}
+}
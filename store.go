@@ -0,0 +1,110 @@
+package hydrate
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// tokenIDSize is the number of random bytes in the jti claims set by the package.
+const tokenIDSize = 16
+
+// sweepInterval is how often the memory stores forget their expired entries.
+const sweepInterval = time.Minute
+
+// TokenStore records the IDs of used refresh tokens so a second use can be detected.
+// Implementations must be safe for concurrent use, and Consume must be atomic across instances sharing the store.
+type TokenStore interface {
+	// Consume marks the token ID as used until expiresAt, reporting whether it was already used.
+	Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+}
+
+// WithTokenStore optionally makes refresh tokens single use, recording them in the store when RefreshToken or
+// RotateTokenPair consumes them. Tokens generated by the configuration always carry a jti claim once a store is set.
+func WithTokenStore(store TokenStore) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if store == nil {
+			return fmt.Errorf("%w: token store cannot be nil", ErrInvalidTokenConfig)
+		}
+
+		t.tokenStore = store
+		return nil
+	}
+}
+
+// MemoryTokenStore is a TokenStore kept in process memory.
+// It suits a single instance; deployments with several instances need a shared store.
+type MemoryTokenStore struct {
+	used    map[string]time.Time // Expiration times by used token ID
+	sweeper expirySweeper        // Forgets the expired token IDs
+	now     func() time.Time     // Wall clock expiring the token IDs
+	lock    sync.Mutex           // Synchronize access to the used token IDs
+}
+
+// NewMemoryTokenStore instantiates an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{used: make(map[string]time.Time), now: wallNow}
+}
+
+// Consume marks the token ID as used until expiresAt, reporting whether it was already used.
+// Token IDs past their expiration are ignored, and forgotten at most once per sweepInterval.
+func (s *MemoryTokenStore) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.sweeper.sweep(now, s.used)
+
+	if unexpired(s.used, jti, now) {
+		return true, nil
+	}
+
+	s.used[jti] = expiresAt.Round(0)
+	return false, nil
+}
+
+// newTokenID returns a random token ID for the jti claim.
+func newTokenID(r io.Reader) (string, error) {
+	id := make([]byte, tokenIDSize)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return "", ErrInsecureRandomSource
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// expirySweeper amortizes forgetting expired entries, scanning the maps at most once per sweepInterval
+// instead of on every call. Lookups must still ignore the expired entries not forgotten yet.
+type expirySweeper struct {
+	next time.Time // Time from which the next sweep runs
+}
+
+// sweep forgets the expired entries of the maps if sweepInterval passed since the last sweep.
+func (s *expirySweeper) sweep(now time.Time, entries ...map[string]time.Time) {
+	if now.Before(s.next) {
+		return
+	}
+	s.next = now.Add(sweepInterval)
+
+	for _, e := range entries {
+		forgetExpired(e, now)
+	}
+}
+
+// forgetExpired deletes the entries whose expiration is before now.
+func forgetExpired(entries map[string]time.Time, now time.Time) {
+	for key, expiry := range entries {
+		if expiry.Before(now) {
+			delete(entries, key)
+		}
+	}
+}
+
+// unexpired reports whether the entries hold the key with an expiration not before now.
+func unexpired(entries map[string]time.Time, key string, now time.Time) bool {
+	expiry, ok := entries[key]
+	return ok && !expiry.Before(now)
+}
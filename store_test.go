@@ -0,0 +1,275 @@
+package hydrate
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupRotatingPair(t *testing.T, store TokenStore) (*TokenConfig, *TokenConfig) {
+	accessConfig, refreshConfig, _ := setupTokens(t, WithTokenStore(store))
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	return accessConfig, refreshConfig
+}
+
+func TestRotateTokenPair(t *testing.T) {
+	accessConfig, refreshConfig := setupRotatingPair(t, NewMemoryTokenStore())
+
+	before, err := refreshConfig.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+	oldRefreshToken := *refreshConfig.token
+
+	accessToken, refreshToken, err := accessConfig.RotateTokenPair(refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	if string(refreshToken) == oldRefreshToken {
+		t.Errorf("Expected a new refresh token")
+	}
+
+	after, err := refreshConfig.ExtractClaimsFrom(string(refreshToken))
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if after["jti"] == before["jti"] || after["jti"] == nil {
+		t.Errorf("Expected a new jti, got %v", after["jti"])
+	}
+
+	if after[tokenUseClaim] != TokenUseRefresh {
+		t.Errorf("Expected the refresh token use to be kept, got %v", after[tokenUseClaim])
+	}
+
+	if err := accessConfig.ValidateString(string(accessToken)); err != nil {
+		t.Errorf("Unexpected error validating access token: %v", err)
+	}
+}
+
+func TestRotateTokenPairDetectsReuse(t *testing.T) {
+	store := NewMemoryTokenStore()
+	accessConfig, refreshConfig := setupRotatingPair(t, store)
+	oldRefreshToken := *refreshConfig.token
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error rotating tokens: %v", err)
+	}
+
+	replayed, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithTokenStore(store),
+		WithToken(oldRefreshToken),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(replayed); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Errorf("Expected the rotated refresh token to be usable, got: %v", err)
+	}
+}
+
+var errStoreDown = errors.New("store is down")
+
+type failingTokenStore struct{}
+
+func (failingTokenStore) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	return false, errStoreDown
+}
+
+func TestRefreshTokenSingleUse(t *testing.T) {
+	accessConfig, refreshConfig := setupRotatingPair(t, NewMemoryTokenStore())
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != nil {
+		t.Fatalf("Unexpected error refreshing token: %v", err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != ErrRefreshTokenReused {
+		t.Errorf("Expected error: %v, got: %v", ErrRefreshTokenReused, err)
+	}
+}
+
+func TestRotateTokenPairStoreFailure(t *testing.T) {
+	accessConfig, refreshConfig := setupRotatingPair(t, failingTokenStore{})
+
+	_, _, err := accessConfig.RotateTokenPair(refreshConfig)
+	if !errors.Is(err, ErrStoringToken) || !errors.Is(err, errStoreDown) {
+		t.Errorf("Expected error: %v, got: %v", ErrStoringToken, err)
+	}
+}
+
+func TestRotateTokenPairWithoutStore(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t)
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+			t.Errorf("Unexpected error rotating tokens: %v", err)
+		}
+	}
+
+	if _, _, err := accessConfig.RotateTokenPair(nil); err != ErrTokenNotGenerated {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenNotGenerated, err)
+	}
+}
+
+func TestMemoryTokenStoreForgetsExpiredIDs(t *testing.T) {
+	wall := NewFakeClock(time.Now())
+	store := NewMemoryTokenStore()
+	store.now = wall.Now
+	ctx := context.Background()
+
+	if used, _ := store.Consume(ctx, "expired", wall.Now().Add(-1*time.Minute)); used {
+		t.Errorf("Expected the first use not to be reported as reused")
+	}
+
+	if used, _ := store.Consume(ctx, "live", wall.Now().Add(1*time.Hour)); used {
+		t.Errorf("Expected the first use not to be reported as reused")
+	}
+
+	if used, _ := store.Consume(ctx, "live", wall.Now().Add(1*time.Hour)); !used {
+		t.Errorf("Expected the second use to be reported as reused")
+	}
+
+	if used, _ := store.Consume(ctx, "expired", wall.Now().Add(-1*time.Minute)); used {
+		t.Errorf("Expected an expired token ID to be ignored before it is forgotten")
+	}
+
+	wall.Advance(sweepInterval)
+	store.Consume(ctx, "other", wall.Now().Add(1*time.Hour))
+
+	if _, ok := store.used["expired"]; ok {
+		t.Errorf("Expected the expired token ID to be forgotten")
+	}
+}
+
+func TestRefreshTokenWithoutExpiration(t *testing.T) {
+	accessConfig, _, _ := setupTokens(t)
+	if _, err := accessConfig.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	refreshConfig, err := NewToken(
+		SecretKey(secretKey),
+		WithTokenStore(NewMemoryTokenStore()),
+		WithToken(signWithKey(t, secretKey, jwt.MapClaims{"sub": "user", "jti": "id"})),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrClaimsInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrClaimsInvalid, err)
+	}
+}
+
+var errSignerUnavailable = errors.New("signer is unavailable")
+
+// flakySigner fails to sign while fail is set, like an HSM that is briefly unreachable.
+type flakySigner struct {
+	crypto.Signer
+	fail bool
+}
+
+func (s *flakySigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.fail {
+		return nil, errSignerUnavailable
+	}
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestRotateTokenPairSigningFailure(t *testing.T) {
+	signer := &flakySigner{Signer: fixtureSigner(t, "ec256_private.pem")}
+	accessConfig, err := NewToken(
+		WithSigner(signer, jwt.SigningMethodES256),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshConfig, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithTokenStore(NewMemoryTokenStore()),
+		WithReuseDetector(NewMemoryReuseDetector()),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+	oldRefreshToken := *refreshConfig.token
+
+	signer.fail = true
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != ErrSigningToken {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningToken, err)
+	}
+
+	if *refreshConfig.token != oldRefreshToken {
+		t.Errorf("Expected the refresh token to be kept after a signing failure")
+	}
+
+	signer.fail = false
+	if _, _, err := accessConfig.RotateTokenPair(refreshConfig); err != nil {
+		t.Errorf("Expected the retry to rotate the tokens, got: %v", err)
+	}
+}
+
+func TestRefreshTokenSigningFailure(t *testing.T) {
+	signer := &flakySigner{Signer: fixtureSigner(t, "ec256_private.pem")}
+	accessConfig, err := NewToken(
+		WithSigner(signer, jwt.SigningMethodES256),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(1 * time.Hour).Unix()}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshConfig, err := NewToken(
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}),
+		WithTokenStore(NewMemoryTokenStore()),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	signer.fail = true
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrSigningToken {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningToken, err)
+	}
+
+	signer.fail = false
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != nil {
+		t.Errorf("Expected the retry to refresh the token, got: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package hydrate
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// tokenUseClaim is the claim telling access tokens apart from refresh tokens.
+const tokenUseClaim = "token_use"
+
+// These are the values of the "token_use" claim stamped by GenerateTokenPair.
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
+// WithExpectedTokenUse optionally requires the "token_use" claim of parsed tokens to match the use.
+// Tokens stamped for another use fail with ErrWrongTokenType; tokens without the claim are accepted.
+func WithExpectedTokenUse(use string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if use != TokenUseAccess && use != TokenUseRefresh {
+			return fmt.Errorf("%w: token use must be access or refresh", ErrInvalidTokenConfig)
+		}
+
+		t.expectedTokenUse = use
+		return nil
+	}
+}
+
+// WithoutTokenUse optionally stops GenerateTokenPair from stamping the "token_use" claim.
+// It keeps tokens compatible with consumers that reject unknown claims.
+func WithoutTokenUse() func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		t.noTokenUse = true
+		return nil
+	}
+}
+
+// stampTokenUse makes the configuration stamp its tokens with the use, and expect it when parsing them.
+// It does nothing if the configuration was created with WithoutTokenUse.
+func (t *TokenConfig) stampTokenUse(use string) {
+	if t.noTokenUse {
+		return
+	}
+
+	t.tokenUse = use
+	if t.expectedTokenUse == "" {
+		t.expectedTokenUse = use
+	}
+}
+
+// updateTokenUse sets the "token_use" claim of the token, if the configuration stamps one.
+func (t *TokenConfig) updateTokenUse(claims jwt.MapClaims) {
+	if t.tokenUse != "" {
+		claims[tokenUseClaim] = t.tokenUse
+	}
+}
+
+// checkTokenUse rejects claims stamped for another use than the expected one, if any.
+func (t *TokenConfig) checkTokenUse(claims jwt.MapClaims) error {
+	if t.expectedTokenUse == "" {
+		return nil
+	}
+
+	if use, ok := claims[tokenUseClaim]; ok && use != t.expectedTokenUse {
+		return ErrWrongTokenType
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestTokenPairStampsTokenUse(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t)
+
+	accessToken, refreshToken, err := GenerateTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	cases := []struct {
+		token    []byte
+		expected string
+	}{
+		{accessToken, TokenUseAccess},
+		{refreshToken, TokenUseRefresh},
+	}
+
+	for _, c := range cases {
+		claims := make(jwt.MapClaims)
+		if _, _, err := new(jwt.Parser).ParseUnverified(string(c.token), claims); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if claims[tokenUseClaim] != c.expected {
+			t.Errorf("Expected token_use %s, got %v", c.expected, claims[tokenUseClaim])
+		}
+	}
+}
+
+func TestTokenUseCrossUseRejected(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t)
+
+	accessToken, refreshToken, err := GenerateTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		use      string
+		token    []byte
+		expected error
+	}{
+		{"access as access", TokenUseAccess, accessToken, nil},
+		{"refresh as refresh", TokenUseRefresh, refreshToken, nil},
+		{"refresh as access", TokenUseAccess, refreshToken, ErrWrongTokenType},
+		{"access as refresh", TokenUseRefresh, accessToken, ErrWrongTokenType},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, string(c.token), WithExpectedTokenUse(c.use))
+
+		if err := config.Validate(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if _, err := config.ExtractClaims(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestRefreshTokenRejectsAccessToken(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t)
+
+	if _, _, err := GenerateTokenPair(accessConfig, refreshConfig); err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != nil {
+		t.Errorf("Unexpected error refreshing token: %v", err)
+	}
+
+	refreshConfig.token = accessConfig.token
+
+	if _, err := accessConfig.RefreshToken(refreshConfig); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestWithoutTokenUse(t *testing.T) {
+	accessConfig, refreshConfig, _ := setupTokens(t, WithoutTokenUse())
+
+	accessToken, _, err := GenerateTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating token pair: %v", err)
+	}
+
+	claims := make(jwt.MapClaims)
+	if _, _, err := new(jwt.Parser).ParseUnverified(string(accessToken), claims); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := claims[tokenUseClaim]; ok {
+		t.Errorf("Expected no token_use claim, got %v", claims[tokenUseClaim])
+	}
+
+	config := newExpectingVerifier(t, string(accessToken), WithExpectedTokenUse(TokenUseRefresh))
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected unstamped tokens to be accepted, got: %v", err)
+	}
+}
+
+func TestInvalidExpectedTokenUse(t *testing.T) {
+	if _, err := NewVerifier(SecretKey(secretKey), WithExpectedTokenUse("id")); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
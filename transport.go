@@ -0,0 +1,137 @@
+package hydrate
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Transport is an http.RoundTripper that authenticates outbound requests with the access token of a token pair.
+// When a response is 401 Unauthorized, the access token is refreshed with the refresh token and the request
+// retried once. It is safe for concurrent use.
+type Transport struct {
+	base           http.RoundTripper // Transport sending the requests
+	accessConfig   *TokenConfig      // Configuration holding the access token
+	refreshConfig  *TokenConfig      // Configuration holding the refresh token
+	retryAnyMethod bool              // Whether non-idempotent requests are retried too
+	lock           sync.Mutex        // Guards the access token across requests and refreshes
+}
+
+// NewTransport instantiates a Transport sending requests with the base transport, or http.DefaultTransport if nil.
+// The access token is refreshed with RefreshToken, so the access configuration must be able to sign.
+func NewTransport(base http.RoundTripper, accessConfig, refreshConfig *TokenConfig, options ...func(*Transport) error) (*Transport, error) {
+	if accessConfig == nil || refreshConfig == nil {
+		return nil, ErrTokenConfigNil
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport := &Transport{base: base, accessConfig: accessConfig, refreshConfig: refreshConfig}
+	for _, option := range options {
+		if err := option(transport); err != nil {
+			return nil, err
+		}
+	}
+
+	return transport, nil
+}
+
+// WithRetryNonIdempotent optionally retries requests of any method after a refresh, not only idempotent ones.
+// Only use it if the server does not act on requests it rejects with 401 Unauthorized.
+func WithRetryNonIdempotent() func(*Transport) error {
+	return func(t *Transport) error {
+		t.retryAnyMethod = true
+		return nil
+	}
+}
+
+// RoundTrip sends the request with the access token, refreshing it and retrying once on 401 Unauthorized.
+// Requests whose body cannot be replayed, and non-idempotent requests unless WithRetryNonIdempotent is set, are not retried.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, err := t.accessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.send(r, token)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || !t.canRetry(r) {
+		return response, err
+	}
+
+	refreshed, err := t.refresh(token)
+	if err != nil {
+		return response, nil
+	}
+
+	retry := r.Clone(r.Context())
+	if r.Body != nil && r.Body != http.NoBody {
+		if retry.Body, err = r.GetBody(); err != nil {
+			return response, nil
+		}
+	}
+
+	response.Body.Close()
+	return t.send(retry, refreshed)
+}
+
+// send sends a copy of the request authenticated with the token.
+func (t *Transport) send(r *http.Request, token string) (*http.Response, error) {
+	authenticated := r.Clone(r.Context())
+	authenticated.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(authenticated)
+}
+
+// canRetry reports whether the request can be sent again after a refresh.
+func (t *Transport) canRetry(r *http.Request) bool {
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return false
+	}
+
+	return t.retryAnyMethod || isIdempotent(r.Method)
+}
+
+// accessToken returns the current access token.
+func (t *Transport) accessToken() (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.accessConfig.closed {
+		return "", ErrConfigClosed
+	}
+
+	if t.accessConfig.token == nil {
+		return "", ErrTokenNotGenerated
+	}
+
+	return *t.accessConfig.token, nil
+}
+
+// refresh refreshes the access token rejected by the server, unless a concurrent request already replaced it.
+// Returns the new access token.
+func (t *Transport) refresh(rejected string) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.accessConfig.token != nil && *t.accessConfig.token != rejected {
+		return *t.accessConfig.token, nil
+	}
+
+	token, err := t.accessConfig.RefreshToken(t.refreshConfig)
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
+
+// isIdempotent reports whether requests of the method can be safely repeated, per RFC 9110.
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
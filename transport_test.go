@@ -0,0 +1,138 @@
+package hydrate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setupTransportServer starts a server rejecting the initial access token until it is refreshed.
+func setupTransportServer(t *testing.T, options ...func(*Transport) error) (*http.Client, *httptest.Server, *atomic.Int32, *[]string) {
+	clock := NewFakeClock(time.Now())
+	accessConfig, refreshConfig, err := setupTokens(t, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	accessToken, _, err := GenerateTokenPair(accessConfig, refreshConfig)
+	if err != nil {
+		t.Fatalf("Unexpected error generating tokens: %v", err)
+	}
+	clock.Advance(1 * time.Second)
+
+	var hits atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if r.Header.Get("Authorization") == "Bearer "+string(accessToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if err := accessConfig.ValidateString(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	transport, err := NewTransport(nil, accessConfig, refreshConfig, options...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return &http.Client{Transport: transport}, server, &hits, &bodies
+}
+
+func TestTransportRefreshesOnUnauthorized(t *testing.T) {
+	client, server, hits, _ := setupTransportServer(t)
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || hits.Load() != 2 {
+		t.Errorf("Expected a single retry to succeed, got %d after %d requests", response.StatusCode, hits.Load())
+	}
+
+	response, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || hits.Load() != 3 {
+		t.Errorf("Expected the refreshed token to be reused, got %d after %d requests", response.StatusCode, hits.Load())
+	}
+}
+
+func TestTransportNonIdempotentRequests(t *testing.T) {
+	client, server, hits, _ := setupTransportServer(t)
+
+	response, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusUnauthorized || hits.Load() != 1 {
+		t.Errorf("Expected POST not to be retried, got %d after %d requests", response.StatusCode, hits.Load())
+	}
+
+	client, server, hits, bodies := setupTransportServer(t, WithRetryNonIdempotent())
+
+	response, err = client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || hits.Load() != 2 {
+		t.Errorf("Expected POST to be retried, got %d after %d requests", response.StatusCode, hits.Load())
+	}
+
+	if len(*bodies) != 2 || (*bodies)[1] != "payload" {
+		t.Errorf("Expected the body to be replayed, got %q", *bodies)
+	}
+}
+
+func TestTransportRetriesOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, _, _, _ := setupTransportServer(t)
+	var hits atomic.Int32
+	client.Transport.(*Transport).base = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		hits.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusUnauthorized || hits.Load() != 2 {
+		t.Errorf("Expected a single retry, got %d after %d requests", response.StatusCode, hits.Load())
+	}
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,73 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// WithTypedClaims optionally sets the claims of the token from a struct implementing jwt.Claims, e.g. one embedding
+// jwt.StandardClaims. The struct is signed as is, so its json tags and MarshalJSON are honored; claims stamped by
+// other options, e.g. a jti for WithRevocationStore, are added to its JSON encoding instead.
+// The struct must pass its Valid method and encode a non-zero exp claim, unless WithExpiration is set. It replaces WithStandardClaims and
+// WithCustomClaims, which cannot be combined with it.
+func WithTypedClaims(claims jwt.Claims) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if claims == nil {
+			return ErrStandardClaimMissing
+		}
+
+		if value := reflect.ValueOf(claims); value.Kind() == reflect.Pointer && value.IsNil() {
+			return ErrStandardClaimMissing
+		}
+
+		if err := claims.Valid(); err != nil {
+			return fmt.Errorf("%w: typed claims fail their Valid method", ErrInvalidTokenConfig)
+		}
+
+		typedClaims, err := encodeClaims(claims)
+		if err != nil {
+			return err
+		}
+
+		exp, ok := numericClaim(typedClaims["exp"])
+		t.typedClaims = typedClaims
+		t.typedStruct = claims
+		t.missingExpiration = !ok || exp == 0
+		return nil
+	}
+}
+
+// ExtractInto extracts the claims from the token like ExtractClaims, then decodes them into dst, typically a pointer
+// to the struct passed to WithTypedClaims. The claims were verified with the configured clock, so the Valid method of
+// dst is not called again.
+func (t *TokenConfig) ExtractInto(dst jwt.Claims) error {
+	if dst == nil {
+		return ErrInvalidTokenConfig
+	}
+
+	claims, err := t.ExtractClaims()
+	if err != nil {
+		return err
+	}
+
+	return DecodeClaims(claims, dst)
+}
+
+// encodeClaims returns the claims as a jwt.MapClaims holding their JSON encoding.
+// Numbers decode as float64, as they do when a token is parsed.
+func encodeClaims(claims jwt.Claims) (jwt.MapClaims, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	var mapClaims jwt.MapClaims
+	if err := json.Unmarshal(data, &mapClaims); err != nil || mapClaims == nil {
+		return nil, ErrClaimsInvalid
+	}
+
+	return mapClaims, nil
+}
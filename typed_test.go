@@ -0,0 +1,126 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type testTypedClaims struct {
+	jwt.StandardClaims
+	UserID int64    `json:"user_id"`
+	Roles  []string `json:"roles"`
+	Tenant struct {
+		Name string `json:"name"`
+	} `json:"tenant"`
+}
+
+func newTestTypedClaims() *testTypedClaims {
+	claims := &testTypedClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   "alice",
+			Issuer:    "test",
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		},
+		UserID: 42,
+		Roles:  []string{"reader", "writer"},
+	}
+	claims.Tenant.Name = "acme"
+
+	return claims
+}
+
+func TestTypedClaims(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	config, err := NewToken(SecretKey(secretKey), WithClock(clock), WithTypedClaims(newTestTypedClaims()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	var claims testTypedClaims
+	if err := config.ExtractInto(&claims); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if claims.Subject != "alice" || claims.Issuer != "test" || claims.UserID != 42 || len(claims.Roles) != 2 || claims.Tenant.Name != "acme" {
+		t.Errorf("Expected the struct to round-trip, got %+v", claims)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error regenerating token: %v", err)
+	}
+
+	var regenerated testTypedClaims
+	if err := config.ExtractInto(&regenerated); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if regenerated.UserID != 42 || regenerated.ExpiresAt != claims.ExpiresAt+int64((10*time.Minute).Seconds()) {
+		t.Errorf("Expected the regenerated token to keep the struct with a fresh exp, got %+v", regenerated)
+	}
+
+	if err := config.ExtractInto(nil); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestTypedClaimsErrors(t *testing.T) {
+	withoutExp := newTestTypedClaims()
+	withoutExp.ExpiresAt = 0
+
+	expired := newTestTypedClaims()
+	expired.ExpiresAt = time.Now().Add(-1 * time.Hour).Unix()
+
+	cases := []struct {
+		name    string
+		options []func(*TokenConfig) error
+		err     error
+	}{
+		{"nil claims", []func(*TokenConfig) error{WithTypedClaims(nil)}, ErrInvalidTokenConfig},
+		{"nil pointer", []func(*TokenConfig) error{WithTypedClaims((*testTypedClaims)(nil))}, ErrInvalidTokenConfig},
+		{"missing exp", []func(*TokenConfig) error{WithTypedClaims(withoutExp)}, ErrStandardClaimMissing},
+		{"invalid claims", []func(*TokenConfig) error{WithTypedClaims(expired)}, ErrInvalidTokenConfig},
+		{"with custom claims", []func(*TokenConfig) error{WithTypedClaims(newTestTypedClaims()), WithCustomClaims(map[string]interface{}{"a": 1})}, ErrClaimConflict},
+	}
+
+	for _, c := range cases {
+		_, err := NewToken(append(c.options, SecretKey(secretKey))...)
+		if !errors.Is(err, ErrInvalidTokenConfig) || !errors.Is(err, c.err) {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestTypedClaimsSignedAsIs(t *testing.T) {
+	claims := newTestTypedClaims()
+	claims.UserID = 1<<62 + 1
+
+	config, err := NewToken(SecretKey(secretKey), WithTypedClaims(claims))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	payload, err := jwt.DecodeSegment(strings.Split(string(token), ".")[1])
+	if err != nil {
+		t.Fatalf("Unexpected error decoding payload: %v", err)
+	}
+
+	expected, _ := json.Marshal(claims)
+	if string(payload) != string(expected) {
+		t.Errorf("Expected the struct to be signed as is, got %s", payload)
+	}
+}
@@ -0,0 +1,270 @@
+package hydrate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// WithExpectedIssuer optionally requires the iss claim of parsed tokens to match the issuer.
+// Tokens with a missing or different issuer fail with ErrIssuerMismatch.
+func WithExpectedIssuer(issuer string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if issuer == "" {
+			return fmt.Errorf("%w: expected issuer cannot be empty", ErrInvalidTokenConfig)
+		}
+
+		t.expectedIssuer = issuer
+		return nil
+	}
+}
+
+// WithExpectedAudience optionally requires the aud claim of parsed tokens to contain the audience.
+// The aud claim may be a string or an array of strings; otherwise parsing fails with ErrAudienceMismatch.
+func WithExpectedAudience(audience string) func(*TokenConfig) error {
+	return WithExpectedAudiences([]string{audience}, MatchAny)
+}
+
+// AudienceMatch tells how the aud claim is matched against several expected audiences.
+type AudienceMatch int
+
+const (
+	// MatchAny accepts tokens whose aud claim contains at least one of the expected audiences.
+	MatchAny AudienceMatch = iota
+	// MatchAll accepts tokens whose aud claim contains every expected audience.
+	MatchAll
+)
+
+// WithExpectedAudiences optionally requires the aud claim of parsed tokens to contain any or all of the audiences.
+// Tokens failing the match fail with ErrAudienceMismatch.
+func WithExpectedAudiences(audiences []string, match AudienceMatch) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if len(audiences) == 0 || (match != MatchAny && match != MatchAll) {
+			return fmt.Errorf("%w: expected audiences must be non-empty with a known match", ErrInvalidTokenConfig)
+		}
+
+		for _, audience := range audiences {
+			if audience == "" {
+				return fmt.Errorf("%w: expected audiences must be non-empty with a known match", ErrInvalidTokenConfig)
+			}
+		}
+
+		t.expectedAudiences = append([]string(nil), audiences...)
+		t.audienceMatch = match
+		return nil
+	}
+}
+
+// WithMaxAge optionally rejects tokens issued longer ago than the maximum age, regardless of their exp claim.
+// Validate and IsValid measure the age from the iat claim; tokens without iat fail with ErrTokenTooOld.
+func WithMaxAge(maxAge time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("%w: maximum age must be positive", ErrInvalidTokenConfig)
+		}
+
+		t.maxAge = maxAge
+		return nil
+	}
+}
+
+// checkMaxAge rejects claims whose iat claim is missing or older than the maximum age, if any.
+func (t *TokenConfig) checkMaxAge(claims jwt.MapClaims) error {
+	if t.maxAge == 0 {
+		return nil
+	}
+
+	issuedAt, ok := numericClaim(claims["iat"])
+	if !ok || t.now().Sub(time.Unix(issuedAt, 0)) > t.maxAge {
+		return ErrTokenTooOld
+	}
+
+	return nil
+}
+
+// ClaimValidator checks application-specific rules on the claims of a verified token.
+type ClaimValidator func(claims jwt.MapClaims) error
+
+// WithClaimValidator optionally adds an application-specific check of the claims, run by Validate, IsValid, and ExtractClaims.
+// Validators run in the order they were added, after the signature and time checks pass, and stop at the first error.
+// The error is wrapped in ErrClaimsInvalid, so errors.Is matches both.
+func WithClaimValidator(validator ClaimValidator) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if validator == nil {
+			return fmt.Errorf("%w: claim validator cannot be nil", ErrInvalidTokenConfig)
+		}
+
+		t.claimValidators = append(t.claimValidators, validator)
+		return nil
+	}
+}
+
+// runClaimValidators runs the claim validators in order, returning the first error wrapped in ErrClaimsInvalid.
+func (t *TokenConfig) runClaimValidators(claims jwt.MapClaims) error {
+	for _, validator := range t.claimValidators {
+		if err := validator(claims); err != nil {
+			return fmt.Errorf("%w: %w", ErrClaimsInvalid, err)
+		}
+	}
+
+	return nil
+}
+
+// checkExpectedClaims checks the token use, issuer, and audience of the claims against the expected values, if any.
+func (t *TokenConfig) checkExpectedClaims(claims jwt.MapClaims) error {
+	if err := t.checkTokenUse(claims); err != nil {
+		return err
+	}
+
+	if t.expectedIssuer != "" {
+		if issuer, _ := claims["iss"].(string); issuer != t.expectedIssuer {
+			return ErrIssuerMismatch
+		}
+	}
+
+	if len(t.expectedAudiences) > 0 && !t.matchAudiences(claims["aud"]) {
+		return ErrAudienceMismatch
+	}
+
+	return nil
+}
+
+// matchAudiences reports whether the aud claim contains any or all of the expected audiences, per the match mode.
+func (t *TokenConfig) matchAudiences(claim interface{}) bool {
+	for _, audience := range t.expectedAudiences {
+		found := hasAudience(claim, audience)
+		if found && t.audienceMatch == MatchAny {
+			return true
+		}
+		if !found && t.audienceMatch == MatchAll {
+			return false
+		}
+	}
+
+	return t.audienceMatch == MatchAll
+}
+
+// hasAudience reports whether the aud claim, a string or an array of strings, contains the audience.
+func hasAudience(claim interface{}, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	case []string:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Validate checks the token held by the configuration and reports why it is invalid.
+// Returns ErrTokenExpired, ErrTokenNotYetValid, ErrSignatureInvalid, ErrClaimsInvalid, or ErrTokenInvalid for malformed tokens.
+// Errors raised while looking up the verification key, and issuer or audience mismatches, are returned as is.
+// Tokens older than WithMaxAge fail with ErrTokenTooOld, revoked tokens with ErrTokenRevoked, outdated tokens
+// with ErrTokenVersionStale, and claim validators run once the token is otherwise valid.
+func (t *TokenConfig) Validate() error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	if t.token == nil {
+		return ErrTokenNotGenerated
+	}
+
+	return t.validateToken(*t.token)
+}
+
+// ValidateString checks the provided token string and reports why it is invalid, like Validate.
+// The token held by the configuration is left untouched.
+func (t *TokenConfig) ValidateString(tokenString string) error {
+	if t.closed {
+		return ErrConfigClosed
+	}
+
+	return t.validateToken(tokenString)
+}
+
+// validateToken verifies a token string and classifies the failure as described by Validate.
+func (t *TokenConfig) validateToken(tokenString string) error {
+	_, err := t.validateClaims(tokenString)
+	return err
+}
+
+// validateClaims verifies a token string like validateToken, returning its claims when it is valid.
+func (t *TokenConfig) validateClaims(tokenString string) (jwt.MapClaims, error) {
+	token, err := t.verifyToken(tokenString)
+	if err == nil {
+		claims := token.Claims.(jwt.MapClaims)
+		if t.faults != nil {
+			if err := t.faults.expiredToken(claims, ErrTokenExpired); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := t.checkMaxAge(claims); err != nil {
+			return nil, err
+		}
+
+		if err := t.checkRevocation(claims); err != nil {
+			return nil, err
+		}
+
+		if err := t.checkFamilyRevocation(claims); err != nil {
+			return nil, err
+		}
+
+		if err := t.checkTokenVersion(claims); err != nil {
+			return nil, err
+		}
+
+		if err := t.runClaimValidators(claims); err != nil {
+			return nil, err
+		}
+
+		return claims, nil
+	}
+
+	validationErr, ok := err.(*jwt.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	if inner := keyLookupError(validationErr.Inner); inner != nil {
+		return nil, inner
+	}
+
+	switch {
+	case validationErr.Errors&jwt.ValidationErrorMalformed != 0:
+		return nil, ErrTokenInvalid
+	case validationErr.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) != 0:
+		return nil, ErrSignatureInvalid
+	case validationErr.Errors&jwt.ValidationErrorExpired != 0:
+		return nil, ErrTokenExpired
+	case validationErr.Errors&jwt.ValidationErrorNotValidYet != 0:
+		return nil, ErrTokenNotYetValid
+	default:
+		return nil, ErrClaimsInvalid
+	}
+}
+
+// LikelyWrongKey reports whether the error means the token is well formed but its signature does not match the key.
+// It usually means the token was signed with another secret, e.g. by a different environment, rather than corrupted.
+func LikelyWrongKey(err error) bool {
+	if errors.Is(err, ErrSignatureInvalid) {
+		return true
+	}
+
+	var validationErr *jwt.ValidationError
+	return errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0
+}
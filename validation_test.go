@@ -0,0 +1,301 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func signTestClaims(t *testing.T, claims jwt.MapClaims) string {
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(1 * time.Hour).Unix()
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+	if err != nil {
+		t.Fatalf("Unexpected error signing token: %v", err)
+	}
+
+	return token
+}
+
+func newExpectingVerifier(t *testing.T, token string, options ...func(*TokenConfig) error) *TokenConfig {
+	config, err := NewVerifier(append(options, SecretKey(secretKey), WithToken(token))...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestExpectedIssuer(t *testing.T) {
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+	}{
+		{"matching issuer", jwt.MapClaims{"iss": "hydrate"}, nil},
+		{"missing issuer", jwt.MapClaims{}, ErrIssuerMismatch},
+		{"wrong issuer", jwt.MapClaims{"iss": "other"}, ErrIssuerMismatch},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, signTestClaims(t, c.claims), WithExpectedIssuer("hydrate"))
+
+		if _, err := config.ParseToken(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if _, err := config.ExtractClaims(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if valid := config.IsValid(); valid != (c.expected == nil) {
+			t.Errorf("%s: Expected IsValid to be %v", c.name, c.expected == nil)
+		}
+	}
+}
+
+func TestExpectedAudience(t *testing.T) {
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+	}{
+		{"string audience", jwt.MapClaims{"aud": "api"}, nil},
+		{"array audience", jwt.MapClaims{"aud": []string{"web", "api"}}, nil},
+		{"missing audience", jwt.MapClaims{}, ErrAudienceMismatch},
+		{"wrong string audience", jwt.MapClaims{"aud": "web"}, ErrAudienceMismatch},
+		{"wrong array audience", jwt.MapClaims{"aud": []string{"web", "admin"}}, ErrAudienceMismatch},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, signTestClaims(t, c.claims), WithExpectedAudience("api"))
+
+		if _, err := config.ParseToken(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if _, err := config.ExtractClaims(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if valid := config.IsValid(); valid != (c.expected == nil) {
+			t.Errorf("%s: Expected IsValid to be %v", c.name, c.expected == nil)
+		}
+	}
+}
+
+func TestExpectedIssuerAndAudience(t *testing.T) {
+	token := signTestClaims(t, jwt.MapClaims{"iss": "hydrate", "aud": "api"})
+	config := newExpectingVerifier(t, token, WithExpectedIssuer("hydrate"), WithExpectedAudience("api"))
+
+	if _, err := config.ParseTokenString(token); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if _, err := NewVerifier(SecretKey(secretKey), WithExpectedIssuer("")); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestNotBefore(t *testing.T) {
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+	}{
+		{"future nbf", jwt.MapClaims{"nbf": time.Now().Add(1 * time.Hour).Unix()}, ErrTokenNotYetValid},
+		{"past nbf", jwt.MapClaims{"nbf": time.Now().Add(-1 * time.Hour).Unix()}, nil},
+		{"absent nbf", jwt.MapClaims{}, nil},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, signTestClaims(t, c.claims))
+
+		if _, err := config.ParseToken(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if valid := config.IsValid(); valid != (c.expected == nil) {
+			t.Errorf("%s: Expected IsValid to be %v", c.name, c.expected == nil)
+		}
+	}
+}
+
+func TestNotBeforeEncodings(t *testing.T) {
+	future := time.Now().Add(1 * time.Hour).Unix()
+
+	for _, nbf := range []interface{}{float64(future), json.Number(strconv.FormatInt(future, 10)), future} {
+		if value, ok := numericClaim(nbf); !ok || value != future {
+			t.Errorf("Expected nbf %v (%T) to be read as %d", nbf, nbf, future)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := signTestClaims(t, jwt.MapClaims{"sub": "user"})
+	tampered := valid[:len(valid)-4] + "AAAA"
+	if tampered == valid {
+		tampered = valid[:len(valid)-4] + "BBBB"
+	}
+
+	cases := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"valid token", valid, nil},
+		{"expired token", signTestClaims(t, jwt.MapClaims{"exp": time.Now().Add(-1 * time.Hour).Unix()}), ErrTokenExpired},
+		{"future nbf", signTestClaims(t, jwt.MapClaims{"nbf": time.Now().Add(1 * time.Hour).Unix()}), ErrTokenNotYetValid},
+		{"future iat", signTestClaims(t, jwt.MapClaims{"iat": time.Now().Add(1 * time.Hour).Unix()}), ErrClaimsInvalid},
+		{"tampered signature", tampered, ErrSignatureInvalid},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, c.token)
+
+		if err := config.Validate(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if valid := config.IsValid(); valid != (c.expected == nil) {
+			t.Errorf("%s: Expected IsValid to be %v", c.name, c.expected == nil)
+		}
+	}
+
+	config, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := config.Validate(); err != ErrTokenNotGenerated {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenNotGenerated, err)
+	}
+}
+
+var errInactiveTenant = errors.New("tenant is not active")
+
+func TestClaimValidators(t *testing.T) {
+	var calls []string
+	requireTenant := func(claims jwt.MapClaims) error {
+		calls = append(calls, "tenant")
+		if _, ok := claims["tenant_id"].(string); !ok {
+			return ErrCustomClaimsMissing
+		}
+		return nil
+	}
+	activeTenant := func(claims jwt.MapClaims) error {
+		calls = append(calls, "active")
+		if claims["tenant_id"] != "acme" {
+			return errInactiveTenant
+		}
+		return nil
+	}
+
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+		calls    []string
+	}{
+		{"valid tenant", jwt.MapClaims{"tenant_id": "acme"}, nil, []string{"tenant", "active"}},
+		{"missing tenant", jwt.MapClaims{}, ErrCustomClaimsMissing, []string{"tenant"}},
+		{"inactive tenant", jwt.MapClaims{"tenant_id": "globex"}, errInactiveTenant, []string{"tenant", "active"}},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, signTestClaims(t, c.claims),
+			WithClaimValidator(requireTenant), WithClaimValidator(activeTenant))
+
+		calls = nil
+		err := config.Validate()
+
+		if c.expected == nil && err != nil {
+			t.Errorf("%s: Unexpected error: %v", c.name, err)
+		}
+
+		if c.expected != nil && (!errors.Is(err, c.expected) || !errors.Is(err, ErrClaimsInvalid)) {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if !reflect.DeepEqual(calls, c.calls) {
+			t.Errorf("%s: Expected validators %v to run, got %v", c.name, c.calls, calls)
+		}
+
+		if _, err := config.ExtractClaims(); !errors.Is(err, c.expected) {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestClaimValidatorsSkipInvalidTokens(t *testing.T) {
+	called := false
+	token := signTestClaims(t, jwt.MapClaims{"exp": time.Now().Add(-1 * time.Hour).Unix()})
+
+	config := newExpectingVerifier(t, token, WithClaimValidator(func(jwt.MapClaims) error {
+		called = true
+		return nil
+	}))
+
+	if err := config.Validate(); err != ErrTokenExpired || called {
+		t.Errorf("Expected validators not to run on an expired token, got: %v", err)
+	}
+}
+
+func TestClaimValidatorsDoNotAffectGenerateToken(t *testing.T) {
+	config, err := NewToken(
+		SecretKey(secretKey),
+		WithClaimValidator(func(jwt.MapClaims) error { return errInactiveTenant }),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := config.GenerateToken(); err != nil {
+			t.Errorf("Unexpected error generating token: %v", err)
+		}
+	}
+
+	if !errors.Is(config.Validate(), errInactiveTenant) {
+		t.Errorf("Expected the validator to reject the generated token")
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	maxAge := 24 * time.Hour
+	cases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+	}{
+		{"iat inside the window", jwt.MapClaims{"iat": time.Now().Add(-maxAge + time.Minute).Unix()}, nil},
+		{"iat outside the window", jwt.MapClaims{"iat": time.Now().Add(-maxAge - time.Minute).Unix()}, ErrTokenTooOld},
+		{"missing iat", jwt.MapClaims{}, ErrTokenTooOld},
+	}
+
+	for _, c := range cases {
+		config := newExpectingVerifier(t, signTestClaims(t, c.claims), WithMaxAge(maxAge))
+
+		if err := config.Validate(); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+
+		if valid := config.IsValid(); valid != (c.expected == nil) {
+			t.Errorf("%s: Expected IsValid to be %v", c.name, c.expected == nil)
+		}
+	}
+
+	if _, err := NewVerifier(SecretKey(secretKey), WithMaxAge(0)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
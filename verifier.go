@@ -0,0 +1,36 @@
+package hydrate
+
+import "github.com/golang-jwt/jwt"
+
+// NewVerifier instantiates a TokenConfig that can only verify tokens.
+// It accepts a public key or a shared secret; GenerateToken and RefreshToken return ErrSigningNotConfigured.
+// If a private key is provided, ErrInvalidTokenConfig is returned.
+func NewVerifier(options ...func(*TokenConfig) error) (*TokenConfig, error) {
+	verifier, err := NewToken(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if verifier.privateKey != nil {
+		verifier.Close()
+		return nil, ErrInvalidTokenConfig
+	}
+
+	verifier.verifyOnly = true
+
+	return verifier, nil
+}
+
+// WithToken sets a token issued elsewhere as the token held by the configuration.
+// The token is verified when it is parsed, validated, or its claims are extracted.
+func WithToken(tokenString string) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		claims := make(jwt.MapClaims)
+		if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, claims); err != nil {
+			return ErrTokenInvalid
+		}
+
+		t.setToken(tokenString, claims)
+		return nil
+	}
+}
@@ -0,0 +1,206 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func setupRSAIssuer(t *testing.T) ([]byte, string) {
+	privatePEM, publicPEM := encodeRSAKeys(t, setupRSAKey(t), false)
+
+	issuer, err := NewToken(
+		WithRSAKeys(privatePEM, publicPEM),
+		WithStandardClaims(jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "test",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	token, err := issuer.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	return publicPEM, string(token)
+}
+
+func TestVerifierWithPublicKey(t *testing.T) {
+	publicPEM, token := setupRSAIssuer(t)
+
+	verifier, err := NewVerifier(
+		WithRSAPublicKey(publicPEM),
+		WithToken(token),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	if !verifier.IsValid() {
+		t.Errorf("Expected token to be valid")
+	}
+
+	claims, err := verifier.ExtractClaims()
+	if err != nil {
+		t.Errorf("Unexpected error extracting claims: %v", err)
+	}
+
+	if claims["iss"] != "test" {
+		t.Errorf("Expected iss to be test, got %v", claims["iss"])
+	}
+
+	if _, err := verifier.ParseToken(); err != nil {
+		t.Errorf("Unexpected error parsing token: %v", err)
+	}
+}
+
+func TestVerifierCannotSign(t *testing.T) {
+	publicPEM, token := setupRSAIssuer(t)
+
+	verifier, err := NewVerifier(
+		WithRSAPublicKey(publicPEM),
+		WithToken(token),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	if _, err := verifier.GenerateToken(); err != ErrSigningNotConfigured {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningNotConfigured, err)
+	}
+
+	if _, err := verifier.RefreshToken(verifier); err != ErrSigningNotConfigured {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningNotConfigured, err)
+	}
+}
+
+func TestVerifierWithSharedSecret(t *testing.T) {
+	token, _, err := setupToken(t)
+	if err != nil {
+		return
+	}
+
+	verifier, err := NewVerifier(
+		SecretKey(secretKey),
+		WithToken(string(token)),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	if !verifier.IsValid() {
+		t.Errorf("Expected token to be valid")
+	}
+
+	if _, err := verifier.GenerateToken(); err != ErrSigningNotConfigured {
+		t.Errorf("Expected error: %v, got: %v", ErrSigningNotConfigured, err)
+	}
+}
+
+func TestVerifierRejectsPrivateKey(t *testing.T) {
+	privatePEM, _ := encodeRSAKeys(t, setupRSAKey(t), false)
+
+	_, err := NewVerifier(WithRSAKeys(privatePEM, nil))
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
+
+func TestParseTokenString(t *testing.T) {
+	publicPEM, token := setupRSAIssuer(t)
+
+	verifier, err := NewVerifier(WithRSAPublicKey(publicPEM))
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	parsed, err := verifier.ParseTokenString(token)
+	if err != nil {
+		t.Errorf("Unexpected error parsing token: %v", err)
+	}
+
+	if parsed == nil || !parsed.Valid {
+		t.Errorf("Expected parsed token to be valid")
+	}
+
+	if verifier.token != nil {
+		t.Errorf("Expected ParseTokenString not to store the token")
+	}
+
+	if _, err := verifier.ParseTokenString("not.a.token"); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestExtractClaimsFrom(t *testing.T) {
+	_, config, _ := setupToken(t)
+	held := *config.token
+
+	external := issueToken(t, "client", SecretKey(secretKey))
+
+	claims, err := config.ExtractClaimsFrom(external)
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if claims["iss"] != "client" {
+		t.Errorf("Expected the claims of the provided token, got %v", claims["iss"])
+	}
+
+	if *config.token != held {
+		t.Errorf("Expected ExtractClaimsFrom not to replace the held token")
+	}
+
+	if _, err := config.ExtractClaimsFrom("not.a.token"); err != ErrTokenInvalid {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenInvalid, err)
+	}
+}
+
+func TestValidateString(t *testing.T) {
+	verifier, err := NewVerifier(SecretKey(secretKey))
+	if err != nil {
+		t.Fatalf("Unexpected error creating verifier: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"valid token", issueToken(t, "client", SecretKey(secretKey)), nil},
+		{"other secret", issueToken(t, "client", SecretKey(otherSecretKey)), ErrSignatureInvalid},
+		{"malformed token", "not.a.token", ErrTokenInvalid},
+	}
+
+	for _, c := range cases {
+		if err := verifier.ValidateString(c.token); err != c.expected {
+			t.Errorf("%s: Expected error: %v, got: %v", c.name, c.expected, err)
+		}
+	}
+
+	if verifier.token != nil {
+		t.Errorf("Expected ValidateString not to store the token")
+	}
+
+	verifier.Close()
+	if err := verifier.ValidateString(cases[0].token); err != ErrConfigClosed {
+		t.Errorf("Expected error: %v, got: %v", ErrConfigClosed, err)
+	}
+}
+
+func TestInvalidWithToken(t *testing.T) {
+	_, err := NewVerifier(
+		SecretKey(secretKey),
+		WithToken("garbage"),
+	)
+
+	if !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
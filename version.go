@@ -0,0 +1,119 @@
+package hydrate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// tokenVersionClaim is the claim holding the version of the subject the token was issued for.
+const tokenVersionClaim = "token_version"
+
+// TokenVersionResolver returns the current token version of the subject, e.g. a column of the users table.
+type TokenVersionResolver func(subject string) (int, error)
+
+// WithTokenVersionResolver optionally stamps tokens with the current version of their subject in the "token_version" claim.
+// Validate, IsValid, and ExtractClaims reject tokens whose version is lower than the current one with ErrTokenVersionStale,
+// so bumping the version invalidates every earlier token of the subject. Tokens without a sub claim are not versioned.
+func WithTokenVersionResolver(resolver TokenVersionResolver) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if resolver == nil {
+			return fmt.Errorf("%w: token version resolver cannot be nil", ErrInvalidTokenConfig)
+		}
+
+		t.versionResolver = resolver
+		return nil
+	}
+}
+
+// WithTokenVersionCache optionally remembers the versions returned by the resolver for the duration,
+// trading how fast a bumped version takes effect for fewer resolver calls.
+func WithTokenVersionCache(ttl time.Duration) func(*TokenConfig) error {
+	return func(t *TokenConfig) error {
+		if ttl <= 0 {
+			return fmt.Errorf("%w: token version cache duration must be positive", ErrInvalidTokenConfig)
+		}
+
+		t.versionCache = &versionCache{ttl: ttl, entries: make(map[string]cachedVersion)}
+		return nil
+	}
+}
+
+// versionCache memoizes the token versions of subjects.
+type versionCache struct {
+	ttl     time.Duration            // Lifetime of a cached version
+	entries map[string]cachedVersion // Cached versions by subject
+	lock    sync.Mutex               // Synchronize access to the cached versions
+}
+
+// cachedVersion is a token version and the time it stops being used.
+type cachedVersion struct {
+	version int
+	expires time.Time
+}
+
+// tokenVersion returns the current token version of the subject, from the cache if it is fresh.
+// Resolver errors are wrapped in ErrClaimsInvalid.
+func (t *TokenConfig) tokenVersion(subject string) (int, error) {
+	cache := t.versionCache
+	if cache != nil {
+		cache.lock.Lock()
+		entry, ok := cache.entries[subject]
+		cache.lock.Unlock()
+
+		if ok && t.wallClock().Before(entry.expires) {
+			return entry.version, nil
+		}
+	}
+
+	version, err := t.versionResolver(subject)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrClaimsInvalid, err)
+	}
+
+	if cache != nil {
+		cache.lock.Lock()
+		cache.entries[subject] = cachedVersion{version: version, expires: t.wallClock().Add(cache.ttl)}
+		cache.lock.Unlock()
+	}
+
+	return version, nil
+}
+
+// updateTokenVersion sets the "token_version" claim of the token to the current version of its subject, if versioned.
+func (t *TokenConfig) updateTokenVersion(claims jwt.MapClaims) error {
+	subject, _ := claims["sub"].(string)
+	if t.versionResolver == nil || subject == "" {
+		return nil
+	}
+
+	version, err := t.tokenVersion(subject)
+	if err != nil {
+		return err
+	}
+
+	claims[tokenVersionClaim] = version
+	return nil
+}
+
+// checkTokenVersion rejects claims whose version is lower than the current version of their subject, if versioned.
+// Tokens without a version are treated as version 0.
+func (t *TokenConfig) checkTokenVersion(claims jwt.MapClaims) error {
+	subject, _ := claims["sub"].(string)
+	if t.versionResolver == nil || subject == "" {
+		return nil
+	}
+
+	current, err := t.tokenVersion(subject)
+	if err != nil {
+		return err
+	}
+
+	if version, _ := numericClaim(claims[tokenVersionClaim]); version < int64(current) {
+		return ErrTokenVersionStale
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package hydrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type versionTable struct {
+	versions map[string]int
+	calls    int
+}
+
+func (v *versionTable) resolve(subject string) (int, error) {
+	v.calls++
+	if subject == "ghost" {
+		return 0, errInactiveTenant
+	}
+	return v.versions[subject], nil
+}
+
+func setupVersionedToken(t *testing.T, subject string, options ...func(*TokenConfig) error) *TokenConfig {
+	config, err := NewToken(append(options,
+		SecretKey(secretKey),
+		WithStandardClaims(jwt.StandardClaims{
+			Subject:   subject,
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+		}),
+	)...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return config
+}
+
+func TestTokenVersionBump(t *testing.T) {
+	table := &versionTable{versions: map[string]int{"alice": 3}}
+	config := setupVersionedToken(t, "alice", WithTokenVersionResolver(table.resolve))
+
+	oldToken, err := config.GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	claims, err := config.ExtractClaims()
+	if err != nil {
+		t.Fatalf("Unexpected error extracting claims: %v", err)
+	}
+
+	if version, _ := numericClaim(claims[tokenVersionClaim]); version != 3 {
+		t.Errorf("Expected token version 3, got %v", claims[tokenVersionClaim])
+	}
+
+	table.versions["alice"] = 4
+
+	if err := config.ValidateString(string(oldToken)); err != ErrTokenVersionStale {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenVersionStale, err)
+	}
+
+	if _, err := config.ExtractClaims(); err != ErrTokenVersionStale {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenVersionStale, err)
+	}
+
+	newToken, err := setupVersionedToken(t, "alice", WithTokenVersionResolver(table.resolve)).GenerateToken()
+	if err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	if err := config.ValidateString(string(newToken)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTokenVersionCache(t *testing.T) {
+	table := &versionTable{versions: map[string]int{"alice": 1}}
+	config := setupVersionedToken(t, "alice", WithTokenVersionResolver(table.resolve), WithTokenVersionCache(time.Hour))
+
+	if _, err := config.GenerateToken(); err != nil {
+		t.Fatalf("Unexpected error generating token: %v", err)
+	}
+
+	table.versions["alice"] = 2
+
+	for i := 0; i < 3; i++ {
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected the cached version to be used, got: %v", err)
+		}
+	}
+
+	if table.calls != 1 {
+		t.Errorf("Expected a single resolver call, got %d", table.calls)
+	}
+
+	config.versionCache.entries["alice"] = cachedVersion{version: 1, expires: time.Now().Add(-time.Second)}
+
+	if err := config.Validate(); err != ErrTokenVersionStale {
+		t.Errorf("Expected error: %v, got: %v", ErrTokenVersionStale, err)
+	}
+}
+
+func TestTokenVersionResolverFailure(t *testing.T) {
+	table := &versionTable{}
+	config := setupVersionedToken(t, "ghost", WithTokenVersionResolver(table.resolve))
+
+	if _, err := config.GenerateToken(); !errors.Is(err, ErrClaimsInvalid) || !errors.Is(err, errInactiveTenant) {
+		t.Errorf("Expected error: %v, got: %v", ErrClaimsInvalid, err)
+	}
+
+	unversioned := setupVersionedToken(t, "", WithTokenVersionResolver(table.resolve))
+	if _, err := unversioned.GenerateToken(); err != nil || table.calls != 1 {
+		t.Errorf("Expected tokens without a subject to skip the resolver, got %v after %d calls", err, table.calls)
+	}
+
+	if _, err := NewToken(SecretKey(secretKey), WithTokenVersionResolver(nil)); !errors.Is(err, ErrInvalidTokenConfig) {
+		t.Errorf("Expected error: %v, got: %v", ErrInvalidTokenConfig, err)
+	}
+}
@@ -0,0 +1,117 @@
+package hydrate
+
+import (
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ClaimsView is a read-only index of the string, bool, integer, and string list claims of a token.
+// It is built once per verification so hot authorization paths read claims without type switches or allocations.
+// Claims of other types, and lists holding anything but strings, are left out of the view.
+type ClaimsView struct {
+	stringKeys   []string   // Sorted keys of the string claims
+	stringValues []string   // String claims, in the order of their keys
+	boolKeys     []string   // Sorted keys of the bool claims
+	boolValues   []bool     // Bool claims, in the order of their keys
+	intKeys      []string   // Sorted keys of the integer claims
+	intValues    []int64    // Integer claims, in the order of their keys
+	listKeys     []string   // Sorted keys of the string list claims
+	listValues   [][]string // String list claims, in the order of their keys
+}
+
+// NewClaimsView indexes the claims into a ClaimsView. The view does not change if the claims do.
+// Numbers are read as integers, truncating any fraction, as the time claims are.
+func NewClaimsView(claims jwt.MapClaims) *ClaimsView {
+	keys := make([]string, 0, len(claims))
+	for key := range claims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	view := &ClaimsView{}
+	for _, key := range keys {
+		switch value := claims[key].(type) {
+		case string:
+			view.stringKeys = append(view.stringKeys, key)
+			view.stringValues = append(view.stringValues, value)
+		case bool:
+			view.boolKeys = append(view.boolKeys, key)
+			view.boolValues = append(view.boolValues, value)
+		case []string:
+			view.listKeys = append(view.listKeys, key)
+			view.listValues = append(view.listValues, append([]string(nil), value...))
+		case []interface{}:
+			if list, ok := stringList(value); ok {
+				view.listKeys = append(view.listKeys, key)
+				view.listValues = append(view.listValues, list)
+			}
+		default:
+			if n, ok := numericClaim(value); ok {
+				view.intKeys = append(view.intKeys, key)
+				view.intValues = append(view.intValues, n)
+			}
+		}
+	}
+
+	return view
+}
+
+// GetString returns the string claim, and whether the view holds it.
+func (v *ClaimsView) GetString(key string) (string, bool) {
+	if i, ok := searchKey(v.stringKeys, key); ok {
+		return v.stringValues[i], true
+	}
+	return "", false
+}
+
+// GetBool returns the bool claim, and whether the view holds it.
+func (v *ClaimsView) GetBool(key string) (bool, bool) {
+	if i, ok := searchKey(v.boolKeys, key); ok {
+		return v.boolValues[i], true
+	}
+	return false, false
+}
+
+// GetInt64 returns the integer claim, and whether the view holds it.
+func (v *ClaimsView) GetInt64(key string) (int64, bool) {
+	if i, ok := searchKey(v.intKeys, key); ok {
+		return v.intValues[i], true
+	}
+	return 0, false
+}
+
+// GetStrings returns the string list claim, and whether the view holds it.
+// The returned slice is shared with the view and must not be modified.
+func (v *ClaimsView) GetStrings(key string) ([]string, bool) {
+	if i, ok := searchKey(v.listKeys, key); ok {
+		return v.listValues[i], true
+	}
+	return nil, false
+}
+
+// HasString reports whether the string list claim contains the value.
+func (v *ClaimsView) HasString(key, value string) bool {
+	list, _ := v.GetStrings(key)
+	return containsString(list, value)
+}
+
+// searchKey returns the index of the key in the sorted keys, and whether it was found.
+func searchKey(keys []string, key string) (int, bool) {
+	i := sort.SearchStrings(keys, key)
+	return i, i < len(keys) && keys[i] == key
+}
+
+// stringList converts a decoded JSON array to a slice of strings, if every element is a string.
+func stringList(values []interface{}) ([]string, bool) {
+	list := make([]string, len(values))
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		list[i] = s
+	}
+
+	return list, true
+}
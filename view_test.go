@@ -0,0 +1,178 @@
+package hydrate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func decodedClaims(t testing.TB, claims jwt.MapClaims) jwt.MapClaims {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	decoded := make(jwt.MapClaims)
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return decoded
+}
+
+func TestClaimsView(t *testing.T) {
+	view := NewClaimsView(decodedClaims(t, jwt.MapClaims{
+		"sub":   "user",
+		"admin": true,
+		"exp":   1700000000,
+		"roles": []string{"admin", "user"},
+		"mixed": []interface{}{"a", 1},
+		"meta":  map[string]interface{}{"a": "b"},
+	}))
+
+	if sub, ok := view.GetString("sub"); !ok || sub != "user" {
+		t.Errorf("Expected sub to be user, got %q", sub)
+	}
+
+	if admin, ok := view.GetBool("admin"); !ok || !admin {
+		t.Errorf("Expected admin to be true")
+	}
+
+	if exp, ok := view.GetInt64("exp"); !ok || exp != 1700000000 {
+		t.Errorf("Expected exp to be 1700000000, got %d", exp)
+	}
+
+	if !view.HasString("roles", "admin") || view.HasString("roles", "owner") {
+		t.Errorf("Expected roles to hold only admin and user")
+	}
+
+	for _, key := range []string{"mixed", "meta", "missing"} {
+		if _, ok := view.GetStrings(key); ok {
+			t.Errorf("Expected %s to be left out of the view", key)
+		}
+	}
+
+	if _, ok := view.GetString("exp"); ok {
+		t.Errorf("Expected exp not to be read as a string")
+	}
+}
+
+func TestClaimsViewConsistency(t *testing.T) {
+	consistent := func(strs map[string]string, bools map[string]bool, ints map[string]int32, lists map[string][]string) bool {
+		claims := make(jwt.MapClaims)
+		for key, value := range strs {
+			claims["s"+key] = value
+		}
+		for key, value := range bools {
+			claims["b"+key] = value
+		}
+		for key, value := range ints {
+			claims["i"+key] = value
+		}
+		for key, value := range lists {
+			claims["l"+key] = value
+		}
+
+		claims = decodedClaims(t, claims)
+		view := NewClaimsView(claims)
+
+		for key, value := range claims {
+			s, sok := view.GetString(key)
+			b, bok := view.GetBool(key)
+			n, nok := view.GetInt64(key)
+			l, lok := view.GetStrings(key)
+
+			switch key[0] {
+			case 's':
+				if !sok || s != value || bok || nok || lok {
+					return false
+				}
+			case 'b':
+				if !bok || b != value || sok || nok || lok {
+					return false
+				}
+			case 'i':
+				if expected, _ := numericClaim(value); !nok || n != expected || sok || bok || lok {
+					return false
+				}
+			case 'l':
+				if !lok || !reflect.DeepEqual(l, stringSlice(value)) || sok || bok || nok {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(consistent, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimsViewGettersDoNotAllocate(t *testing.T) {
+	view := NewClaimsView(benchmarkClaims(t))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		evaluateViewPolicy(view)
+	})
+
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func benchmarkClaims(t testing.TB) jwt.MapClaims {
+	return decodedClaims(t, jwt.MapClaims{
+		"sub":       "user",
+		"tenant_id": "acme",
+		"verified":  true,
+		"exp":       1700000000,
+		"roles":     []string{"user", "admin"},
+		"name":      "Ada",
+		"locale":    "en",
+	})
+}
+
+// evaluateMapPolicy checks five claims reading jwt.MapClaims directly.
+func evaluateMapPolicy(claims jwt.MapClaims) bool {
+	sub, _ := claims["sub"].(string)
+	tenant, _ := claims["tenant_id"].(string)
+	verified, _ := claims["verified"].(bool)
+	exp, _ := numericClaim(claims["exp"])
+
+	return sub != "" && tenant == "acme" && verified && exp > 0 && HasRole(claims, "admin")
+}
+
+// evaluateViewPolicy checks the same five claims as evaluateMapPolicy through a ClaimsView.
+func evaluateViewPolicy(view *ClaimsView) bool {
+	sub, _ := view.GetString("sub")
+	tenant, _ := view.GetString("tenant_id")
+	verified, _ := view.GetBool("verified")
+	exp, _ := view.GetInt64("exp")
+
+	return sub != "" && tenant == "acme" && verified && exp > 0 && view.HasString("roles", "admin")
+}
+
+func BenchmarkPolicyMapClaims(b *testing.B) {
+	claims := benchmarkClaims(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		evaluateMapPolicy(claims)
+	}
+}
+
+func BenchmarkPolicyClaimsView(b *testing.B) {
+	view := NewClaimsView(benchmarkClaims(b))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		evaluateViewPolicy(view)
+	}
+}